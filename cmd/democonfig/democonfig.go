@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"log"
@@ -35,10 +36,61 @@ func NewBasicApp() BasicApp {
 
 // todo - ignoring errors in the demo app for the moment
 
-func (a *BasicApp) download(writer http.ResponseWriter, _ *http.Request) {
+// defaultBundle is served at /bundles/bundle.tar.gz and receives uploads posted to /bundles.
+const defaultBundle = "bundle"
+
+type Bundles struct {
+	Bundles []Bundle `json:"bundles"`
+}
+
+// Bundle is an available bundle, the path being relative to the server and the package that of its policy.rego.
+type Bundle struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Package string `json:"package"`
+}
+
+func (a *BasicApp) bundles() string {
 	_, file, _, _ := runtime.Caller(0)
-	bundles := filepath.Join(file, "../resources/bundles")
-	tar, _ := compressionsupport.TarFromPath(fmt.Sprintf("%s/%s", bundles, a.latest(bundles)))
+	return filepath.Join(file, "../resources/bundles")
+}
+
+func (a *BasicApp) name(r *http.Request) string {
+	if name, found := mux.Vars(r)["name"]; found {
+		return name
+	}
+	return defaultBundle
+}
+
+func (a *BasicApp) list(writer http.ResponseWriter, _ *http.Request) {
+	list := Bundles{Bundles: make([]Bundle, 0)}
+	for _, bundle := range a.available(a.bundles()) {
+		dir := filepath.Join(a.bundles(), bundle.Name())
+		if !bundle.IsDir() || len(a.available(dir)) == 0 {
+			continue
+		}
+		list.Bundles = append(list.Bundles, Bundle{
+			Name:    bundle.Name(),
+			Path:    fmt.Sprintf("/bundles/%s.tar.gz", bundle.Name()),
+			Package: a.regoPackage(filepath.Join(dir, a.latest(dir))),
+		})
+	}
+	sort.Slice(list.Bundles, func(i, j int) bool {
+		return list.Bundles[i].Name < list.Bundles[j].Name
+	})
+	data, _ := json.Marshal(list)
+	writer.Header().Set("content-type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write(data)
+}
+
+func (a *BasicApp) download(writer http.ResponseWriter, r *http.Request) {
+	dir := filepath.Join(a.bundles(), a.name(r))
+	if len(a.available(dir)) == 0 {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	tar, _ := compressionsupport.TarFromPath(filepath.Join(dir, a.latest(dir)))
 	_ = compressionsupport.Gzip(writer, tar)
 }
 
@@ -50,6 +102,9 @@ func (a *BasicApp) latest(dir string) string {
 func (a *BasicApp) available(dir string) []fs.FileInfo {
 	available := make([]fs.FileInfo, 0)
 	_ = fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
 		info, _ := d.Info()
 		if info.Name() == "." {
 			return nil
@@ -63,37 +118,53 @@ func (a *BasicApp) available(dir string) []fs.FileInfo {
 	return available
 }
 
+// regoPackage reads the package declared by the bundle version's policy.rego.
+func (a *BasicApp) regoPackage(version string) string {
+	rego, _ := os.ReadFile(filepath.Join(version, "bundle/policy.rego"))
+	for _, line := range strings.Split(string(rego), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "package" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
 func (a *BasicApp) upload(writer http.ResponseWriter, r *http.Request) {
 	_ = r.ParseMultipartForm(32 << 20)
 	bundleFile, _, _ := r.FormFile("bundle")
 	gzip, _ := compressionsupport.UnGzip(bundleFile)
-	_, file, _, _ := runtime.Caller(0)
 	rand.Seed(time.Now().UnixNano())
-	path := filepath.Join(file, fmt.Sprintf("../resources/bundles/.bundle-%d", rand.Uint64()))
+	path := filepath.Join(a.bundles(), a.name(r), fmt.Sprintf(".bundle-%d", rand.Uint64()))
 	_ = compressionsupport.UnTarToPath(bytes.NewReader(gzip), path)
 	writer.WriteHeader(http.StatusCreated)
 }
 
 func (a *BasicApp) reset(writer http.ResponseWriter, r *http.Request) {
-	_, file, _, _ := runtime.Caller(0)
-	bundles := filepath.Join(file, "../resources/bundles")
-	for _, available := range a.available(bundles) {
-		if strings.Index(available.Name(), ".bundle") == 0 {
-			path := filepath.Join(bundles, available.Name())
-			err := os.RemoveAll(path)
-			if err != nil {
-				log.Printf("Unable to remove bundle %v", path)
-				writer.WriteHeader(http.StatusInternalServerError)
-				return
+	for _, bundle := range a.available(a.bundles()) {
+		dir := filepath.Join(a.bundles(), bundle.Name())
+		for _, available := range a.available(dir) {
+			if strings.Index(available.Name(), ".bundle") == 0 {
+				path := filepath.Join(dir, available.Name())
+				err := os.RemoveAll(path)
+				if err != nil {
+					log.Printf("Unable to remove bundle %v", path)
+					writer.WriteHeader(http.StatusInternalServerError)
+					return
+				}
 			}
 		}
+		if len(a.available(dir)) == 0 {
+			_ = os.Remove(dir)
+		}
 	}
 }
 
 func (a *BasicApp) loadHandlers() func(router *mux.Router) {
 	return func(router *mux.Router) {
-		router.HandleFunc("/bundles/bundle.tar.gz", a.download).Methods("GET")
+		router.HandleFunc("/bundles", a.list).Methods("GET")
+		router.HandleFunc("/bundles/{name:[A-Za-z0-9_-]+}.tar.gz", a.download).Methods("GET")
 		router.HandleFunc("/bundles", a.upload).Methods("POST")
+		router.HandleFunc("/bundles/{name:[A-Za-z0-9_-]+}", a.upload).Methods("POST")
 		router.HandleFunc("/reset", a.reset).Methods("GET")
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"net"
@@ -54,7 +55,7 @@ func TestUpload(t *testing.T) {
 	app := setup()
 
 	_, file, _, _ := runtime.Caller(0)
-	bundleDir := filepath.Join(file, "../resources/bundles")
+	bundleDir := filepath.Join(file, "../resources/bundles/bundle/default")
 	tar, _ := compressionsupport.TarFromPath(bundleDir)
 	var buffer bytes.Buffer
 	_ = compressionsupport.Gzip(&buffer, tar)
@@ -73,6 +74,62 @@ func TestUpload(t *testing.T) {
 	websupport.Stop(app)
 }
 
+func upload(t *testing.T, app *http.Server, path string) {
+	_, file, _, _ := runtime.Caller(0)
+	tar, _ := compressionsupport.TarFromPath(filepath.Join(file, "../resources/bundles/bundle/default"))
+	var buffer bytes.Buffer
+	_ = compressionsupport.Gzip(&buffer, tar)
+
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+	formFile, _ := writer.CreateFormFile("bundle", "bundle.tar.gz")
+	_, _ = formFile.Write(buffer.Bytes())
+	_ = writer.Close()
+
+	response, _ := http.Post(fmt.Sprintf("http://%s%s", app.Addr, path), writer.FormDataContentType(), buf)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+}
+
+func TestList(t *testing.T) {
+	app := setup()
+	response, _ := http.Get(fmt.Sprintf("http://%s/bundles", app.Addr))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	var list Bundles
+	_ = json.NewDecoder(response.Body).Decode(&list)
+	assert.Equal(t, []Bundle{{Name: "bundle", Path: "/bundles/bundle.tar.gz", Package: "authz"}}, list.Bundles)
+	websupport.Stop(app)
+}
+
+func TestUpload_withName(t *testing.T) {
+	app := setup()
+	upload(t, app, "/bundles/anotherService")
+
+	response, _ := http.Get(fmt.Sprintf("http://%s/bundles", app.Addr))
+	var list Bundles
+	_ = json.NewDecoder(response.Body).Decode(&list)
+	assert.Equal(t, []Bundle{
+		{Name: "anotherService", Path: "/bundles/anotherService.tar.gz", Package: "authz"},
+		{Name: "bundle", Path: "/bundles/bundle.tar.gz", Package: "authz"},
+	}, list.Bundles)
+
+	response, _ = http.Get(fmt.Sprintf("http://%s/bundles/anotherService.tar.gz", app.Addr))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	files, _ := compressionsupport.UnTarGzip(response.Body)
+	assert.Contains(t, files, "bundle/data.json")
+
+	_, _ = http.Get(fmt.Sprintf("http://%s/reset", app.Addr))
+	response, _ = http.Get(fmt.Sprintf("http://%s/bundles/anotherService.tar.gz", app.Addr))
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	websupport.Stop(app)
+}
+
+func TestDownload_withUnknownBundle(t *testing.T) {
+	app := setup()
+	response, _ := http.Get(fmt.Sprintf("http://%s/bundles/unknown.tar.gz", app.Addr))
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	websupport.Stop(app)
+}
+
 func TestReset(t *testing.T) {
 	app := setup()
 	response, _ := http.Get(fmt.Sprintf("http://%s/reset", app.Addr))
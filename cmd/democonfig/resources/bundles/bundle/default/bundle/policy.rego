@@ -3,10 +3,20 @@ import future.keywords.in
 default allow = false
 allow {
     anyMatching
+    not anyDenying
 }
 anyMatching {
     some i
 	matches(data.bundle.policies[i])
+	not denies(data.bundle.policies[i])
+}
+anyDenying {
+    some i
+	matches(data.bundle.policies[i])
+	denies(data.bundle.policies[i])
+}
+denies(policy) {
+    policy.effect == "deny"
 }
 matches(policy) {
     matchesAction(policy.actions[i])
@@ -15,6 +25,10 @@ matches(policy) {
 matchesAction(action) {
     input.method == action.action_uri
 }
+matchesAction(action) {
+    endswith(action.action_uri, "*")
+    startswith(input.method, trim_suffix(action.action_uri, "*"))
+}
 matchesPrincipal(subject) {
     "allusers" in subject.members
 }
@@ -8,8 +8,10 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/databasesupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/encryptionsupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/hawksupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/healthsupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
@@ -48,6 +50,25 @@ func (s ServerHealthCheck) Check() bool {
 	return true
 }
 
+type LeaderHealthCheck struct {
+	Leader *workflowsupport.Leader
+}
+
+func (l LeaderHealthCheck) Name() string {
+	return "leader"
+}
+
+// Check fails when no replica currently leads the scheduler.
+func (l LeaderHealthCheck) Check() bool {
+	current, err := l.Leader.Current()
+	return err == nil && current != ""
+}
+
+func (l LeaderHealthCheck) Details() map[string]string {
+	current, _ := l.Leader.Current()
+	return map[string]string{"leader": current, "replica": l.Leader.Holder}
+}
+
 func App(key string, addr string, hostPort string, dbUrl string) (*http.Server, *workflowsupport.WorkScheduler) {
 	db, _ := databasesupport.Open(dbUrl)
 	store := hawksupport.NewCredentialStore(key)
@@ -56,15 +77,45 @@ func App(key string, addr string, hostPort string, dbUrl string) (*http.Server,
 	providers["azure"] = &microsoftazure.AzureProvider{}
 	providers["amazon"] = &amazonwebservices.AmazonProvider{}
 	providers["open_policy_agent"] = &openpolicyagent.OpaProvider{}
-	handlers, scheduler := orchestrator.LoadHandlers(db, store, hostPort, providers)
+	options := orchestrator.Options{ReapplyDrift: os.Getenv("ORCHESTRATOR_REAPPLY_DRIFT") == "true", Envelope: masterKeyEnvelope(), MissingGracePeriod: missingGracePeriod(), Replica: os.Getenv("ORCHESTRATOR_REPLICA")}
+	handlers, scheduler := orchestrator.LoadHandlers(db, store, hostPort, providers, options)
 	return websupport.Create(addr, handlers, websupport.Options{
 		HealthChecks: []healthsupport.HealthCheck{
 			ServerHealthCheck{},
 			DatabaseHealthCheck{db},
+			LeaderHealthCheck{scheduler.Leader},
 		},
 	}), scheduler
 }
 
+func masterKeyEnvelope() *encryptionsupport.Envelope {
+	masterKey := os.Getenv("ORCHESTRATOR_MASTER_KEY")
+	if masterKey == "" {
+		if os.Getenv("ORCHESTRATOR_UNENCRYPTED_KEYS") != "true" {
+			panic("missing ORCHESTRATOR_MASTER_KEY, set ORCHESTRATOR_UNENCRYPTED_KEYS=true to store integration keys unencrypted")
+		}
+		log.Println("Missing ORCHESTRATOR_MASTER_KEY, integration keys will be stored unencrypted.")
+		return nil
+	}
+	envelope, err := encryptionsupport.NewEnvelopeFromHex(masterKey)
+	if err != nil {
+		panic(err.Error())
+	}
+	return envelope
+}
+
+func missingGracePeriod() time.Duration {
+	found := os.Getenv("ORCHESTRATOR_MISSING_GRACE_PERIOD")
+	if found == "" {
+		return orchestrator.DefaultMissingGracePeriod
+	}
+	gracePeriod, err := time.ParseDuration(found)
+	if err != nil {
+		panic(err.Error())
+	}
+	return gracePeriod
+}
+
 func newApp(addr string) (*http.Server, net.Listener, *workflowsupport.WorkScheduler) {
 	if found := os.Getenv("PORT"); found != "" {
 		host, _, _ := net.SplitHostPort(addr)
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/databasesupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/encryptionsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
+)
+
+// Rotate re-wraps integration keys held by the previous master key with the new master key and
+// encrypts any keys stored before encryption was enabled. The previous key may be empty on first use.
+func Rotate(dbUrl string, masterKey string, previousMasterKey string) (int, error) {
+	envelope, err := encryptionsupport.NewEnvelopeFromHex(masterKey)
+	if err != nil {
+		return 0, err
+	}
+	var previous *encryptionsupport.Envelope
+	if previousMasterKey != "" {
+		if previous, err = encryptionsupport.NewEnvelopeFromHex(previousMasterKey); err != nil {
+			return 0, err
+		}
+	}
+
+	db, _ := databasesupport.Open(dbUrl)
+	defer func() {
+		_ = db.Close()
+	}()
+	return orchestrator.IntegrationsDataGateway{DB: db, Envelope: envelope}.RotateKeys(previous)
+}
+
+func main() {
+	rotated, err := Rotate(os.Getenv("POSTGRESQL_URL"), os.Getenv("ORCHESTRATOR_MASTER_KEY"), os.Getenv("ORCHESTRATOR_PREVIOUS_MASTER_KEY"))
+	if err != nil {
+		log.Fatalf("Unable to rotate integration keys: %s", err.Error())
+	}
+	log.Printf("Rotated %d integration keys.", rotated)
+}
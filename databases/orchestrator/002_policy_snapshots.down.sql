@@ -0,0 +1 @@
+drop table if exists policy_snapshots;
\ No newline at end of file
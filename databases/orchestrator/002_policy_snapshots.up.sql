@@ -0,0 +1,11 @@
+create table policy_snapshots (
+    id             uuid not null primary key default gen_random_uuid(),
+    application_id uuid not null,
+    phase          varchar(16),
+    reason         varchar(255),
+    policies       jsonb,
+    created_at     timestamp default now(),
+    constraint fk_application
+        foreign key (application_id)
+            references applications (id) on delete cascade
+);
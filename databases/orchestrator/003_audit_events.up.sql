@@ -0,0 +1,16 @@
+create table audit_events (
+    id            uuid not null primary key default gen_random_uuid(),
+    actor         varchar(255) not null,
+    action        varchar(64) not null,
+    resource_type varchar(32) not null,
+    resource_id   varchar(255),
+    payload_hash  varchar(64) not null,
+    outcome       varchar(16) not null,
+    status        integer not null,
+    created_at    timestamp not null default now()
+);
+
+create index audit_events_created_at on audit_events (created_at);
+
+create rule audit_events_no_update as on update to audit_events do instead nothing;
+create rule audit_events_no_delete as on delete to audit_events do instead nothing;
@@ -0,0 +1 @@
+drop table if exists desired_policies;
\ No newline at end of file
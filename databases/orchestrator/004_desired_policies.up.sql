@@ -0,0 +1,12 @@
+create table desired_policies (
+    application_id uuid not null primary key,
+    policies       jsonb not null,
+    updated_at     timestamp not null default now(),
+    drift_status   varchar(16) not null default 'unknown',
+    drift          jsonb,
+    drift_message  text,
+    checked_at     timestamp,
+    constraint fk_application
+        foreign key (application_id)
+            references applications (id) on delete cascade
+);
@@ -0,0 +1,3 @@
+alter table integrations
+    drop column if exists data_key,
+    drop column if exists master_key_id;
\ No newline at end of file
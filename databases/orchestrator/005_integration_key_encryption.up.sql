@@ -0,0 +1,3 @@
+alter table integrations
+    add column data_key      bytea,
+    add column master_key_id varchar(16);
@@ -0,0 +1,3 @@
+alter table applications
+    drop column if exists missing,
+    drop column if exists last_seen_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+alter table applications
+    add column last_seen_at timestamp not null default now(),
+    add column missing      boolean   not null default false;
@@ -0,0 +1,4 @@
+alter table applications
+    drop constraint if exists uq_integration_object_id,
+    drop column if exists updated_at,
+    alter column created_at drop not null;
\ No newline at end of file
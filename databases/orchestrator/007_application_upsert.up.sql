@@ -0,0 +1,6 @@
+update applications set created_at = now() where created_at is null;
+
+alter table applications
+    alter column created_at set not null,
+    add column updated_at timestamp not null default now(),
+    add constraint uq_integration_object_id unique (integration_id, object_id);
@@ -0,0 +1 @@
+drop table if exists integration_discovery;
\ No newline at end of file
@@ -0,0 +1,11 @@
+create table integration_discovery (
+    integration_id    uuid    not null primary key,
+    interval_seconds  integer not null default 60,
+    last_run_at       timestamp,
+    duration_ms       integer not null default 0,
+    application_count integer not null default 0,
+    last_error        text,
+    constraint fk_integration
+        foreign key (integration_id)
+            references integrations (id) on delete cascade
+);
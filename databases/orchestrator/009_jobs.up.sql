@@ -0,0 +1,16 @@
+create table jobs (
+    id           uuid not null primary key default gen_random_uuid(),
+    kind         varchar(64) not null,
+    key          varchar(255),
+    payload      bytea,
+    state        varchar(16) not null default 'queued',
+    attempts     integer not null default 0,
+    max_attempts integer not null default 5,
+    run_at       timestamp not null default now(),
+    last_error   text,
+    created_at   timestamp not null default now(),
+    updated_at   timestamp not null default now()
+);
+
+create unique index jobs_active_key on jobs (kind, key) where state in ('queued', 'running', 'failed');
+create index jobs_due on jobs (run_at) where state in ('queued', 'failed');
@@ -0,0 +1,2 @@
+alter table jobs drop column if exists claimed_by;
+drop table if exists leases;
\ No newline at end of file
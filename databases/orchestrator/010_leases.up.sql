@@ -0,0 +1,8 @@
+create table leases (
+    name        varchar(64) not null primary key,
+    holder      varchar(255) not null,
+    acquired_at timestamp not null default now(),
+    expires_at  timestamp not null
+);
+
+alter table jobs add column claimed_by varchar(255);
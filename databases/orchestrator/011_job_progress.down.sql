@@ -0,0 +1,3 @@
+drop index if exists jobs_kind_created_at;
+alter table jobs drop column if exists result;
+alter table jobs drop column if exists progress;
\ No newline at end of file
@@ -0,0 +1,4 @@
+alter table jobs add column progress integer not null default 0;
+alter table jobs add column result jsonb;
+
+create index jobs_kind_created_at on jobs (kind, created_at);
@@ -0,0 +1,2 @@
+drop table if exists sync_rule_runs;
+drop table if exists sync_rules;
\ No newline at end of file
@@ -0,0 +1,30 @@
+create table sync_rules (
+    id               uuid not null primary key default gen_random_uuid(),
+    name             varchar(255) not null,
+    source_id        uuid not null,
+    targets          uuid[] not null default '{}',
+    selector         jsonb,
+    interval_seconds integer not null default 3600,
+    options          jsonb not null default '{}',
+    enabled          boolean not null default true,
+    created_at       timestamp not null default now(),
+    updated_at       timestamp not null default now(),
+    constraint fk_source
+        foreign key (source_id)
+            references applications (id) on delete cascade
+);
+
+create table sync_rule_runs (
+    id          uuid not null primary key default gen_random_uuid(),
+    rule_id     uuid not null,
+    started_at  timestamp not null,
+    duration_ms integer not null default 0,
+    succeeded   boolean not null,
+    results     jsonb not null default '[]',
+    error       text,
+    constraint fk_rule
+        foreign key (rule_id)
+            references sync_rules (id) on delete cascade
+);
+
+create index sync_rule_runs_rule_started_at on sync_rule_runs (rule_id, started_at desc);
@@ -0,0 +1 @@
+drop table if exists mappings;
\ No newline at end of file
@@ -0,0 +1,11 @@
+create table mappings (
+    id            uuid not null primary key default gen_random_uuid(),
+    kind          varchar(16) not null,
+    from_provider varchar(255) not null,
+    from_value    varchar(1024) not null,
+    to_provider   varchar(255) not null,
+    to_value      varchar(1024) not null,
+    created_at    timestamp not null default now(),
+    constraint mappings_kind check (kind in ('member', 'action')),
+    constraint mappings_unique unique (kind, from_provider, from_value, to_provider)
+);
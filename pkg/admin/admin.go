@@ -14,12 +14,25 @@ type Client interface {
 	Health() (string, error)
 	Integrations() ([]Integration, error)
 	CreateIntegration(name string, provider string, key []byte) error
+	TestIntegration(provider string, key []byte) error
+	UpdateIntegration(id string, name string, key []byte) error
 	DeleteIntegration(id string) error
 	Applications() ([]Application, error)
 	Application(id string) (Application, error)
 	GetPolicies(id string) ([]Policy, string, error)
 	SetPolicies(id string, policies string) error
 	Orchestration(from string, to string) error
+	OrchestrationPreview(from string, to string) (OrchestrationDiff, error)
+	OrchestrationJobs() ([]OrchestrationJob, error)
+	SyncRules() ([]SyncRule, error)
+	CreateSyncRule(rule SyncRule) error
+	UpdateSyncRule(rule SyncRule) error
+	DeleteSyncRule(id string) error
+	RunSyncRule(id string) error
+	Mappings() ([]Mapping, error)
+	CreateMapping(mapping Mapping) error
+	DeleteMapping(id string) error
+	AuditEvents(filter AuditFilter) ([]AuditEvent, error)
 }
 
 func IndexHandler(w http.ResponseWriter, r *http.Request) {
@@ -31,12 +44,18 @@ func LoadHandlers(orchestratorUrl string, client Client) func(router *mux.Router
 	integrations := NewIntegrationsHandler(orchestratorUrl, client)
 	orchestration := NewOrchestrationHandler(orchestratorUrl, client)
 	status := NewStatusHandler(orchestratorUrl, client)
+	audit := NewAuditHandler(orchestratorUrl, client)
+	syncRules := NewSyncRulesHandler(orchestratorUrl, client)
+	mappings := NewMappingsHandler(orchestratorUrl, client)
 
 	return func(router *mux.Router) {
 		router.HandleFunc("/", IndexHandler).Methods("GET")
 		router.HandleFunc("/integrations", integrations.List).Methods("GET")
 		router.HandleFunc("/integrations/new", integrations.New).Methods("GET").Queries("provider", "{provider}")
 		router.HandleFunc("/integrations", integrations.CreateIntegration).Methods("POST")
+		router.HandleFunc("/integrations/test", integrations.TestIntegration).Methods("POST")
+		router.HandleFunc("/integrations/{id}/edit", integrations.Edit).Methods("GET")
+		router.HandleFunc("/integrations/{id}/edit", integrations.Update).Methods("POST")
 		router.HandleFunc("/integrations/{id}", integrations.Delete).Methods("POST")
 		router.HandleFunc("/applications", apps.List).Methods("GET")
 		router.HandleFunc("/applications/{id}", apps.Show).Methods("GET")
@@ -44,7 +63,18 @@ func LoadHandlers(orchestratorUrl string, client Client) func(router *mux.Router
 		router.HandleFunc("/applications/{id}/edit", apps.Edit).Methods("GET")
 		router.HandleFunc("/applications/{id}", apps.Update).Methods("POST")
 		router.HandleFunc("/orchestration/new", orchestration.New).Methods("GET")
+		router.HandleFunc("/orchestration/preview", orchestration.Preview).Methods("POST")
 		router.HandleFunc("/orchestration", orchestration.Update).Methods("POST")
+		router.HandleFunc("/orchestration/jobs", orchestration.Jobs).Methods("GET")
+		router.HandleFunc("/sync-rules", syncRules.List).Methods("GET")
+		router.HandleFunc("/sync-rules", syncRules.Create).Methods("POST")
+		router.HandleFunc("/sync-rules/{id}/toggle", syncRules.Toggle).Methods("POST")
+		router.HandleFunc("/sync-rules/{id}/run", syncRules.Run).Methods("POST")
+		router.HandleFunc("/sync-rules/{id}", syncRules.Delete).Methods("POST")
+		router.HandleFunc("/mappings", mappings.List).Methods("GET")
+		router.HandleFunc("/mappings", mappings.Create).Methods("POST")
+		router.HandleFunc("/mappings/{id}", mappings.Delete).Methods("POST")
+		router.HandleFunc("/audit", audit.List).Methods("GET")
 		router.HandleFunc("/status", status.StatusHandler).Methods("GET")
 
 		fileServer := http.FileServer(http.Dir("pkg/admin/resources/static"))
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
@@ -18,17 +19,23 @@ type Application struct {
 	Name          string
 	Description   string
 	ProviderName  string
+	Missing       bool
+	LastSeenAt    time.Time
 }
 
 type Policy struct {
-	Meta    Meta     `validate:"required"`
-	Actions []Action `validate:"required"`
-	Subject Subject  `validate:"required"`
-	Object  Object   `validate:"required"`
+	ID        string
+	Meta      Meta     `validate:"required"`
+	Actions   []Action `validate:"required"`
+	Subject   Subject  `validate:"required"`
+	Object    Object   `validate:"required"`
+	Effect    string
+	Condition Condition
 }
 
 type Meta struct {
-	Version string `validate:"required"`
+	Version  string `validate:"required"`
+	Revision string
 }
 
 type Action struct {
@@ -43,6 +50,10 @@ type Object struct {
 	ResourceID string `validate:"required"`
 }
 
+type Condition struct {
+	Rule string
+}
+
 type ApplicationsHandler interface {
 	List(w http.ResponseWriter, r *http.Request)
 	Show(w http.ResponseWriter, r *http.Request)
@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/admin"
 	"github.com/hexa-org/policy-orchestrator/pkg/admin/test"
@@ -66,6 +67,21 @@ func (suite *ApplicationsSuite) TestApplications() {
 	assert.Contains(suite.T(), string(body), "Google Cloud Platform")
 }
 
+func (suite *ApplicationsSuite) TestApplications_withMissingApplication() {
+	lastSeen := time.Date(2022, 6, 1, 12, 30, 0, 0, time.UTC)
+	suite.client.DesiredApplications = []admin.Application{
+		{ID: "anId", ObjectId: "anObjectId", Name: "aName", ProviderName: "google_cloud", Missing: true, LastSeenAt: lastSeen},
+	}
+
+	resp, _ := http.Get(fmt.Sprintf("http://%s/applications", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Missing, last seen 2022-06-01 12:30")
+
+	resp, _ = http.Get(fmt.Sprintf("http://%s/applications/anId", suite.server.Addr))
+	body, _ = io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "No longer found by discovery, last seen 2022-06-01 12:30.")
+}
+
 func (suite *ApplicationsSuite) TestApplications_with_error() {
 	suite.client.Errs = map[string]error{"http://noop/applications": errors.New("oops")}
 
@@ -79,8 +95,8 @@ func (suite *ApplicationsSuite) TestApplication() {
 		{ID: "anotherId", IntegrationId: "anotherIntegrationId", ObjectId: "anotherObjectId", Name: "anotherName", Description: "anotherDescription", ProviderName: "google_cloud"},
 	}
 	suite.client.DesiredPolicies = []admin.Policy{
-		{admin.Meta{Version: "aVersion"}, []admin.Action{{"anAction"}}, admin.Subject{Members: []string{"aUser"}}, admin.Object{ResourceID: "aResourceId"}},
-		{admin.Meta{Version: "anotherVersion"}, []admin.Action{{"anotherAction"}}, admin.Subject{Members: []string{"anotherUser"}}, admin.Object{ResourceID: "anotherResourceId"}},
+		{ID: "aPolicyId", Meta: admin.Meta{Version: "aVersion"}, Actions: []admin.Action{{ActionUri: "anAction"}}, Subject: admin.Subject{Members: []string{"aUser"}}, Object: admin.Object{ResourceID: "aResourceId"}},
+		{Meta: admin.Meta{Version: "anotherVersion"}, Actions: []admin.Action{{ActionUri: "anotherAction"}}, Subject: admin.Subject{Members: []string{"anotherUser"}}, Object: admin.Object{ResourceID: "anotherResourceId"}, Effect: "deny", Condition: admin.Condition{Rule: "req.ip sw 127"}},
 	}
 
 	identifier := "anotherId"
@@ -91,6 +107,7 @@ func (suite *ApplicationsSuite) TestApplication() {
 	assert.Contains(suite.T(), string(body), "anotherName")
 	assert.Contains(suite.T(), string(body), "anotherDescription")
 
+	assert.Contains(suite.T(), string(body), "aPolicyId")
 	assert.Contains(suite.T(), string(body), "aVersion")
 	assert.Contains(suite.T(), string(body), "anAction")
 	assert.Contains(suite.T(), string(body), "aUser")
@@ -100,6 +117,8 @@ func (suite *ApplicationsSuite) TestApplication() {
 	assert.Contains(suite.T(), string(body), "anotherAction")
 	assert.Contains(suite.T(), string(body), "anotherUser")
 	assert.Contains(suite.T(), string(body), "anotherResourceId")
+	assert.Contains(suite.T(), string(body), "Deny")
+	assert.Contains(suite.T(), string(body), "req.ip sw 127")
 }
 
 func (suite *ApplicationsSuite) TestApplication_withErroneousGet() {
@@ -131,8 +150,8 @@ func (suite *ApplicationsSuite) TestApplication_Edit() {
 		{ID: "anotherId", IntegrationId: "anotherIntegrationId", ObjectId: "anotherObjectId", Name: "anotherName", Description: "anotherDescription", ProviderName: "google_cloud"},
 	}
 	suite.client.DesiredPolicies = []admin.Policy{
-		{admin.Meta{Version: "aVersion"}, []admin.Action{{"anAction"}}, admin.Subject{Members: []string{"aUser"}}, admin.Object{ResourceID: "aResourceId"}},
-		{admin.Meta{Version: "anotherVersion"}, []admin.Action{{"anotherAction"}}, admin.Subject{Members: []string{"anotherUser"}}, admin.Object{ResourceID: "anotherResourceId"}},
+		{ID: "aPolicyId", Meta: admin.Meta{Version: "aVersion"}, Actions: []admin.Action{{ActionUri: "anAction"}}, Subject: admin.Subject{Members: []string{"aUser"}}, Object: admin.Object{ResourceID: "aResourceId"}},
+		{Meta: admin.Meta{Version: "anotherVersion"}, Actions: []admin.Action{{ActionUri: "anotherAction"}}, Subject: admin.Subject{Members: []string{"anotherUser"}}, Object: admin.Object{ResourceID: "anotherResourceId"}},
 	}
 
 	identifier := "anotherId"
@@ -173,7 +192,7 @@ func (suite *ApplicationsSuite) TestApplication_Update() {
 		{ID: "anId", IntegrationId: "anIntegrationId", ObjectId: "anObjectId", Name: "aName", Description: "aDescription", ProviderName: "google_cloud"},
 	}
 	suite.client.DesiredPolicies = []admin.Policy{
-		{admin.Meta{Version: "aVersion"}, []admin.Action{{"anAction"}}, admin.Subject{Members: []string{"aUser"}}, admin.Object{ResourceID: "aResourceId"}},
+		{Meta: admin.Meta{Version: "aVersion"}, Actions: []admin.Action{{ActionUri: "anAction"}}, Subject: admin.Subject{Members: []string{"aUser"}}, Object: admin.Object{ResourceID: "aResourceId"}},
 	}
 
 	identifier := "anId"
@@ -216,7 +235,7 @@ func (suite *ApplicationsSuite) TestApplication_Policies() {
 		{ID: "anId", IntegrationId: "anIntegrationId", ObjectId: "anObjectId", Name: "aName", Description: "aDescription", ProviderName: "google_cloud"},
 	}
 	suite.client.DesiredPolicies = []admin.Policy{
-		{admin.Meta{Version: "aVersion"}, []admin.Action{{"anAction"}}, admin.Subject{Members: []string{"aUser"}}, admin.Object{ResourceID: "aResourceId"}},
+		{Meta: admin.Meta{Version: "aVersion"}, Actions: []admin.Action{{ActionUri: "anAction"}}, Subject: admin.Subject{Members: []string{"aUser"}}, Object: admin.Object{ResourceID: "aResourceId"}},
 	}
 
 	identifier := "anId"
@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
+)
+
+type AuditEvent struct {
+	ID           string
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceId   string
+	PayloadHash  string
+	Outcome      string
+	Status       int
+	CreatedAt    time.Time
+}
+
+// AuditFilter holds optional RFC 3339 from and to times along with actor and resource filters.
+type AuditFilter struct {
+	From     string
+	To       string
+	Actor    string
+	Resource string
+}
+
+type AuditHandler interface {
+	List(w http.ResponseWriter, r *http.Request)
+}
+
+type auditHandler struct {
+	orchestratorUrl string
+	client          Client
+}
+
+func NewAuditHandler(orchestratorUrl string, client Client) AuditHandler {
+	return auditHandler{orchestratorUrl, client}
+}
+
+// datetimeLocal is the format submitted by datetime-local inputs, times are taken as UTC.
+const datetimeLocal = "2006-01-02T15:04"
+
+func (p auditHandler) List(w http.ResponseWriter, r *http.Request) {
+	form := map[string]string{"from": r.FormValue("from"), "to": r.FormValue("to"), "actor": r.FormValue("actor"), "resource": r.FormValue("resource")}
+	filter := AuditFilter{Actor: form["actor"], Resource: form["resource"]}
+	for name, found := range map[string]*string{"from": &filter.From, "to": &filter.To} {
+		if form[name] == "" {
+			continue
+		}
+		parsed, err := time.Parse(datetimeLocal, form[name])
+		if err != nil {
+			model := websupport.Model{Map: map[string]interface{}{"resource": "audit", "filter": form, "message": fmt.Sprintf("Invalid %s time.", name)}}
+			_ = websupport.ModelAndView(w, &resources, "audit", model)
+			return
+		}
+		*found = parsed.UTC().Format(time.RFC3339)
+	}
+
+	events, clientErr := p.client.AuditEvents(filter)
+	if clientErr != nil {
+		model := websupport.Model{Map: map[string]interface{}{"resource": "audit", "filter": form, "message": clientErr.Error()}}
+		_ = websupport.ModelAndView(w, &resources, "audit", model)
+		log.Println(clientErr)
+		return
+	}
+	model := websupport.Model{Map: map[string]interface{}{"resource": "audit", "filter": form, "events": events}}
+	_ = websupport.ModelAndView(w, &resources, "audit", model)
+}
@@ -0,0 +1,79 @@
+package admin_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/admin"
+	"github.com/hexa-org/policy-orchestrator/pkg/admin/test"
+	"github.com/hexa-org/policy-orchestrator/pkg/healthsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditSuite struct {
+	suite.Suite
+	server *http.Server
+	client *admin_test.MockClient
+}
+
+func TestAudit(t *testing.T) {
+	suite.Run(t, new(AuditSuite))
+}
+
+func (suite *AuditSuite) SetupTest() {
+	listener, _ := net.Listen("tcp", "localhost:0")
+	suite.client = &admin_test.MockClient{Url: "http://noop"}
+	suite.server = websupport.Create(
+		listener.Addr().String(),
+		admin.LoadHandlers("http://noop", suite.client),
+		websupport.Options{})
+	go websupport.Start(suite.server, listener)
+	healthsupport.WaitForHealthy(suite.server)
+}
+
+func (suite *AuditSuite) TearDownTest() {
+	websupport.Stop(suite.server)
+}
+
+func (suite *AuditSuite) TestList() {
+	suite.client.DesiredEvents = []admin.AuditEvent{
+		{ID: "anId", Actor: "anActor", Action: "integration.delete", ResourceType: "integration", ResourceId: "anIntegrationId", PayloadHash: "aHash", Outcome: "success", Status: 200, CreatedAt: time.Date(2022, 5, 1, 10, 30, 0, 0, time.UTC)},
+	}
+	resp, _ := http.Get(fmt.Sprintf("http://%s/audit", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Audit")
+	assert.Contains(suite.T(), string(body), "2022-05-01 10:30:00")
+	assert.Contains(suite.T(), string(body), "anActor")
+	assert.Contains(suite.T(), string(body), "integration.delete")
+	assert.Contains(suite.T(), string(body), "integration anIntegrationId")
+	assert.Contains(suite.T(), string(body), "success (200)")
+	assert.Equal(suite.T(), admin.AuditFilter{}, suite.client.Filter)
+}
+
+func (suite *AuditSuite) TestList_withFilter() {
+	resp, _ := http.Get(fmt.Sprintf("http://%s/audit?from=2022-05-01T10:30&to=&actor=anActor&resource=anAppId", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(suite.T(), admin.AuditFilter{From: "2022-05-01T10:30:00Z", Actor: "anActor", Resource: "anAppId"}, suite.client.Filter)
+	assert.Contains(suite.T(), string(body), "value=\"2022-05-01T10:30\"")
+	assert.Contains(suite.T(), string(body), "value=\"anActor\"")
+}
+
+func (suite *AuditSuite) TestList_withBadTime() {
+	resp, _ := http.Get(fmt.Sprintf("http://%s/audit?to=yesterday", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. Invalid to time.")
+}
+
+func (suite *AuditSuite) TestList_withError() {
+	suite.client.Errs = map[string]error{"http://noop/audit": errors.New("oops")}
+	resp, _ := http.Get(fmt.Sprintf("http://%s/audit", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. oops")
+}
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"errors"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -17,16 +18,20 @@ type IntegrationProviderInterface interface {
 }
 
 type Integration struct {
-	ID       string
-	Name     string
-	Provider string
-	Key      []byte
+	ID             string
+	Name           string
+	Provider       string
+	KeyFingerprint string
+	KeyError       string
 }
 
 type IntegrationHandler interface {
 	List(w http.ResponseWriter, r *http.Request)
 	New(w http.ResponseWriter, r *http.Request)
 	CreateIntegration(w http.ResponseWriter, r *http.Request)
+	TestIntegration(w http.ResponseWriter, r *http.Request)
+	Edit(w http.ResponseWriter, r *http.Request)
+	Update(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
 }
 
@@ -107,13 +112,126 @@ func (i integrationsHandler) CreateIntegration(w http.ResponseWriter, r *http.Re
 
 	err = i.client.CreateIntegration(name, provider, key)
 	if err != nil {
-		model := websupport.Model{Map: map[string]interface{}{"resource": "integrations", "provider": provider, "message": "Unable to communicate with orchestrator."}}
+		model := websupport.Model{Map: map[string]interface{}{"resource": "integrations", "provider": provider, "message": "Unable to communicate with orchestrator. " + err.Error()}}
 		_ = websupport.ModelAndView(w, &resources, integrationView, model)
 		return
 	}
 	http.Redirect(w, r, "/integrations", http.StatusMovedPermanently)
 }
 
+// TestIntegration asks the orchestrator to check a key with its provider, the key is not saved.
+func (i integrationsHandler) TestIntegration(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	provider := r.FormValue("provider")
+	integrationView := i.knownIntegrationViews(provider)
+
+	file, _, err := r.FormFile("key")
+	if err != nil {
+		i.viewWithMessage(w, provider, "Missing key file.", integrationView)
+		return
+	}
+	key, err := ioutil.ReadAll(file)
+	_ = file.Close()
+	if err != nil {
+		i.viewWithMessage(w, provider, "Unable to read key file.", integrationView)
+		return
+	}
+
+	if err = i.client.TestIntegration(provider, key); err != nil {
+		i.viewWithMessage(w, provider, "Connection failed. "+err.Error(), integrationView)
+		return
+	}
+	model := websupport.Model{Map: map[string]interface{}{"resource": "integrations", "provider": provider, "notice": "Connection successful."}}
+	_ = websupport.ModelAndView(w, &resources, integrationView, model)
+}
+
+func (i integrationsHandler) Edit(w http.ResponseWriter, r *http.Request) {
+	found, err := i.integration(mux.Vars(r)["id"])
+	if err != nil {
+		model := websupport.Model{Map: map[string]interface{}{"resource": "integrations", "message": err.Error()}}
+		_ = websupport.ModelAndView(w, &resources, "integrations", model)
+		return
+	}
+	model := websupport.Model{Map: map[string]interface{}{"resource": "integrations", "integration": found}}
+	_ = websupport.ModelAndView(w, &resources, "integrations_edit", model)
+}
+
+// Update renames an integration and optionally replaces its key, a new key must be readable by the integration's provider.
+func (i integrationsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	found, err := i.integration(mux.Vars(r)["id"])
+	if err != nil {
+		model := websupport.Model{Map: map[string]interface{}{"resource": "integrations", "message": err.Error()}}
+		_ = websupport.ModelAndView(w, &resources, "integrations", model)
+		return
+	}
+	if err = r.ParseMultipartForm(32 << 20); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	editWithMessage := func(message string) {
+		model := websupport.Model{Map: map[string]interface{}{"resource": "integrations", "integration": found, "message": message}}
+		_ = websupport.ModelAndView(w, &resources, "integrations_edit", model)
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	var key []byte
+	file, _, fileErr := r.FormFile("key")
+	if fileErr == nil {
+		key, err = ioutil.ReadAll(file)
+		_ = file.Close()
+		if err != nil {
+			editWithMessage("Unable to read key file.")
+			return
+		}
+
+		var foundProvider IntegrationProviderInterface
+		for _, p := range i.providerStructs {
+			if p.detect(found.Provider) {
+				foundProvider = p
+			}
+		}
+		if foundProvider == nil {
+			editWithMessage("unknown provider")
+			return
+		}
+		derivedName, nameErr := foundProvider.name(key)
+		if nameErr != nil {
+			editWithMessage(nameErr.Error())
+			return
+		}
+		if name == "" {
+			name = derivedName
+		}
+	}
+	if name == "" {
+		editWithMessage("Missing name.")
+		return
+	}
+
+	if err = i.client.UpdateIntegration(found.ID, name, key); err != nil {
+		editWithMessage("Unable to update integration. " + err.Error())
+		return
+	}
+	http.Redirect(w, r, "/integrations", http.StatusMovedPermanently)
+}
+
+func (i integrationsHandler) integration(id string) (Integration, error) {
+	integrations, err := i.client.Integrations()
+	if err != nil {
+		log.Println(err)
+		return Integration{}, errors.New("unable to contact orchestrator")
+	}
+	for _, found := range integrations {
+		if found.ID == id {
+			return found, nil
+		}
+	}
+	return Integration{}, errors.New("unknown integration")
+}
+
 func (i integrationsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	identifier := mux.Vars(r)["id"]
 	err := i.client.DeleteIntegration(identifier)
@@ -48,6 +48,7 @@ func (suite *IntegrationsSuite) TestListIntegrations() {
 	resp := suite.must(http.Get(fmt.Sprintf("http://%s/integrations", suite.server.Addr)))
 	body, _ := io.ReadAll(resp.Body)
 	assert.Contains(suite.T(), string(body), "Discovery")
+	assert.Contains(suite.T(), string(body), "sha256:aFingerprint")
 }
 
 func (suite *IntegrationsSuite) TestListIntegrations_templateRenders() {
@@ -148,6 +149,31 @@ func (suite *IntegrationsSuite) TestCreateIntegration_withErroneousKeyFile() {
 	assert.Contains(suite.T(), string(all), "Something went wrong. unable to read key file, missing project")
 }
 
+func (suite *IntegrationsSuite) TestTestIntegration() {
+	buf, contentType := suite.multipartFormSuccessAzure()
+	resp := suite.must(http.Post(fmt.Sprintf("http://%s/integrations/test", suite.server.Addr), contentType, buf))
+	all, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(all), "Install Azure Provider")
+	assert.Contains(suite.T(), string(all), "Connection successful.")
+	assert.Equal(suite.T(), "azure", suite.client.Provider)
+	assert.Equal(suite.T(), "", suite.client.Name)
+}
+
+func (suite *IntegrationsSuite) TestTestIntegration_withFailedCheck() {
+	suite.client.Errs = map[string]error{"http://noop/integrations/test": errors.New("unable to authenticate with azure tenant aTenant")}
+	buf, contentType := suite.multipartFormSuccessAzure()
+	resp := suite.must(http.Post(fmt.Sprintf("http://%s/integrations/test", suite.server.Addr), contentType, buf))
+	all, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(all), "Something went wrong. Connection failed. unable to authenticate with azure tenant aTenant")
+}
+
+func (suite *IntegrationsSuite) TestTestIntegration_withMissingKeyFile() {
+	buf, contentType := suite.multipartFormMissingFile()
+	resp := suite.must(http.Post(fmt.Sprintf("http://%s/integrations/test", suite.server.Addr), contentType, buf))
+	all, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(all), "Something went wrong. Missing key file.")
+}
+
 func (suite *IntegrationsSuite) TestDeleteIntegration() {
 	suite.client.On("DeleteIntegration", "http://noop/integrations/101").Return()
 	resp := suite.must(http.Post(fmt.Sprintf("http://%s/integrations/101", suite.server.Addr), "", nil))
@@ -227,3 +253,80 @@ func (suite *IntegrationsSuite) multipartForm(writeFormValues func(writer *multi
 	_ = writer.Close()
 	return buf, contentType
 }
+
+func (suite *IntegrationsSuite) TestEditIntegration() {
+	resp := suite.must(http.Get(fmt.Sprintf("http://%s/integrations/anId/edit", suite.server.Addr)))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Edit Integration")
+	assert.Contains(suite.T(), string(body), "value=\"aName\"")
+	assert.Contains(suite.T(), string(body), "sha256:aFingerprint")
+}
+
+func (suite *IntegrationsSuite) TestEditIntegration_withUnknownId() {
+	resp := suite.must(http.Get(fmt.Sprintf("http://%s/integrations/anotherId/edit", suite.server.Addr)))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. unknown integration")
+}
+
+func (suite *IntegrationsSuite) TestUpdateIntegration() {
+	buf, contentType := suite.multipartForm(func(writer *multipart.Writer) {
+		name, _ := writer.CreateFormField("name")
+		_, _ = name.Write([]byte("anotherName"))
+
+		file, _ := writer.CreateFormFile("key", "aKey.json")
+		_, _ = file.Write([]byte("{\"type\": \"service_account\", \"project_id\": \"google-cloud-project-id\"}"))
+	})
+	resp := suite.must(http.Post(fmt.Sprintf("http://%s/integrations/anId/edit", suite.server.Addr), contentType, buf))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Discovery")
+	assert.Equal(suite.T(), "anotherName", suite.client.Name)
+	assert.Contains(suite.T(), string(suite.client.Key), "google-cloud-project-id")
+}
+
+func (suite *IntegrationsSuite) TestUpdateIntegration_withoutKey() {
+	buf, contentType := suite.multipartForm(func(writer *multipart.Writer) {
+		name, _ := writer.CreateFormField("name")
+		_, _ = name.Write([]byte("anotherName"))
+	})
+	_ = suite.must(http.Post(fmt.Sprintf("http://%s/integrations/anId/edit", suite.server.Addr), contentType, buf))
+	assert.Equal(suite.T(), "anotherName", suite.client.Name)
+	assert.Nil(suite.T(), suite.client.Key)
+}
+
+func (suite *IntegrationsSuite) TestUpdateIntegration_withEmptyNameUsesKey() {
+	buf, contentType := suite.multipartForm(func(writer *multipart.Writer) {
+		file, _ := writer.CreateFormFile("key", "aKey.json")
+		_, _ = file.Write([]byte("{\"type\": \"service_account\", \"project_id\": \"google-cloud-project-id\"}"))
+	})
+	_ = suite.must(http.Post(fmt.Sprintf("http://%s/integrations/anId/edit", suite.server.Addr), contentType, buf))
+	assert.Equal(suite.T(), "project:google-cloud-project-id", suite.client.Name)
+}
+
+func (suite *IntegrationsSuite) TestUpdateIntegration_withInvalidKey() {
+	buf, contentType := suite.multipartFormErroneousFile()
+	resp := suite.must(http.Post(fmt.Sprintf("http://%s/integrations/anId/edit", suite.server.Addr), contentType, buf))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. unable to read key file, missing project")
+	assert.Equal(suite.T(), "", suite.client.Name)
+}
+
+func (suite *IntegrationsSuite) TestUpdateIntegration_withMissingName() {
+	buf, contentType := suite.multipartForm(func(writer *multipart.Writer) {
+		name, _ := writer.CreateFormField("name")
+		_, _ = name.Write([]byte(" "))
+	})
+	resp := suite.must(http.Post(fmt.Sprintf("http://%s/integrations/anId/edit", suite.server.Addr), contentType, buf))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. Missing name.")
+}
+
+func (suite *IntegrationsSuite) TestUpdateIntegration_withOrchestratorError() {
+	suite.client.Errs = map[string]error{"http://noop/integrations/anId": errors.New("oops")}
+	buf, contentType := suite.multipartForm(func(writer *multipart.Writer) {
+		name, _ := writer.CreateFormField("name")
+		_, _ = name.Write([]byte("anotherName"))
+	})
+	resp := suite.must(http.Post(fmt.Sprintf("http://%s/integrations/anId/edit", suite.server.Addr), contentType, buf))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. Unable to update integration. oops")
+}
@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
+)
+
+// Mapping replaces a member or action of one provider with its counterpart in another during orchestration.
+type Mapping struct {
+	ID           string
+	Kind         string
+	FromProvider string
+	FromValue    string
+	ToProvider   string
+	ToValue      string
+}
+
+type MappingsHandler interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+type mappingsHandler struct {
+	orchestratorUrl string
+	client          Client
+}
+
+func NewMappingsHandler(orchestratorUrl string, client Client) MappingsHandler {
+	return mappingsHandler{orchestratorUrl, client}
+}
+
+func (h mappingsHandler) List(w http.ResponseWriter, _ *http.Request) {
+	h.render(w, "")
+}
+
+func (h mappingsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	mapping := Mapping{
+		Kind:         r.FormValue("kind"),
+		FromProvider: r.FormValue("from_provider"),
+		FromValue:    strings.TrimSpace(r.FormValue("from_value")),
+		ToProvider:   r.FormValue("to_provider"),
+		ToValue:      strings.TrimSpace(r.FormValue("to_value")),
+	}
+	if err := h.client.CreateMapping(mapping); err != nil {
+		log.Println(err)
+		h.render(w, err.Error())
+		return
+	}
+	http.Redirect(w, r, "/mappings", http.StatusSeeOther)
+}
+
+func (h mappingsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.client.DeleteMapping(mux.Vars(r)["id"]); err != nil {
+		log.Println(err)
+		h.render(w, err.Error())
+		return
+	}
+	http.Redirect(w, r, "/mappings", http.StatusSeeOther)
+}
+
+func (h mappingsHandler) render(w http.ResponseWriter, message string) {
+	mappings, err := h.client.Mappings()
+	if err != nil {
+		log.Println(err)
+		message = err.Error()
+	}
+	model := websupport.Model{Map: map[string]interface{}{"resource": "mappings", "mappings": mappings, "message": message,
+		"providers": []string{"amazon", "azure", "google_cloud", "open_policy_agent"}}}
+	_ = websupport.ModelAndView(w, &resources, "mappings", model)
+}
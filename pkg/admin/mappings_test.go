@@ -0,0 +1,90 @@
+package admin_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/admin"
+	"github.com/hexa-org/policy-orchestrator/pkg/admin/test"
+	"github.com/hexa-org/policy-orchestrator/pkg/healthsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MappingsSuite struct {
+	suite.Suite
+	server *http.Server
+	client *admin_test.MockClient
+}
+
+func TestMappings(t *testing.T) {
+	suite.Run(t, new(MappingsSuite))
+}
+
+func (suite *MappingsSuite) SetupTest() {
+	listener, _ := net.Listen("tcp", "localhost:0")
+	suite.client = &admin_test.MockClient{Url: "http://noop"}
+	suite.server = websupport.Create(
+		listener.Addr().String(),
+		admin.LoadHandlers("http://noop", suite.client),
+		websupport.Options{})
+	go websupport.Start(suite.server, listener)
+	healthsupport.WaitForHealthy(suite.server)
+}
+
+func (suite *MappingsSuite) TearDownTest() {
+	websupport.Stop(suite.server)
+}
+
+func (suite *MappingsSuite) TestList() {
+	suite.client.DesiredMappings = []admin.Mapping{
+		{ID: "aMappingId", Kind: "member", FromProvider: "google_cloud", FromValue: "user:aUser@example.com", ToProvider: "azure", ToValue: "aPrincipalId"},
+	}
+
+	resp, _ := http.Get(fmt.Sprintf("http://%s/mappings", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Mappings")
+	assert.Contains(suite.T(), string(body), "<td>user:aUser@example.com [google_cloud]</td>")
+	assert.Contains(suite.T(), string(body), "<td>aPrincipalId [azure]</td>")
+	assert.Contains(suite.T(), string(body), "/mappings/aMappingId")
+	assert.Contains(suite.T(), string(body), "<option value=\"open_policy_agent\">open_policy_agent</option>")
+}
+
+func (suite *MappingsSuite) TestList_withClientError() {
+	suite.client.Errs = map[string]error{"http://noop/mappings": errors.New("oops")}
+
+	resp, _ := http.Get(fmt.Sprintf("http://%s/mappings", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. oops")
+}
+
+func (suite *MappingsSuite) TestCreate() {
+	form := url.Values{"kind": {"member"}, "from_provider": {"google_cloud"}, "from_value": {" user:aUser@example.com "}, "to_provider": {"azure"}, "to_value": {"aPrincipalId"}}
+
+	resp, _ := http.PostForm(fmt.Sprintf("http://%s/mappings", suite.server.Addr), form)
+	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(suite.T(), "/mappings", resp.Request.URL.Path)
+	assert.Equal(suite.T(), admin.Mapping{Kind: "member", FromProvider: "google_cloud", FromValue: "user:aUser@example.com", ToProvider: "azure", ToValue: "aPrincipalId"}, suite.client.Mapping)
+
+	suite.client.Errs = map[string]error{"POST http://noop/mappings": errors.New("unable to save mapping")}
+	resp, _ = http.PostForm(fmt.Sprintf("http://%s/mappings", suite.server.Addr), form)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. unable to save mapping")
+}
+
+func (suite *MappingsSuite) TestDelete() {
+	resp, _ := http.Post(fmt.Sprintf("http://%s/mappings/aMappingId", suite.server.Addr), "application/json", nil)
+	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(suite.T(), "aMappingId", suite.client.Mapping.ID)
+
+	suite.client.Errs = map[string]error{"DELETE http://noop/mappings/aMappingId": errors.New("oops")}
+	resp, _ = http.Post(fmt.Sprintf("http://%s/mappings/aMappingId", suite.server.Addr), "application/json", nil)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. oops")
+}
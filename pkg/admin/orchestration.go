@@ -3,13 +3,49 @@ package admin
 import (
 	"log"
 	"net/http"
+	"time"
 
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
 )
 
+// OrchestrationDiff lists, as unmapped, the source members that would be left out of the target's policies.
+type OrchestrationDiff struct {
+	policysupport.PolicyDiff
+	Unmapped []string `json:"unmapped"`
+}
+
+type OrchestrationJob struct {
+	ID          string
+	State       string
+	From        string
+	To          string
+	Progress    int
+	Steps       []OrchestrationStep
+	Attempts    int
+	MaxAttempts int
+	Error       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type OrchestrationStep struct {
+	Name     string
+	Status   string
+	Error    string
+	Unmapped []string
+}
+
+// Active reports whether the job is queued, running or awaiting a retry.
+func (j OrchestrationJob) Active() bool {
+	return j.State == "queued" || j.State == "running" || j.State == "failed"
+}
+
 type OrchestrationHandler interface {
 	New(w http.ResponseWriter, r *http.Request)
+	Preview(w http.ResponseWriter, r *http.Request)
 	Update(w http.ResponseWriter, r *http.Request)
+	Jobs(w http.ResponseWriter, r *http.Request)
 }
 
 type orchestrationHandler struct {
@@ -30,15 +66,29 @@ func (p orchestrationHandler) New(w http.ResponseWriter, _ *http.Request) {
 		return
 	}
 
-	/// todo - remove once working across providers
-	available := make([]Application, 0)
-	for _, app := range foundApplications {
-		if app.ProviderName == "google_cloud" || app.ProviderName == "open_policy_agent" {
-			available = append(available, app)
-		}
+	model := websupport.Model{Map: map[string]interface{}{"resource": "orchestration", "applications": foundApplications}}
+	_ = websupport.ModelAndView(w, &resources, "orchestration_new", model)
+}
+
+func (p orchestrationHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	from, to := r.FormValue("from"), r.FormValue("to")
+	foundApplications, clientErr := p.client.Applications()
+	if clientErr != nil {
+		model := websupport.Model{Map: map[string]interface{}{"resource": "orchestration", "message": clientErr.Error()}}
+		_ = websupport.ModelAndView(w, &resources, "orchestration_new", model)
+		log.Println(clientErr)
+		return
+	}
+
+	diff, previewErr := p.client.OrchestrationPreview(from, to)
+	if previewErr != nil {
+		model := websupport.Model{Map: map[string]interface{}{"resource": "orchestration", "applications": foundApplications, "from": from, "to": to, "message": previewErr.Error()}}
+		_ = websupport.ModelAndView(w, &resources, "orchestration_new", model)
+		log.Println(previewErr)
+		return
 	}
 
-	model := websupport.Model{Map: map[string]interface{}{"resource": "orchestration", "applications": available}}
+	model := websupport.Model{Map: map[string]interface{}{"resource": "orchestration", "applications": foundApplications, "from": from, "to": to, "diff": diff}}
 	_ = websupport.ModelAndView(w, &resources, "orchestration_new", model)
 }
 
@@ -50,5 +100,29 @@ func (p orchestrationHandler) Update(w http.ResponseWriter, r *http.Request) {
 		log.Println(clientErr.Error())
 		return
 	}
-	http.Redirect(w, r, "/orchestration/new", http.StatusMovedPermanently)
+	http.Redirect(w, r, "/orchestration/jobs", http.StatusSeeOther)
+}
+
+// Jobs lists recent orchestration jobs, the page refreshes itself while any job is active.
+func (p orchestrationHandler) Jobs(w http.ResponseWriter, _ *http.Request) {
+	jobs, clientErr := p.client.OrchestrationJobs()
+	if clientErr != nil {
+		model := websupport.Model{Map: map[string]interface{}{"resource": "orchestration", "message": clientErr.Error()}}
+		_ = websupport.ModelAndView(w, &resources, "orchestration_jobs", model)
+		log.Println(clientErr)
+		return
+	}
+
+	names := make(map[string]string)
+	if foundApplications, err := p.client.Applications(); err == nil {
+		for _, application := range foundApplications {
+			names[application.ID] = application.Name
+		}
+	}
+	active := false
+	for _, job := range jobs {
+		active = active || job.Active()
+	}
+	model := websupport.Model{Map: map[string]interface{}{"resource": "orchestration", "jobs": jobs, "names": names, "active": active}}
+	_ = websupport.ModelAndView(w, &resources, "orchestration_jobs", model)
 }
@@ -6,11 +6,13 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"testing"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/admin"
 	"github.com/hexa-org/policy-orchestrator/pkg/admin/test"
 	"github.com/hexa-org/policy-orchestrator/pkg/healthsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -55,6 +57,19 @@ func (suite *OrchestrationSuite) TestNewOrchestration() {
 	assert.Contains(suite.T(), string(body), "<option value=\"anotherId\">")
 }
 
+func (suite *OrchestrationSuite) TestNewOrchestration_acrossProviders() {
+	suite.client.DesiredApplications = []admin.Application{
+		{ID: "anId", IntegrationId: "anIntegrationId", ObjectId: "anObjectId", Name: "aName", Description: "aDescription", ProviderName: "azure"},
+		{ID: "anotherId", IntegrationId: "anotherIntegrationId", ObjectId: "anotherObjectId", Name: "anotherName", Description: "anotherDescription", ProviderName: "amazon"},
+	}
+	resp, _ := http.Get(fmt.Sprintf("http://%s/orchestration/new", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "<option value=\"anId\">")
+	assert.Contains(suite.T(), string(body), "[Azure Cloud Platform]")
+	assert.Contains(suite.T(), string(body), "<option value=\"anotherId\">")
+	assert.Contains(suite.T(), string(body), "[Amazon Web Services]")
+}
+
 func (suite *OrchestrationSuite) TestNewOrchestration_withClientError() {
 	suite.client.Errs = map[string]error{"http://noop/applications": errors.New("oops")}
 
@@ -69,6 +84,40 @@ func (suite *OrchestrationSuite) TestUpdateOrchestration() {
 	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
 }
 
+func (suite *OrchestrationSuite) TestOrchestrationJobs() {
+	suite.client.DesiredApplications = []admin.Application{{ID: "anId", Name: "aName"}}
+	suite.client.DesiredJobs = []admin.OrchestrationJob{
+		{ID: "aJobId", State: "running", From: "anId", To: "anotherId", Progress: 50, Steps: []admin.OrchestrationStep{{Name: "read source policies", Status: "succeeded"}, {Name: "translate policies", Status: "succeeded", Unmapped: []string{"aUser", "anotherUser"}}, {Name: "write target policies", Status: "failed", Error: "oops"}}},
+	}
+
+	resp, _ := http.Get(fmt.Sprintf("http://%s/orchestration/jobs", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Orchestration Jobs")
+	assert.Contains(suite.T(), string(body), "<td>aName</td>")
+	assert.Contains(suite.T(), string(body), "<td>anotherId</td>")
+	assert.Contains(suite.T(), string(body), "running (50%)")
+	assert.Contains(suite.T(), string(body), "translate policies: succeeded (no mapping for aUser, anotherUser)")
+	assert.Contains(suite.T(), string(body), "write target policies: failed (oops)")
+	assert.Contains(suite.T(), string(body), "window.location.reload()")
+}
+
+func (suite *OrchestrationSuite) TestOrchestrationJobs_withoutActiveJobs() {
+	suite.client.DesiredJobs = []admin.OrchestrationJob{{ID: "aJobId", State: "succeeded", Progress: 100}}
+
+	resp, _ := http.Get(fmt.Sprintf("http://%s/orchestration/jobs", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "succeeded (100%)")
+	assert.NotContains(suite.T(), string(body), "window.location.reload()")
+}
+
+func (suite *OrchestrationSuite) TestOrchestrationJobs_withClientError() {
+	suite.client.Errs = map[string]error{"http://noop/orchestration/jobs": errors.New("oops")}
+
+	resp, _ := http.Get(fmt.Sprintf("http://%s/orchestration/jobs", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. oops")
+}
+
 func (suite *OrchestrationSuite) TestUpdateOrchestration_withError() {
 	suite.client.Errs = map[string]error{"http://noop/orchestration": errors.New("oops")}
 
@@ -76,3 +125,46 @@ func (suite *OrchestrationSuite) TestUpdateOrchestration_withError() {
 	body, _ := io.ReadAll(resp.Body)
 	assert.Contains(suite.T(), string(body), "oops")
 }
+
+func (suite *OrchestrationSuite) TestPreviewOrchestration() {
+	suite.client.DesiredApplications = []admin.Application{
+		{ID: "anId", IntegrationId: "anIntegrationId", ObjectId: "anObjectId", Name: "aName", Description: "aDescription", ProviderName: "google_cloud"},
+		{ID: "anotherId", IntegrationId: "anotherIntegrationId", ObjectId: "anotherObjectId", Name: "anotherName", Description: "anotherDescription", ProviderName: "open_policy_agent"},
+	}
+	suite.client.DesiredDiff = admin.OrchestrationDiff{
+		PolicyDiff: policysupport.PolicyDiff{
+			ActionsAdded:   []string{"anAddedAction"},
+			ActionsRemoved: []string{"aRemovedAction"},
+			Members:        []policysupport.MemberDiff{{ActionUri: "anAction", Added: []string{"anAddedUser"}, Removed: []string{"aRemovedUser"}}},
+			Rules:          []policysupport.RuleDiff{{ActionUri: "anotherAction", Added: []string{"deny on \"aResource\""}, Removed: []string{"allow on \"aResource\""}}},
+		},
+		Unmapped: []string{"anUnmappedUser"},
+	}
+
+	resp, _ := http.PostForm(fmt.Sprintf("http://%s/orchestration/preview", suite.server.Addr), url.Values{"from": {"anId"}, "to": {"anotherId"}})
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Proposed Changes")
+	assert.Contains(suite.T(), string(body), "<option value=\"anotherId\" selected>")
+	assert.Contains(suite.T(), string(body), "anAddedAction (action added)")
+	assert.Contains(suite.T(), string(body), "aRemovedAction (action removed)")
+	assert.Contains(suite.T(), string(body), "+ anAddedUser")
+	assert.Contains(suite.T(), string(body), "- aRemovedUser")
+	assert.Contains(suite.T(), string(body), "anotherAction (rules changed)")
+	assert.Contains(suite.T(), string(body), "+ deny on &#34;aResource&#34;")
+	assert.Contains(suite.T(), string(body), "Not applied, no mapping for members anUnmappedUser")
+	assert.Contains(suite.T(), string(body), "<input type=\"hidden\" name=\"to\" value=\"anotherId\"/>")
+}
+
+func (suite *OrchestrationSuite) TestPreviewOrchestration_withNoChanges() {
+	resp, _ := http.PostForm(fmt.Sprintf("http://%s/orchestration/preview", suite.server.Addr), url.Values{"from": {"anId"}, "to": {"anotherId"}})
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "No changes")
+}
+
+func (suite *OrchestrationSuite) TestPreviewOrchestration_withError() {
+	suite.client.Errs = map[string]error{"http://noop/orchestration?dryRun=true": errors.New("oops")}
+
+	resp, _ := http.PostForm(fmt.Sprintf("http://%s/orchestration/preview", suite.server.Addr), url.Values{"from": {"anId"}, "to": {"anotherId"}})
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. oops")
+}
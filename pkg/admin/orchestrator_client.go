@@ -8,7 +8,9 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/hawksupport"
 )
@@ -43,12 +45,14 @@ type applicationList struct {
 }
 
 type application struct {
-	ID            string `json:"id"`
-	IntegrationId string `json:"integration_id"`
-	ObjectId      string `json:"object_id"`
-	Name          string `json:"name"`
-	Description   string `json:"description"`
-	ProviderName  string `json:"provider_name"`
+	ID            string    `json:"id"`
+	IntegrationId string    `json:"integration_id"`
+	ObjectId      string    `json:"object_id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	ProviderName  string    `json:"provider_name"`
+	Missing       bool      `json:"missing"`
+	LastSeenAt    time.Time `json:"last_seen_at"`
 }
 
 func (c orchestratorClient) Applications() (applications []Application, err error) {
@@ -71,7 +75,9 @@ func (c orchestratorClient) Applications() (applications []Application, err erro
 			ObjectId:      app.ObjectId,
 			Name:          app.Name,
 			Description:   app.Description,
-			ProviderName:  app.ProviderName})
+			ProviderName:  app.ProviderName,
+			Missing:       app.Missing,
+			LastSeenAt:    app.LastSeenAt})
 	}
 
 	return applications, nil
@@ -89,7 +95,7 @@ func (c orchestratorClient) Application(id string) (Application, error) {
 		log.Printf("unable to parse found json: %s\n", err.Error())
 		return Application{}, err
 	}
-	app := Application{ID: jsonResponse.ID, IntegrationId: jsonResponse.IntegrationId, ObjectId: jsonResponse.ObjectId, Name: jsonResponse.Name, Description: jsonResponse.Description}
+	app := Application{ID: jsonResponse.ID, IntegrationId: jsonResponse.IntegrationId, ObjectId: jsonResponse.ObjectId, Name: jsonResponse.Name, Description: jsonResponse.Description, Missing: jsonResponse.Missing, LastSeenAt: jsonResponse.LastSeenAt}
 	return app, nil
 }
 
@@ -98,10 +104,12 @@ type integrationList struct {
 }
 
 type integration struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Provider string `json:"provider"`
-	Key      []byte `json:"key"`
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Provider       string `json:"provider"`
+	Key            []byte `json:"key,omitempty"`
+	KeyFingerprint string `json:"key_fingerprint,omitempty"`
+	KeyError       string `json:"key_error,omitempty"`
 }
 
 func (c orchestratorClient) Integrations() (integrations []Integration, err error) {
@@ -118,7 +126,7 @@ func (c orchestratorClient) Integrations() (integrations []Integration, err erro
 	}
 
 	for _, in := range jsonResponse.Integrations {
-		integrations = append(integrations, Integration{in.ID, in.Name, in.Provider, in.Key})
+		integrations = append(integrations, Integration{ID: in.ID, Name: in.Name, Provider: in.Provider, KeyFingerprint: in.KeyFingerprint, KeyError: in.KeyError})
 	}
 	return integrations, nil
 }
@@ -130,6 +138,20 @@ func (c orchestratorClient) CreateIntegration(name string, provider string, key
 	return errorOrBadResponse(resp, http.StatusCreated, hawkErr)
 }
 
+func (c orchestratorClient) TestIntegration(provider string, key []byte) error {
+	url := fmt.Sprintf("%v/integrations/test", c.url)
+	marshal, _ := json.Marshal(integration{Provider: provider, Key: key})
+	resp, hawkErr := hawksupport.HawkPost(c.client, "anId", c.key, url, bytes.NewReader(marshal))
+	return errorOrBadResponse(resp, http.StatusOK, hawkErr)
+}
+
+func (c orchestratorClient) UpdateIntegration(id string, name string, key []byte) error {
+	url := fmt.Sprintf("%v/integrations/%s", c.url, id)
+	marshal, _ := json.Marshal(integration{Name: name, Key: key})
+	resp, hawkErr := hawksupport.HawkPut(c.client, "anId", c.key, url, bytes.NewReader(marshal))
+	return errorOrBadResponse(resp, http.StatusOK, hawkErr)
+}
+
 func (c orchestratorClient) DeleteIntegration(id string) error {
 	url := fmt.Sprintf("%v/integrations/%s", c.url, id)
 	resp, hawkErr := hawksupport.HawkGet(c.client, "anId", c.key, url)
@@ -141,14 +163,18 @@ type policies struct {
 }
 
 type policy struct {
-	Meta    meta     `json:"meta"`
-	Actions []action `json:"actions"`
-	Subject subject  `json:"subject"`
-	Object  object   `json:"object"`
+	ID        string     `json:"id,omitempty"`
+	Meta      meta       `json:"meta"`
+	Actions   []action   `json:"actions"`
+	Subject   subject    `json:"subject"`
+	Object    object     `json:"object"`
+	Effect    string     `json:"effect,omitempty"`
+	Condition *condition `json:"condition,omitempty"`
 }
 
 type meta struct {
-	Version string `json:"version"`
+	Version  string `json:"version"`
+	Revision string `json:"revision,omitempty"`
 }
 
 type action struct {
@@ -164,6 +190,10 @@ type object struct {
 	Resources  []string `json:"resources"`
 }
 
+type condition struct {
+	Rule string `json:"rule"`
+}
+
 func (c orchestratorClient) GetPolicies(id string) ([]Policy, string, error) {
 	url := fmt.Sprintf("%v/applications/%s/policies", c.url, id)
 	resp, hawkErr := hawksupport.HawkGet(c.client, "anId", c.key, url)
@@ -188,12 +218,18 @@ func (c orchestratorClient) GetPolicies(id string) ([]Policy, string, error) {
 		for _, a := range p.Actions {
 			actions = append(actions, Action{a.ActionUri})
 		}
-		foundPolicies = append(foundPolicies, Policy{
-			Meta:    Meta{p.Meta.Version},
+		found := Policy{
+			ID:      p.ID,
+			Meta:    Meta{Version: p.Meta.Version, Revision: p.Meta.Revision},
 			Actions: actions,
 			Subject: Subject{Members: p.Subject.Members},
 			Object:  Object{ResourceID: p.Object.ResourceId},
-		})
+			Effect:  p.Effect,
+		}
+		if p.Condition != nil {
+			found.Condition = Condition{p.Condition.Rule}
+		}
+		foundPolicies = append(foundPolicies, found)
 	}
 	return foundPolicies, string(jsonBody), nil
 }
@@ -213,9 +249,256 @@ func (c orchestratorClient) Orchestration(from string, to string) error {
 	url := fmt.Sprintf("%v/orchestration", c.url)
 	marshal, _ := json.Marshal(orchestration{From: from, To: to})
 	resp, err := hawksupport.HawkPost(c.client, "anId", c.key, url, bytes.NewReader(marshal))
+	return errorOrBadResponse(resp, http.StatusAccepted, err)
+}
+
+type orchestrationJobList struct {
+	Jobs []orchestrationJob `json:"jobs"`
+}
+
+type orchestrationJob struct {
+	ID          string              `json:"id"`
+	State       string              `json:"state"`
+	From        string              `json:"from"`
+	To          string              `json:"to"`
+	Progress    int                 `json:"progress"`
+	Steps       []orchestrationStep `json:"steps"`
+	Attempts    int                 `json:"attempts"`
+	MaxAttempts int                 `json:"max_attempts"`
+	Error       string              `json:"error"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+type orchestrationStep struct {
+	Name     string   `json:"name"`
+	Status   string   `json:"status"`
+	Error    string   `json:"error"`
+	Unmapped []string `json:"unmapped"`
+}
+
+func (c orchestratorClient) OrchestrationJobs() ([]OrchestrationJob, error) {
+	url := fmt.Sprintf("%v/orchestration/jobs", c.url)
+	resp, hawkErr := hawksupport.HawkGet(c.client, "anId", c.key, url)
+	if err := errorOrBadResponse(resp, http.StatusOK, hawkErr); err != nil {
+		return []OrchestrationJob{}, err
+	}
+
+	var jsonResponse orchestrationJobList
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		log.Printf("unable to parse found json: %s\n", err.Error())
+		return []OrchestrationJob{}, err
+	}
+
+	jobs := make([]OrchestrationJob, 0)
+	for _, job := range jsonResponse.Jobs {
+		steps := make([]OrchestrationStep, 0)
+		for _, step := range job.Steps {
+			steps = append(steps, OrchestrationStep{Name: step.Name, Status: step.Status, Error: step.Error, Unmapped: step.Unmapped})
+		}
+		jobs = append(jobs, OrchestrationJob{ID: job.ID, State: job.State, From: job.From, To: job.To, Progress: job.Progress, Steps: steps,
+			Attempts: job.Attempts, MaxAttempts: job.MaxAttempts, Error: job.Error, CreatedAt: job.CreatedAt, UpdatedAt: job.UpdatedAt})
+	}
+	return jobs, nil
+}
+
+type syncRuleList struct {
+	SyncRules []syncRule `json:"sync_rules"`
+}
+
+type syncRule struct {
+	ID              string            `json:"id,omitempty"`
+	Name            string            `json:"name"`
+	Source          string            `json:"source"`
+	Targets         []string          `json:"targets"`
+	Selector        *syncRuleSelector `json:"selector,omitempty"`
+	IntervalSeconds int               `json:"interval_seconds"`
+	Options         syncRuleOptions   `json:"options"`
+	Enabled         *bool             `json:"enabled"`
+	LastRunAt       *time.Time        `json:"last_run_at,omitempty"`
+	LastSucceeded   bool              `json:"last_succeeded,omitempty"`
+	LastError       string            `json:"last_error,omitempty"`
+}
+
+type syncRuleSelector struct {
+	IntegrationId string `json:"integration_id,omitempty"`
+	Name          string `json:"name,omitempty"`
+}
+
+type syncRuleOptions struct {
+	KeepResource bool     `json:"keep_resource,omitempty"`
+	Actions      []string `json:"actions,omitempty"`
+}
+
+func (c orchestratorClient) SyncRules() ([]SyncRule, error) {
+	url := fmt.Sprintf("%v/sync-rules", c.url)
+	resp, hawkErr := hawksupport.HawkGet(c.client, "anId", c.key, url)
+	if err := errorOrBadResponse(resp, http.StatusOK, hawkErr); err != nil {
+		return []SyncRule{}, err
+	}
+
+	var jsonResponse syncRuleList
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		log.Printf("unable to parse found json: %s\n", err.Error())
+		return []SyncRule{}, err
+	}
+
+	rules := make([]SyncRule, 0)
+	for _, rule := range jsonResponse.SyncRules {
+		found := SyncRule{ID: rule.ID, Name: rule.Name, Source: rule.Source, Targets: rule.Targets, IntervalSeconds: rule.IntervalSeconds,
+			KeepResource: rule.Options.KeepResource, Actions: rule.Options.Actions, Enabled: rule.Enabled != nil && *rule.Enabled,
+			LastRunAt: rule.LastRunAt, LastSucceeded: rule.LastSucceeded, LastError: rule.LastError}
+		if rule.Selector != nil {
+			found.SelectorIntegrationId, found.SelectorName = rule.Selector.IntegrationId, rule.Selector.Name
+		}
+		rules = append(rules, found)
+	}
+	return rules, nil
+}
+
+func (c orchestratorClient) CreateSyncRule(rule SyncRule) error {
+	url := fmt.Sprintf("%v/sync-rules", c.url)
+	marshal, _ := json.Marshal(syncRuleRequest(rule))
+	resp, err := hawksupport.HawkPost(c.client, "anId", c.key, url, bytes.NewReader(marshal))
 	return errorOrBadResponse(resp, http.StatusCreated, err)
 }
 
+func (c orchestratorClient) UpdateSyncRule(rule SyncRule) error {
+	url := fmt.Sprintf("%v/sync-rules/%s", c.url, rule.ID)
+	marshal, _ := json.Marshal(syncRuleRequest(rule))
+	resp, err := hawksupport.HawkPut(c.client, "anId", c.key, url, bytes.NewReader(marshal))
+	return errorOrBadResponse(resp, http.StatusOK, err)
+}
+
+func (c orchestratorClient) DeleteSyncRule(id string) error {
+	url := fmt.Sprintf("%v/sync-rules/%s", c.url, id)
+	resp, err := hawksupport.HawkDelete(c.client, "anId", c.key, url)
+	return errorOrBadResponse(resp, http.StatusOK, err)
+}
+
+func (c orchestratorClient) RunSyncRule(id string) error {
+	url := fmt.Sprintf("%v/sync-rules/%s/runs", c.url, id)
+	resp, err := hawksupport.HawkPost(c.client, "anId", c.key, url, bytes.NewReader(nil))
+	return errorOrBadResponse(resp, http.StatusOK, err)
+}
+
+func syncRuleRequest(rule SyncRule) syncRule {
+	request := syncRule{Name: rule.Name, Source: rule.Source, Targets: rule.Targets, IntervalSeconds: rule.IntervalSeconds,
+		Options: syncRuleOptions{KeepResource: rule.KeepResource, Actions: rule.Actions}, Enabled: &rule.Enabled}
+	if rule.SelectorIntegrationId != "" || rule.SelectorName != "" {
+		request.Selector = &syncRuleSelector{IntegrationId: rule.SelectorIntegrationId, Name: rule.SelectorName}
+	}
+	return request
+}
+
+type mappingList struct {
+	Mappings []mapping `json:"mappings"`
+}
+
+type mapping struct {
+	ID           string `json:"id,omitempty"`
+	Kind         string `json:"kind"`
+	FromProvider string `json:"from_provider"`
+	FromValue    string `json:"from_value"`
+	ToProvider   string `json:"to_provider"`
+	ToValue      string `json:"to_value"`
+}
+
+func (c orchestratorClient) Mappings() ([]Mapping, error) {
+	url := fmt.Sprintf("%v/mappings", c.url)
+	resp, hawkErr := hawksupport.HawkGet(c.client, "anId", c.key, url)
+	if err := errorOrBadResponse(resp, http.StatusOK, hawkErr); err != nil {
+		return []Mapping{}, err
+	}
+
+	var jsonResponse mappingList
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		log.Printf("unable to parse found json: %s\n", err.Error())
+		return []Mapping{}, err
+	}
+
+	mappings := make([]Mapping, 0)
+	for _, found := range jsonResponse.Mappings {
+		mappings = append(mappings, Mapping{ID: found.ID, Kind: found.Kind, FromProvider: found.FromProvider, FromValue: found.FromValue,
+			ToProvider: found.ToProvider, ToValue: found.ToValue})
+	}
+	return mappings, nil
+}
+
+func (c orchestratorClient) CreateMapping(m Mapping) error {
+	url := fmt.Sprintf("%v/mappings", c.url)
+	marshal, _ := json.Marshal(mapping{Kind: m.Kind, FromProvider: m.FromProvider, FromValue: m.FromValue, ToProvider: m.ToProvider, ToValue: m.ToValue})
+	resp, err := hawksupport.HawkPost(c.client, "anId", c.key, url, bytes.NewReader(marshal))
+	return errorOrBadResponse(resp, http.StatusCreated, err)
+}
+
+func (c orchestratorClient) DeleteMapping(id string) error {
+	url := fmt.Sprintf("%v/mappings/%s", c.url, id)
+	resp, err := hawksupport.HawkDelete(c.client, "anId", c.key, url)
+	return errorOrBadResponse(resp, http.StatusOK, err)
+}
+
+func (c orchestratorClient) OrchestrationPreview(from string, to string) (OrchestrationDiff, error) {
+	url := fmt.Sprintf("%v/orchestration?dryRun=true", c.url)
+	marshal, _ := json.Marshal(orchestration{From: from, To: to})
+	resp, hawkErr := hawksupport.HawkPost(c.client, "anId", c.key, url, bytes.NewReader(marshal))
+	if err := errorOrBadResponse(resp, http.StatusOK, hawkErr); err != nil {
+		return OrchestrationDiff{}, err
+	}
+
+	var diff OrchestrationDiff
+	if err := json.NewDecoder(resp.Body).Decode(&diff); err != nil {
+		log.Printf("unable to parse found json: %s\n", err.Error())
+		return OrchestrationDiff{}, err
+	}
+	return diff, nil
+}
+
+type auditEventList struct {
+	Events []auditEvent `json:"events"`
+}
+
+type auditEvent struct {
+	ID           string    `json:"id"`
+	Actor        string    `json:"actor"`
+	Action       string    `json:"action"`
+	ResourceType string    `json:"resource_type"`
+	ResourceId   string    `json:"resource_id"`
+	PayloadHash  string    `json:"payload_hash"`
+	Outcome      string    `json:"outcome"`
+	Status       int       `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (c orchestratorClient) AuditEvents(filter AuditFilter) ([]AuditEvent, error) {
+	query := url.Values{}
+	for name, value := range map[string]string{"from": filter.From, "to": filter.To, "actor": filter.Actor, "resource": filter.Resource} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	auditUrl := fmt.Sprintf("%v/audit", c.url)
+	if len(query) > 0 {
+		auditUrl += "?" + query.Encode()
+	}
+	resp, hawkErr := hawksupport.HawkGet(c.client, "anId", c.key, auditUrl)
+	if err := errorOrBadResponse(resp, http.StatusOK, hawkErr); err != nil {
+		return []AuditEvent{}, err
+	}
+
+	var jsonResponse auditEventList
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		log.Printf("unable to parse found json: %s\n", err.Error())
+		return []AuditEvent{}, err
+	}
+
+	events := make([]AuditEvent, 0)
+	for _, e := range jsonResponse.Events {
+		events = append(events, AuditEvent{e.ID, e.Actor, e.Action, e.ResourceType, e.ResourceId, e.PayloadHash, e.Outcome, e.Status, e.CreatedAt})
+	}
+	return events, nil
+}
+
 func errorOrBadResponse(response *http.Response, status int, err error) error {
 	if err != nil {
 		log.Println(err)
@@ -2,27 +2,34 @@ package admin_test
 
 import (
 	"bytes"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/hexa-org/policy-orchestrator/pkg/admin"
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 type MockClient struct {
 	mock.Mock
-	response []byte
-	status   int
-	err      error
+	response    []byte
+	status      int
+	err         error
+	method      string
+	requestBody []byte
 }
 
-func (m *MockClient) Do(_ *http.Request) (*http.Response, error) {
+func (m *MockClient) Do(req *http.Request) (*http.Response, error) {
+	m.method = req.Method
+	if req.Body != nil {
+		m.requestBody, _ = ioutil.ReadAll(req.Body)
+	}
 	r := ioutil.NopCloser(bytes.NewReader(m.response))
 	return &http.Response{StatusCode: m.status, Body: r}, m.err
 }
@@ -52,12 +59,13 @@ func TestOrchestratorClient_NotHealthy(t *testing.T) {
 
 func TestOrchestratorClient_Applications(t *testing.T) {
 	mockClient := new(MockClient)
-	mockClient.response = []byte("{\"applications\":[{\"id\":\"anId\", \"integration_id\":\"anIntegrationId\", \"object_id\":\"anObjectId\", \"name\":\"anApp\", \"description\":\"aDescription\", \"provider_name\":\"aProviderName\"}]}")
+	mockClient.response = []byte("{\"applications\":[{\"id\":\"anId\", \"integration_id\":\"anIntegrationId\", \"object_id\":\"anObjectId\", \"name\":\"anApp\", \"description\":\"aDescription\", \"provider_name\":\"aProviderName\", \"missing\":true, \"last_seen_at\":\"2022-06-01T12:30:00Z\"}]}")
 	mockClient.status = http.StatusOK
 	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
 
 	resp, _ := client.Applications()
-	assert.Equal(t, []admin.Application{{ID: "anId", IntegrationId: "anIntegrationId", ObjectId: "anObjectId", Name: "anApp", Description: "aDescription", ProviderName: "aProviderName"}}, resp)
+	lastSeen := time.Date(2022, 6, 1, 12, 30, 0, 0, time.UTC)
+	assert.Equal(t, []admin.Application{{ID: "anId", IntegrationId: "anIntegrationId", ObjectId: "anObjectId", Name: "anApp", Description: "aDescription", ProviderName: "aProviderName", Missing: true, LastSeenAt: lastSeen}}, resp)
 }
 
 func TestOrchestratorClient_Applications_withErroneousGet(t *testing.T) {
@@ -99,15 +107,13 @@ func TestOrchestratorClient_Application_withBadJson(t *testing.T) {
 }
 
 func TestOrchestratorClient_Integrations(t *testing.T) {
-	key := base64.StdEncoding.EncodeToString([]byte("anotherKey"))
-
 	mockClient := new(MockClient)
-	mockClient.response = []byte(fmt.Sprintf("{\"integrations\":[{\"provider\":\"google_cloud\", \"key\":\"%s\"}]}", key))
+	mockClient.response = []byte("{\"integrations\":[{\"provider\":\"google_cloud\", \"key_fingerprint\":\"sha256:aFingerprint\"},{\"provider\":\"azure\", \"key_error\":\"aKeyError\"}]}")
 	mockClient.status = http.StatusOK
 	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
 
 	resp, _ := client.Integrations()
-	assert.Equal(t, []admin.Integration{{Provider: "google_cloud", Key: []byte("anotherKey")}}, resp)
+	assert.Equal(t, []admin.Integration{{Provider: "google_cloud", KeyFingerprint: "sha256:aFingerprint"}, {Provider: "azure", KeyError: "aKeyError"}}, resp)
 }
 
 func TestOrchestratorClient_Integrations_withErroneousGet(t *testing.T) {
@@ -138,6 +144,16 @@ func TestOrchestratorClient_CreateIntegrations(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestOrchestratorClient_TestIntegration(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusOK
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+	assert.NoError(t, client.TestIntegration("aProvider", []byte("aKey")))
+
+	mockClient.status = http.StatusBadRequest
+	assert.Error(t, client.TestIntegration("aProvider", []byte("aKey")))
+}
+
 func TestOrchestratorClient_DeleteIntegrations(t *testing.T) {
 	mockClient := new(MockClient)
 	mockClient.status = http.StatusOK
@@ -151,17 +167,22 @@ func TestOrchestratorClient_GetPolicy(t *testing.T) {
 	mockClient := new(MockClient)
 	mockClient.status = http.StatusOK
 	rawJson := "{\"policies\":[" +
-		"{\"meta\":{\"version\":\"aVersion\"},\"actions\":[{\"action_uri\": \"anAction\"}],\"subject\":{\"members\":[\"aUser\"]},\"object\":{\"resource_id\":\"aResourceId\"}}," +
-		"{\"meta\":{\"version\":\"anotherVersion\"},\"actions\":[{\"action\": \"anotherAction\"}],\"subject\":{\"members\":[\"anotherUser\"]},\"object\":{\"resource_id\":\"anotherResourceId\"}}]}"
+		"{\"id\":\"aPolicyId\",\"meta\":{\"version\":\"aVersion\",\"revision\":\"aRevision\"},\"actions\":[{\"action_uri\": \"anAction\"}],\"subject\":{\"members\":[\"aUser\"]},\"object\":{\"resource_id\":\"aResourceId\"}}," +
+		"{\"meta\":{\"version\":\"anotherVersion\"},\"actions\":[{\"action\": \"anotherAction\"}],\"subject\":{\"members\":[\"anotherUser\"]},\"object\":{\"resource_id\":\"anotherResourceId\"},\"effect\":\"deny\",\"condition\":{\"rule\":\"req.ip sw 127\"}}]}"
 	mockClient.response = []byte(rawJson)
 	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
 
 	resp, raw, _ := client.GetPolicies("anId")
 	assert.Equal(t, rawJson, raw)
+	assert.Equal(t, "aPolicyId", resp[0].ID)
 	assert.Equal(t, "aVersion", resp[0].Meta.Version)
+	assert.Equal(t, "aRevision", resp[0].Meta.Revision)
 	assert.Equal(t, "anAction", resp[0].Actions[0].ActionUri)
 	assert.Equal(t, []string{"aUser"}, resp[0].Subject.Members)
 	assert.Equal(t, "aResourceId", resp[0].Object.ResourceID)
+	assert.Equal(t, "", resp[0].Effect)
+	assert.Equal(t, "deny", resp[1].Effect)
+	assert.Equal(t, "req.ip sw 127", resp[1].Condition.Rule)
 
 	validate := validator.New()
 	errPolicies := validate.Var(resp, "omitempty,dive")
@@ -220,7 +241,7 @@ func TestOrchestratorClient_SetPolicy_withBadStatus(t *testing.T) {
 
 func TestOrchestrationClient_Orchestration(t *testing.T) {
 	mockClient := new(MockClient)
-	mockClient.status = http.StatusCreated
+	mockClient.status = http.StatusAccepted
 	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
 	err := client.Orchestration("fromId", "toId")
 	assert.NoError(t, err)
@@ -233,3 +254,163 @@ func TestOrchestrationClient_Orchestration_withError(t *testing.T) {
 	err := client.Orchestration("fromId", "toId")
 	assert.Error(t, err)
 }
+
+func TestOrchestrationClient_OrchestrationJobs(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusOK
+	mockClient.response = []byte(`{"jobs":[{"id":"anId","state":"failed","from":"fromId","to":"toId","progress":25,"steps":[{"name":"read source policies","status":"succeeded"},{"name":"read target policies","status":"failed","error":"oops"}],"attempts":1,"max_attempts":5,"error":"oops","created_at":"2022-05-01T10:30:00Z","updated_at":"2022-05-01T10:30:01Z"}]}`)
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	jobs, err := client.OrchestrationJobs()
+	assert.NoError(t, err)
+	assert.Equal(t, []admin.OrchestrationJob{{ID: "anId", State: "failed", From: "fromId", To: "toId", Progress: 25,
+		Steps:    []admin.OrchestrationStep{{Name: "read source policies", Status: "succeeded"}, {Name: "read target policies", Status: "failed", Error: "oops"}},
+		Attempts: 1, MaxAttempts: 5, Error: "oops", CreatedAt: time.Date(2022, 5, 1, 10, 30, 0, 0, time.UTC), UpdatedAt: time.Date(2022, 5, 1, 10, 30, 1, 0, time.UTC)}}, jobs)
+	assert.True(t, jobs[0].Active())
+}
+
+func TestOrchestrationClient_OrchestrationJobs_withError(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusInternalServerError
+	mockClient.response = []byte("oops")
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	_, err := client.OrchestrationJobs()
+	assert.EqualError(t, err, "oops")
+}
+
+func TestOrchestrationClient_OrchestrationPreview(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusOK
+	mockClient.response = []byte(`{"actions_added":["anAction"],"actions_removed":[],"members":[{"action_uri":"anAction","added":["aUser"],"removed":[]}],"rules":[{"action_uri":"anAction","added":["deny on \"aResource\""],"removed":[]}],"unmapped":["anotherUser"]}`)
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	diff, err := client.OrchestrationPreview("fromId", "toId")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"anAction"}, diff.ActionsAdded)
+	assert.Equal(t, []string{"anotherUser"}, diff.Unmapped)
+	assert.Equal(t, []policysupport.MemberDiff{{ActionUri: "anAction", Added: []string{"aUser"}, Removed: []string{}}}, diff.Members)
+	assert.Equal(t, []policysupport.RuleDiff{{ActionUri: "anAction", Added: []string{`deny on "aResource"`}, Removed: []string{}}}, diff.Rules)
+	assert.False(t, diff.IsEmpty())
+}
+
+func TestOrchestrationClient_OrchestrationPreview_withError(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusInternalServerError
+	mockClient.response = []byte("oops")
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	_, err := client.OrchestrationPreview("fromId", "toId")
+	assert.EqualError(t, err, "oops")
+}
+
+func TestOrchestratorClient_AuditEvents(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusOK
+	mockClient.response = []byte(`{"events":[{"id":"anId","actor":"anActor","action":"policies.set","resource_type":"application","resource_id":"anAppId","payload_hash":"aHash","outcome":"success","status":201,"created_at":"2022-05-01T10:30:00Z"}]}`)
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	events, err := client.AuditEvents(admin.AuditFilter{Actor: "anActor"})
+	assert.NoError(t, err)
+	assert.Equal(t, []admin.AuditEvent{{ID: "anId", Actor: "anActor", Action: "policies.set", ResourceType: "application", ResourceId: "anAppId", PayloadHash: "aHash", Outcome: "success", Status: 201, CreatedAt: time.Date(2022, 5, 1, 10, 30, 0, 0, time.UTC)}}, events)
+}
+
+func TestOrchestratorClient_AuditEvents_withError(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusBadRequest
+	mockClient.response = []byte("oops")
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	_, err := client.AuditEvents(admin.AuditFilter{From: "yesterday"})
+	assert.EqualError(t, err, "oops")
+}
+
+func TestOrchestratorClient_UpdateIntegration(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusOK
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	assert.NoError(t, client.UpdateIntegration("anId", "aName", []byte("aKey")))
+
+	mockClient.status = http.StatusNotFound
+	assert.Error(t, client.UpdateIntegration("anId", "aName", nil))
+}
+
+func TestOrchestrationClient_SyncRules(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusOK
+	mockClient.response = []byte(`{"sync_rules":[{"id":"aRuleId","name":"aRule","source":"anId","targets":["anotherId"],"selector":{"name":"payments-*"},"interval_seconds":60,"options":{"keep_resource":true,"actions":["anAction"]},"enabled":true,"last_run_at":"2022-05-01T10:30:00Z","last_succeeded":false,"last_error":"oops"}]}`)
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	rules, err := client.SyncRules()
+	assert.NoError(t, err)
+	lastRunAt := time.Date(2022, 5, 1, 10, 30, 0, 0, time.UTC)
+	assert.Equal(t, []admin.SyncRule{{ID: "aRuleId", Name: "aRule", Source: "anId", Targets: []string{"anotherId"}, SelectorName: "payments-*", IntervalSeconds: 60,
+		KeepResource: true, Actions: []string{"anAction"}, Enabled: true, LastRunAt: &lastRunAt, LastError: "oops"}}, rules)
+}
+
+func TestOrchestrationClient_SyncRules_withError(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusInternalServerError
+	mockClient.response = []byte("oops")
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	_, err := client.SyncRules()
+	assert.EqualError(t, err, "oops")
+}
+
+func TestOrchestrationClient_SaveSyncRule(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusCreated
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	rule := admin.SyncRule{ID: "aRuleId", Name: "aRule", Source: "anId", Targets: []string{"anotherId"}, SelectorIntegrationId: "anIntegrationId", IntervalSeconds: 60, Actions: []string{"anAction"}}
+	assert.NoError(t, client.CreateSyncRule(rule))
+	assert.Equal(t, http.MethodPost, mockClient.method)
+	assert.JSONEq(t, `{"name":"aRule","source":"anId","targets":["anotherId"],"selector":{"integration_id":"anIntegrationId"},"interval_seconds":60,"options":{"actions":["anAction"]},"enabled":false}`, string(mockClient.requestBody))
+
+	mockClient.status = http.StatusOK
+	assert.NoError(t, client.UpdateSyncRule(rule))
+	assert.Equal(t, http.MethodPut, mockClient.method)
+	assert.NoError(t, client.DeleteSyncRule("aRuleId"))
+	assert.Equal(t, http.MethodDelete, mockClient.method)
+	assert.NoError(t, client.RunSyncRule("aRuleId"))
+	assert.Equal(t, http.MethodPost, mockClient.method)
+
+	mockClient.status = http.StatusBadRequest
+	assert.Error(t, client.CreateSyncRule(rule))
+}
+
+func TestOrchestrationClient_Mappings(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusOK
+	mockClient.response = []byte(`{"mappings":[{"id":"aMappingId","kind":"member","from_provider":"google_cloud","from_value":"user:aUser@example.com","to_provider":"azure","to_value":"aPrincipalId","created_at":"2022-05-01T10:30:00Z"}]}`)
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	mappings, err := client.Mappings()
+	assert.NoError(t, err)
+	assert.Equal(t, []admin.Mapping{{ID: "aMappingId", Kind: "member", FromProvider: "google_cloud", FromValue: "user:aUser@example.com", ToProvider: "azure", ToValue: "aPrincipalId"}}, mappings)
+
+	mockClient.status = http.StatusInternalServerError
+	mockClient.response = []byte("oops")
+	_, err = client.Mappings()
+	assert.EqualError(t, err, "oops")
+}
+
+func TestOrchestrationClient_SaveMapping(t *testing.T) {
+	mockClient := new(MockClient)
+	mockClient.status = http.StatusCreated
+	client := admin.NewOrchestratorClient(mockClient, "localhost:8883", "aKey")
+
+	mapping := admin.Mapping{Kind: "action", FromProvider: "google_cloud", FromValue: "gcp:roles/viewer", ToProvider: "azure", ToValue: "azure:aRoleId"}
+	assert.NoError(t, client.CreateMapping(mapping))
+	assert.Equal(t, http.MethodPost, mockClient.method)
+	assert.JSONEq(t, `{"kind":"action","from_provider":"google_cloud","from_value":"gcp:roles/viewer","to_provider":"azure","to_value":"azure:aRoleId"}`, string(mockClient.requestBody))
+
+	mockClient.status = http.StatusOK
+	assert.NoError(t, client.DeleteMapping("aMappingId"))
+	assert.Equal(t, http.MethodDelete, mockClient.method)
+
+	mockClient.status = http.StatusConflict
+	assert.Error(t, client.CreateMapping(mapping))
+}
@@ -0,0 +1,148 @@
+package admin
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
+)
+
+// SyncRule keeps the policies of its source application applied to its targets, the listed applications and any
+// selected by integration or name pattern.
+type SyncRule struct {
+	ID                    string
+	Name                  string
+	Source                string
+	Targets               []string
+	SelectorIntegrationId string
+	SelectorName          string
+	IntervalSeconds       int
+	KeepResource          bool
+	Actions               []string
+	Enabled               bool
+	LastRunAt             *time.Time
+	LastSucceeded         bool
+	LastError             string
+}
+
+type SyncRulesHandler interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Toggle(w http.ResponseWriter, r *http.Request)
+	Run(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+type syncRulesHandler struct {
+	orchestratorUrl string
+	client          Client
+}
+
+func NewSyncRulesHandler(orchestratorUrl string, client Client) SyncRulesHandler {
+	return syncRulesHandler{orchestratorUrl, client}
+}
+
+func (h syncRulesHandler) List(w http.ResponseWriter, _ *http.Request) {
+	h.render(w, "")
+}
+
+func (h syncRulesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	interval, _ := strconv.Atoi(r.FormValue("interval_seconds"))
+	rule := SyncRule{
+		Name:                  r.FormValue("name"),
+		Source:                r.FormValue("source"),
+		Targets:               r.Form["targets"],
+		SelectorIntegrationId: r.FormValue("selector_integration_id"),
+		SelectorName:          strings.TrimSpace(r.FormValue("selector_name")),
+		IntervalSeconds:       interval,
+		KeepResource:          r.FormValue("keep_resource") == "on",
+		Actions:               splitActions(r.FormValue("actions")),
+		Enabled:               true,
+	}
+	if err := h.client.CreateSyncRule(rule); err != nil {
+		log.Println(err)
+		h.render(w, err.Error())
+		return
+	}
+	http.Redirect(w, r, "/sync-rules", http.StatusSeeOther)
+}
+
+// Toggle enables a disabled rule or disables an enabled one.
+func (h syncRulesHandler) Toggle(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	rules, err := h.client.SyncRules()
+	if err != nil {
+		log.Println(err)
+		h.render(w, err.Error())
+		return
+	}
+	for _, rule := range rules {
+		if rule.ID != id {
+			continue
+		}
+		rule.Enabled = !rule.Enabled
+		if updateErr := h.client.UpdateSyncRule(rule); updateErr != nil {
+			log.Println(updateErr)
+			h.render(w, updateErr.Error())
+			return
+		}
+	}
+	http.Redirect(w, r, "/sync-rules", http.StatusSeeOther)
+}
+
+// Run syncs the rule now, the outcome is shown with the rule's last run.
+func (h syncRulesHandler) Run(w http.ResponseWriter, r *http.Request) {
+	if err := h.client.RunSyncRule(mux.Vars(r)["id"]); err != nil {
+		log.Println(err)
+		h.render(w, err.Error())
+		return
+	}
+	http.Redirect(w, r, "/sync-rules", http.StatusSeeOther)
+}
+
+func (h syncRulesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.client.DeleteSyncRule(mux.Vars(r)["id"]); err != nil {
+		log.Println(err)
+		h.render(w, err.Error())
+		return
+	}
+	http.Redirect(w, r, "/sync-rules", http.StatusSeeOther)
+}
+
+func (h syncRulesHandler) render(w http.ResponseWriter, message string) {
+	rules, err := h.client.SyncRules()
+	if err != nil {
+		log.Println(err)
+		model := websupport.Model{Map: map[string]interface{}{"resource": "sync rules", "message": err.Error()}}
+		_ = websupport.ModelAndView(w, &resources, "sync_rules", model)
+		return
+	}
+	applications, _ := h.client.Applications()
+	integrations, _ := h.client.Integrations()
+	names := make(map[string]string)
+	for _, application := range applications {
+		names[application.ID] = application.Name
+	}
+	for _, integration := range integrations {
+		names[integration.ID] = integration.Name
+	}
+
+	model := websupport.Model{Map: map[string]interface{}{"resource": "sync rules", "rules": rules, "applications": applications,
+		"integrations": integrations, "names": names, "message": message}}
+	_ = websupport.ModelAndView(w, &resources, "sync_rules", model)
+}
+
+func splitActions(value string) []string {
+	actions := make([]string, 0)
+	for _, action := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(action); trimmed != "" {
+			actions = append(actions, trimmed)
+		}
+	}
+	return actions
+}
@@ -0,0 +1,128 @@
+package admin_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/admin"
+	"github.com/hexa-org/policy-orchestrator/pkg/admin/test"
+	"github.com/hexa-org/policy-orchestrator/pkg/healthsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SyncRulesSuite struct {
+	suite.Suite
+	server *http.Server
+	client *admin_test.MockClient
+}
+
+func TestSyncRules(t *testing.T) {
+	suite.Run(t, new(SyncRulesSuite))
+}
+
+func (suite *SyncRulesSuite) SetupTest() {
+	listener, _ := net.Listen("tcp", "localhost:0")
+	suite.client = &admin_test.MockClient{Url: "http://noop"}
+	suite.server = websupport.Create(
+		listener.Addr().String(),
+		admin.LoadHandlers("http://noop", suite.client),
+		websupport.Options{})
+	go websupport.Start(suite.server, listener)
+	healthsupport.WaitForHealthy(suite.server)
+}
+
+func (suite *SyncRulesSuite) TearDownTest() {
+	websupport.Stop(suite.server)
+}
+
+func (suite *SyncRulesSuite) TestList() {
+	lastRunAt := time.Date(2022, 5, 1, 10, 30, 0, 0, time.UTC)
+	suite.client.DesiredApplications = []admin.Application{{ID: "anId", Name: "aName"}, {ID: "anotherId", Name: "anotherName"}}
+	suite.client.DesiredSyncRules = []admin.SyncRule{
+		{ID: "aRuleId", Name: "aRule", Source: "anId", Targets: []string{"anotherId"}, SelectorIntegrationId: "anId", SelectorName: "payments-*", IntervalSeconds: 3600,
+			Actions: []string{"anAction", "anotherAction"}, Enabled: true, LastRunAt: &lastRunAt, LastError: "anotherId: oops"},
+		{ID: "anotherRuleId", Name: "anotherRule", Source: "anId", IntervalSeconds: 60},
+	}
+
+	resp, _ := http.Get(fmt.Sprintf("http://%s/sync-rules", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Sync Rules")
+	assert.Contains(suite.T(), string(body), "<td>aRule</td>")
+	assert.Contains(suite.T(), string(body), "<div>anotherName</div>")
+	assert.Contains(suite.T(), string(body), "Applications named payments-*")
+	assert.Contains(suite.T(), string(body), "Actions anAction, anotherAction")
+	assert.Contains(suite.T(), string(body), "2022-05-01 10:30:00 failed")
+	assert.Contains(suite.T(), string(body), "anotherId: oops")
+	assert.Contains(suite.T(), string(body), "[disable]")
+	assert.Contains(suite.T(), string(body), "<td>anotherRule (disabled)</td>")
+	assert.Contains(suite.T(), string(body), "never")
+	assert.Contains(suite.T(), string(body), "[enable]")
+	assert.Contains(suite.T(), string(body), "<option value=\"anotherId\">anotherName</option>")
+}
+
+func (suite *SyncRulesSuite) TestList_withClientError() {
+	suite.client.Errs = map[string]error{"http://noop/sync-rules": errors.New("oops")}
+
+	resp, _ := http.Get(fmt.Sprintf("http://%s/sync-rules", suite.server.Addr))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. oops")
+}
+
+func (suite *SyncRulesSuite) TestCreate() {
+	form := url.Values{"name": {"aRule"}, "source": {"anId"}, "targets": {"anotherId", "andAnotherId"}, "selector_name": {" payments-* "},
+		"interval_seconds": {"60"}, "actions": {"anAction, anotherAction,"}, "keep_resource": {"on"}}
+
+	resp, _ := http.PostForm(fmt.Sprintf("http://%s/sync-rules", suite.server.Addr), form)
+	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(suite.T(), "/sync-rules", resp.Request.URL.Path)
+	assert.Equal(suite.T(), admin.SyncRule{Name: "aRule", Source: "anId", Targets: []string{"anotherId", "andAnotherId"}, SelectorName: "payments-*",
+		IntervalSeconds: 60, KeepResource: true, Actions: []string{"anAction", "anotherAction"}, Enabled: true}, suite.client.SyncRule)
+}
+
+func (suite *SyncRulesSuite) TestCreate_withError() {
+	suite.client.Errs = map[string]error{"POST http://noop/sync-rules": errors.New("unable to save sync rule")}
+
+	resp, _ := http.PostForm(fmt.Sprintf("http://%s/sync-rules", suite.server.Addr), url.Values{"name": {"aRule"}})
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. unable to save sync rule")
+}
+
+func (suite *SyncRulesSuite) TestToggle() {
+	suite.client.DesiredSyncRules = []admin.SyncRule{{ID: "aRuleId", Name: "aRule", Enabled: true}, {ID: "anotherRuleId", Name: "anotherRule"}}
+
+	resp, _ := http.Post(fmt.Sprintf("http://%s/sync-rules/aRuleId/toggle", suite.server.Addr), "application/json", nil)
+	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(suite.T(), admin.SyncRule{ID: "aRuleId", Name: "aRule", Enabled: false}, suite.client.SyncRule)
+
+	suite.client.Errs = map[string]error{"http://noop/sync-rules/anotherRuleId": errors.New("oops")}
+	resp, _ = http.Post(fmt.Sprintf("http://%s/sync-rules/anotherRuleId/toggle", suite.server.Addr), "application/json", nil)
+	body, _ := io.ReadAll(resp.Body)
+	assert.True(suite.T(), suite.client.SyncRule.Enabled)
+	assert.Contains(suite.T(), string(body), "Something went wrong. oops")
+}
+
+func (suite *SyncRulesSuite) TestRunAndDelete() {
+	resp, _ := http.Post(fmt.Sprintf("http://%s/sync-rules/aRuleId/run", suite.server.Addr), "application/json", nil)
+	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(suite.T(), "aRuleId", suite.client.SyncRule.ID)
+
+	resp, _ = http.Post(fmt.Sprintf("http://%s/sync-rules/anotherRuleId", suite.server.Addr), "application/json", nil)
+	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(suite.T(), "anotherRuleId", suite.client.SyncRule.ID)
+
+	suite.client.Errs = map[string]error{"http://noop/sync-rules/aRuleId/runs": errors.New("oops"), "DELETE http://noop/sync-rules/aRuleId": errors.New("oops")}
+	resp, _ = http.Post(fmt.Sprintf("http://%s/sync-rules/aRuleId/run", suite.server.Addr), "application/json", nil)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. oops")
+	resp, _ = http.Post(fmt.Sprintf("http://%s/sync-rules/aRuleId", suite.server.Addr), "application/json", nil)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Contains(suite.T(), string(body), "Something went wrong. oops")
+}
@@ -18,6 +18,14 @@ type MockClient struct {
 
 	DesiredApplications []admin.Application
 	DesiredPolicies     []admin.Policy
+	DesiredDiff         admin.OrchestrationDiff
+	DesiredEvents       []admin.AuditEvent
+	DesiredJobs         []admin.OrchestrationJob
+	DesiredSyncRules    []admin.SyncRule
+	SyncRule            admin.SyncRule
+	DesiredMappings     []admin.Mapping
+	Mapping             admin.Mapping
+	Filter              admin.AuditFilter
 }
 
 func (m *MockClient) Health() (string, error) {
@@ -25,7 +33,7 @@ func (m *MockClient) Health() (string, error) {
 }
 
 func (m *MockClient) Integrations() ([]admin.Integration, error) {
-	integration := admin.Integration{ID: "anId", Name: "aName", Provider: "google_cloud", Key: []byte("aKey")}
+	integration := admin.Integration{ID: "anId", Name: "aName", Provider: "google_cloud", KeyFingerprint: "sha256:aFingerprint"}
 	url := fmt.Sprintf("%v/integrations", m.Url)
 	return []admin.Integration{integration}, m.Errs[url]
 }
@@ -42,6 +50,20 @@ func (m *MockClient) CreateIntegration(name string, provider string, key []byte)
 	return m.Errs[url]
 }
 
+func (m *MockClient) TestIntegration(provider string, key []byte) error {
+	url := fmt.Sprintf("%v/integrations/test", m.Url)
+	m.Provider = provider
+	m.Key = key
+	return m.Errs[url]
+}
+
+func (m *MockClient) UpdateIntegration(id string, name string, key []byte) error {
+	url := fmt.Sprintf("%v/integrations/%s", m.Url, id)
+	m.Name = name
+	m.Key = key
+	return m.Errs[url]
+}
+
 func (m *MockClient) DeleteIntegration(id string) error {
 	url := fmt.Sprintf("%v/integrations/%s", m.Url, id)
 	args := m.Called(url)
@@ -78,3 +100,65 @@ func (m *MockClient) Orchestration(from string, to string) error {
 	url := fmt.Sprintf("%v/orchestration", m.Url)
 	return m.Errs[url]
 }
+
+func (m *MockClient) OrchestrationPreview(from string, to string) (admin.OrchestrationDiff, error) {
+	url := fmt.Sprintf("%v/orchestration?dryRun=true", m.Url)
+	return m.DesiredDiff, m.Errs[url]
+}
+
+func (m *MockClient) AuditEvents(filter admin.AuditFilter) ([]admin.AuditEvent, error) {
+	url := fmt.Sprintf("%v/audit", m.Url)
+	m.Filter = filter
+	return m.DesiredEvents, m.Errs[url]
+}
+
+func (m *MockClient) OrchestrationJobs() ([]admin.OrchestrationJob, error) {
+	url := fmt.Sprintf("%v/orchestration/jobs", m.Url)
+	return m.DesiredJobs, m.Errs[url]
+}
+
+func (m *MockClient) SyncRules() ([]admin.SyncRule, error) {
+	url := fmt.Sprintf("%v/sync-rules", m.Url)
+	return m.DesiredSyncRules, m.Errs[url]
+}
+
+func (m *MockClient) CreateSyncRule(rule admin.SyncRule) error {
+	url := fmt.Sprintf("%v/sync-rules", m.Url)
+	m.SyncRule = rule
+	return m.Errs["POST "+url]
+}
+
+func (m *MockClient) UpdateSyncRule(rule admin.SyncRule) error {
+	url := fmt.Sprintf("%v/sync-rules/%s", m.Url, rule.ID)
+	m.SyncRule = rule
+	return m.Errs[url]
+}
+
+func (m *MockClient) DeleteSyncRule(id string) error {
+	url := fmt.Sprintf("%v/sync-rules/%s", m.Url, id)
+	m.SyncRule = admin.SyncRule{ID: id}
+	return m.Errs["DELETE "+url]
+}
+
+func (m *MockClient) RunSyncRule(id string) error {
+	url := fmt.Sprintf("%v/sync-rules/%s/runs", m.Url, id)
+	m.SyncRule = admin.SyncRule{ID: id}
+	return m.Errs[url]
+}
+
+func (m *MockClient) Mappings() ([]admin.Mapping, error) {
+	url := fmt.Sprintf("%v/mappings", m.Url)
+	return m.DesiredMappings, m.Errs[url]
+}
+
+func (m *MockClient) CreateMapping(mapping admin.Mapping) error {
+	url := fmt.Sprintf("%v/mappings", m.Url)
+	m.Mapping = mapping
+	return m.Errs["POST "+url]
+}
+
+func (m *MockClient) DeleteMapping(id string) error {
+	url := fmt.Sprintf("%v/mappings/%s", m.Url, id)
+	m.Mapping = admin.Mapping{ID: id}
+	return m.Errs["DELETE "+url]
+}
@@ -7,7 +7,9 @@ import (
 	"errors"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -108,3 +110,68 @@ func UnGzip(r io.Reader) ([]byte, error) {
 	}
 	return uncompressed.Bytes(), nil
 }
+
+// TarGzip writes the files, keyed by their path within the archive, to w as a gzip compressed tar in path order.
+func TarGzip(w io.Writer, files map[string][]byte) error {
+	zw := gzip.NewWriter(w)
+	if err := TarFiles(zw, files); err != nil {
+		_ = zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// UnTarGzip reads the regular files of a gzip compressed tar into memory, keyed by their path within the archive.
+func UnTarGzip(r io.Reader) (map[string][]byte, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func(zr *gzip.Reader) {
+		_ = zr.Close()
+	}(zr)
+	return UnTarFiles(zr)
+}
+
+func TarFiles(w io.Writer, files map[string][]byte) error {
+	names := make([]string, 0)
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tar.NewWriter(w)
+	for _, name := range names {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func UnTarFiles(r io.Reader) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, readErr := io.ReadAll(tr)
+		if readErr != nil {
+			return nil, readErr
+		}
+		files[strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")] = data
+	}
+	return files, nil
+}
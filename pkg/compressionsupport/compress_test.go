@@ -73,3 +73,34 @@ func TestGzip_withCopyErr(t *testing.T) {
 	_, err := compressionsupport.UnGzip(&incorrect)
 	assert.Error(t, err)
 }
+
+func TestTarGzipFiles(t *testing.T) {
+	var buffer bytes.Buffer
+	err := compressionsupport.TarGzip(&buffer, map[string][]byte{".manifest": []byte("{}"), "bundle/data.json": []byte(`{"a":1}`)})
+	assert.NoError(t, err)
+
+	files, err := compressionsupport.UnTarGzip(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{".manifest": []byte("{}"), "bundle/data.json": []byte(`{"a":1}`)}, files)
+}
+
+func TestUnTarFiles_fromPath(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	tar, _ := compressionsupport.TarFromPath(filepath.Join(file, "../resources/compressdir"))
+
+	files, err := compressionsupport.UnTarFiles(bytes.NewReader(tar))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(files))
+	assert.Contains(t, files, ".manifest")
+	assert.Contains(t, files, "compress.txt")
+}
+
+func TestUnTarGzip_withErr(t *testing.T) {
+	_, err := compressionsupport.UnTarGzip(bytes.NewReader([]byte("oops")))
+	assert.Error(t, err)
+
+	var buffer bytes.Buffer
+	_ = compressionsupport.Gzip(&buffer, []byte("not a tar"))
+	_, err = compressionsupport.UnTarGzip(&buffer)
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package encryptionsupport
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const MasterKeySize = 32
+
+var ErrUnableToOpen = errors.New("unable to decrypt, the data or its key may have been sealed by another master key")
+
+// Envelope encrypts each secret with its own random data key and wraps the data key with a master key.
+// Rotating the master key only re-wraps data keys, secrets are never re-encrypted.
+type Envelope struct {
+	master cipher.AEAD
+	keyId  string
+}
+
+func NewEnvelope(masterKey []byte) (*Envelope, error) {
+	if len(masterKey) != MasterKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, found %d", MasterKeySize, len(masterKey))
+	}
+	master, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(masterKey)
+	return &Envelope{master: master, keyId: hex.EncodeToString(sum[:8])}, nil
+}
+
+// NewEnvelopeFromHex accepts a hex encoded master key, for example one generated by `openssl rand -hex 32`.
+func NewEnvelopeFromHex(masterKey string) (*Envelope, error) {
+	decoded, err := hex.DecodeString(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("master key must be hex encoded: %w", err)
+	}
+	return NewEnvelope(decoded)
+}
+
+// KeyId identifies the master key without revealing it.
+func (e *Envelope) KeyId() string {
+	return e.keyId
+}
+
+func (e *Envelope) Seal(plaintext []byte) (ciphertext []byte, wrappedKey []byte, err error) {
+	dataKey := make([]byte, MasterKeySize)
+	if _, err = io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, nil, err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ciphertext, err = seal(data, plaintext); err != nil {
+		return nil, nil, err
+	}
+	if wrappedKey, err = seal(e.master, dataKey); err != nil {
+		return nil, nil, err
+	}
+	return ciphertext, wrappedKey, nil
+}
+
+func (e *Envelope) Open(ciphertext []byte, wrappedKey []byte) ([]byte, error) {
+	dataKey, err := open(e.master, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(data, ciphertext)
+}
+
+// Rewrap moves a wrapped data key from this envelope's master key to another's.
+func (e *Envelope) Rewrap(wrappedKey []byte, to *Envelope) ([]byte, error) {
+	dataKey, err := open(e.master, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return seal(to.master, dataKey)
+}
+
+// Fingerprint returns a short, non-reversible identifier for secret material suitable for display.
+func Fingerprint(secret []byte) string {
+	if len(secret) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(secret)
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrUnableToOpen
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrUnableToOpen
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,72 @@
+package encryptionsupport_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/encryptionsupport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	envelope, _ := encryptionsupport.NewEnvelope(bytes.Repeat([]byte{1}, 32))
+
+	ciphertext, wrappedKey, err := envelope.Seal([]byte("aSecret"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "aSecret")
+	assert.Equal(t, 16, len(envelope.KeyId()))
+
+	opened, err := envelope.Open(ciphertext, wrappedKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "aSecret", string(opened))
+
+	another, _, _ := envelope.Seal([]byte("aSecret"))
+	assert.NotEqual(t, ciphertext, another)
+}
+
+func TestEnvelope_withAnotherMasterKey(t *testing.T) {
+	envelope, _ := encryptionsupport.NewEnvelope(bytes.Repeat([]byte{1}, 32))
+	another, _ := encryptionsupport.NewEnvelope(bytes.Repeat([]byte{2}, 32))
+	assert.NotEqual(t, envelope.KeyId(), another.KeyId())
+
+	ciphertext, wrappedKey, _ := envelope.Seal([]byte("aSecret"))
+	_, err := another.Open(ciphertext, wrappedKey)
+	assert.ErrorIs(t, err, encryptionsupport.ErrUnableToOpen)
+
+	_, err = envelope.Open(ciphertext[:4], wrappedKey)
+	assert.ErrorIs(t, err, encryptionsupport.ErrUnableToOpen)
+}
+
+func TestEnvelope_Rewrap(t *testing.T) {
+	envelope, _ := encryptionsupport.NewEnvelope(bytes.Repeat([]byte{1}, 32))
+	another, _ := encryptionsupport.NewEnvelope(bytes.Repeat([]byte{2}, 32))
+
+	ciphertext, wrappedKey, _ := envelope.Seal([]byte("aSecret"))
+	rewrapped, err := envelope.Rewrap(wrappedKey, another)
+	assert.NoError(t, err)
+
+	opened, err := another.Open(ciphertext, rewrapped)
+	assert.NoError(t, err)
+	assert.Equal(t, "aSecret", string(opened))
+
+	_, err = another.Rewrap(wrappedKey, envelope)
+	assert.ErrorIs(t, err, encryptionsupport.ErrUnableToOpen)
+}
+
+func TestNewEnvelopeFromHex(t *testing.T) {
+	envelope, err := encryptionsupport.NewEnvelopeFromHex(strings.Repeat("0a", 32))
+	assert.NoError(t, err)
+	assert.NotNil(t, envelope)
+
+	_, err = encryptionsupport.NewEnvelopeFromHex("not hex")
+	assert.Error(t, err)
+
+	_, err = encryptionsupport.NewEnvelopeFromHex("0a0a")
+	assert.EqualError(t, err, "master key must be 32 bytes, found 2")
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, "sha256:2cf24dba5fb0a30e", encryptionsupport.Fingerprint([]byte("hello")))
+	assert.Equal(t, "", encryptionsupport.Fingerprint(nil))
+}
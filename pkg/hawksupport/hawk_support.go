@@ -1,6 +1,7 @@
 package hawksupport
 
 import (
+	"context"
 	"io"
 	"log"
 	"net/http"
@@ -55,10 +56,18 @@ func HawkMiddleware(next http.HandlerFunc, credentialStore hawk.CredentialStore,
 		}
 		h, _ := s.Header(r, cred, opt)
 		w.Header().Set("server-authorization", h)
-		next(w, r)
+		next(w, r.WithContext(context.WithValue(r.Context(), credentialIdKey{}, cred.ID)))
 	}
 }
 
+type credentialIdKey struct{}
+
+// CredentialId returns the id of the credential that authenticated the request, empty outside HawkMiddleware.
+func CredentialId(r *http.Request) string {
+	id, _ := r.Context().Value(credentialIdKey{}).(string)
+	return id
+}
+
 func HawkGet(client HTTPClient, id string, key string, url string) (*http.Response, error) {
 	req, _ := http.NewRequest("GET", url, nil)
 	authorize(req, id, key, url, "GET")
@@ -72,6 +81,19 @@ func HawkPost(client HTTPClient, id string, key string, url string, body io.Read
 	return client.Do(req)
 }
 
+func HawkPut(client HTTPClient, id string, key string, url string, body io.Reader) (*http.Response, error) {
+	req, _ := http.NewRequest("PUT", url, body)
+	req.Header.Set("Content-Type", "application/json")
+	authorize(req, id, key, url, "PUT")
+	return client.Do(req)
+}
+
+func HawkDelete(client HTTPClient, id string, key string, url string) (*http.Response, error) {
+	req, _ := http.NewRequest("DELETE", url, nil)
+	authorize(req, id, key, url, "DELETE")
+	return client.Do(req)
+}
+
 func authorize(req *http.Request, id string, key string, url string, method string) {
 	c := hawk.NewClient(
 		&hawk.Credential{
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -28,13 +29,27 @@ func securePost(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(all)
 }
 
+func secureDelete(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func secureCredential(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(hawksupport.CredentialId(r)))
+}
+
 func setup(key string) (*http.Server, func(t *testing.T)) {
 	listener, _ := net.Listen("tcp", "localhost:0")
 	get := hawksupport.HawkMiddleware(secureGet, hawksupport.NewCredentialStore(key), listener.Addr().String())
 	post := hawksupport.HawkMiddleware(securePost, hawksupport.NewCredentialStore(key), listener.Addr().String())
+	remove := hawksupport.HawkMiddleware(secureDelete, hawksupport.NewCredentialStore(key), listener.Addr().String())
+	credential := hawksupport.HawkMiddleware(secureCredential, hawksupport.NewCredentialStore(key), listener.Addr().String())
 	server := websupport.Create(listener.Addr().String(), func(router *mux.Router) {
 		router.HandleFunc("/secure", get).Methods("GET")
 		router.HandleFunc("/secure", post).Methods("POST")
+		router.HandleFunc("/secure", post).Methods("PUT")
+		router.HandleFunc("/secure", remove).Methods("DELETE")
+		router.HandleFunc("/credential", credential).Methods("GET")
 	}, websupport.Options{})
 
 	go websupport.Start(server, listener)
@@ -99,6 +114,42 @@ func TestPost(t *testing.T) {
 	assert.Equal(t, "aBody", string(b))
 }
 
+func TestPut(t *testing.T) {
+	key := getKey()
+	app, teardownTestCase := setup(key)
+	defer teardownTestCase(t)
+
+	reader := strings.NewReader("aBody")
+	resp, _ := hawksupport.HawkPut(&http.Client{}, "anId", key, fmt.Sprintf("http://%s/secure", app.Addr), reader)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "aBody", string(b))
+}
+
+func TestDelete(t *testing.T) {
+	key := getKey()
+	app, teardownTestCase := setup(key)
+	defer teardownTestCase(t)
+
+	resp, _ := hawksupport.HawkDelete(&http.Client{}, "anId", key, fmt.Sprintf("http://%s/secure", app.Addr))
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestCredentialId(t *testing.T) {
+	key := getKey()
+	app, teardownTestCase := setup(key)
+	defer teardownTestCase(t)
+
+	resp, _ := hawksupport.HawkGet(&http.Client{}, "anId", key, fmt.Sprintf("http://%s/credential", app.Addr))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "anId", string(b))
+
+	assert.Equal(t, "", hawksupport.CredentialId(httptest.NewRequest("GET", "/", nil)))
+}
+
 ///
 
 func getKey() string {
@@ -13,6 +13,12 @@ type HealthCheck interface {
 	Check() bool
 }
 
+// DetailedHealthCheck reports details alongside its result.
+type DetailedHealthCheck interface {
+	HealthCheck
+	Details() map[string]string
+}
+
 type NoopCheck struct {
 }
 
@@ -25,8 +31,9 @@ func (d *NoopCheck) Check() bool {
 }
 
 type response struct {
-	Name string `json:"name"`
-	Pass string `json:"pass"`
+	Name    string            `json:"name"`
+	Pass    string            `json:"pass"`
+	Details map[string]string `json:"details,omitempty"`
 }
 
 func HealthHandlerFunction(w http.ResponseWriter, r *http.Request) {
@@ -38,10 +45,14 @@ func HealthHandlerFunction(w http.ResponseWriter, r *http.Request) {
 func HealthHandlerFunctionWithChecks(w http.ResponseWriter, _ *http.Request, checks []HealthCheck) { // todo - move to func?
 	responses := make([]response, 0)
 	for _, check := range checks {
-		responses = append(responses, response{
+		found := response{
 			Name: check.Name(),
 			Pass: strconv.FormatBool(check.Check()),
-		})
+		}
+		if detailed, ok := check.(DetailedHealthCheck); ok {
+			found.Details = detailed.Details()
+		}
+		responses = append(responses, found)
 	}
 	data, _ := json.Marshal(responses)
 	w.Header().Set("content-type", "application/json")
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -31,3 +32,24 @@ func TestHealth(t *testing.T) {
 	assert.Equal(t, "[{\"name\":\"noop\",\"pass\":\"true\"}]", string(body))
 	_ = server.Shutdown(context.Background())
 }
+
+type DetailedCheck struct {
+}
+
+func (d *DetailedCheck) Name() string {
+	return "detailed"
+}
+
+func (d *DetailedCheck) Check() bool {
+	return false
+}
+
+func (d *DetailedCheck) Details() map[string]string {
+	return map[string]string{"leader": "aReplica"}
+}
+
+func TestHealth_withDetails(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	healthsupport.HealthHandlerFunctionWithChecks(recorder, httptest.NewRequest("GET", "/health", nil), []healthsupport.HealthCheck{&healthsupport.NoopCheck{}, &DetailedCheck{}})
+	assert.Equal(t, `[{"name":"noop","pass":"true"},{"name":"detailed","pass":"false","details":{"leader":"aReplica"}}]`, recorder.Body.String())
+}
@@ -1,8 +1,12 @@
 package orchestrator
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"time"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/databasesupport"
+	"github.com/lib/pq"
 )
 
 type ApplicationRecord struct {
@@ -11,26 +15,63 @@ type ApplicationRecord struct {
 	ObjectId      string
 	Name          string
 	Description   string
+	LastSeenAt    time.Time
+	Missing       bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 type ApplicationsDataGateway struct {
 	DB *sql.DB
 }
 
-func (gateway ApplicationsDataGateway) CreateIfAbsent(integrationId string, objectId string, name string, description string) (string, error) {
-	existing, _ := gateway.FindByObjectId(objectId)
-	if existing.ObjectId != "" {
-		log.Println("Found existing application record.")
-		return existing.ID, nil
-	}
+const applicationColumns = "id, integration_id, object_id, name, description, last_seen_at, missing, created_at, updated_at"
+
+// Upsert creates an integration's application or refreshes its name and description, updated_at changes only with them.
+func (gateway ApplicationsDataGateway) Upsert(integrationId string, objectId string, name string, description string) (string, error) {
 	var id string
-	err := gateway.DB.QueryRow(`insert into applications (integration_id, object_id, name, description) values ($1, $2, $3, $4) returning id`,
+	err := gateway.DB.QueryRow(`insert into applications (integration_id, object_id, name, description) values ($1, $2, $3, $4)
+on conflict (integration_id, object_id) do update set name=excluded.name, description=excluded.description,
+    updated_at=case when (applications.name, applications.description) is distinct from (excluded.name, excluded.description) then now() else applications.updated_at end
+returning id`,
 		integrationId, objectId, name, description).Scan(&id)
 	return id, err
 }
 
+// Reconcile marks an integration's applications with the given object ids as seen and the remainder as missing.
+// It returns the number of applications newly marked missing.
+func (gateway ApplicationsDataGateway) Reconcile(integrationId string, objectIds []string) (int, error) {
+	marked, err := databasesupport.WithTransaction(gateway.DB, context.Background(), sql.TxOptions{}, func(tx *sql.Tx) (interface{}, error) {
+		if _, err := tx.Exec("update applications set last_seen_at=now(), missing=false where integration_id=$1 and object_id=any($2)",
+			integrationId, pq.Array(objectIds)); err != nil {
+			return 0, err
+		}
+		result, err := tx.Exec("update applications set missing=true where integration_id=$1 and not object_id=any($2) and not missing",
+			integrationId, pq.Array(objectIds))
+		if err != nil {
+			return 0, err
+		}
+		affected, _ := result.RowsAffected()
+		return int(affected), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return marked.(int), nil
+}
+
+// PurgeMissing deletes applications missing and unseen for longer than the grace period.
+func (gateway ApplicationsDataGateway) PurgeMissing(grace time.Duration) (int, error) {
+	result, err := gateway.DB.Exec("delete from applications where missing and last_seen_at < now() - make_interval(secs => $1)", grace.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	affected, _ := result.RowsAffected()
+	return int(affected), nil
+}
+
 func (gateway ApplicationsDataGateway) Find() ([]ApplicationRecord, error) {
-	rows, err := gateway.DB.Query("select id, integration_id, object_id, name, description from applications order by name")
+	rows, err := gateway.DB.Query("select " + applicationColumns + " from applications order by name")
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +82,7 @@ func (gateway ApplicationsDataGateway) Find() ([]ApplicationRecord, error) {
 	records := make([]ApplicationRecord, 0)
 	for rows.Next() {
 		var record ApplicationRecord
-		if erroneousScan := rows.Scan(&record.ID, &record.IntegrationId, &record.ObjectId, &record.Name, &record.Description); erroneousScan != nil {
+		if erroneousScan := rows.Scan(&record.ID, &record.IntegrationId, &record.ObjectId, &record.Name, &record.Description, &record.LastSeenAt, &record.Missing, &record.CreatedAt, &record.UpdatedAt); erroneousScan != nil {
 			return nil, erroneousScan
 		}
 		records = append(records, record)
@@ -50,23 +91,24 @@ func (gateway ApplicationsDataGateway) Find() ([]ApplicationRecord, error) {
 }
 
 func (gateway ApplicationsDataGateway) FindByIntegrationId(integrationId string) (ApplicationRecord, error) {
-	s := "select id, integration_id, object_id, name, description from applications where integration_id=$1"
+	s := "select " + applicationColumns + " from applications where integration_id=$1"
 	return gateway.queryRow(s, integrationId)
 }
 
-func (gateway ApplicationsDataGateway) FindByObjectId(objectId string) (ApplicationRecord, error) {
-	s := "select id, integration_id, object_id, name, description from applications where object_id=$1"
-	return gateway.queryRow(s, objectId)
+// FindByObjectId finds an integration's application, object ids are only unique within an integration.
+func (gateway ApplicationsDataGateway) FindByObjectId(integrationId string, objectId string) (ApplicationRecord, error) {
+	s := "select " + applicationColumns + " from applications where integration_id=$1 and object_id=$2"
+	return gateway.queryRow(s, integrationId, objectId)
 }
 
 func (gateway ApplicationsDataGateway) FindById(id string) (ApplicationRecord, error) {
-	s := "select id, integration_id, object_id, name, description from applications where id=$1"
+	s := "select " + applicationColumns + " from applications where id=$1"
 	return gateway.queryRow(s, id)
 }
 
-func (gateway ApplicationsDataGateway) queryRow(sql string, id string) (ApplicationRecord, error) {
-	row := gateway.DB.QueryRow(sql, id)
+func (gateway ApplicationsDataGateway) queryRow(sql string, args ...interface{}) (ApplicationRecord, error) {
+	row := gateway.DB.QueryRow(sql, args...)
 	var record ApplicationRecord
-	err := row.Scan(&record.ID, &record.IntegrationId, &record.ObjectId, &record.Name, &record.Description)
+	err := row.Scan(&record.ID, &record.IntegrationId, &record.ObjectId, &record.Name, &record.Description, &record.LastSeenAt, &record.Missing, &record.CreatedAt, &record.UpdatedAt)
 	return record, err
 }
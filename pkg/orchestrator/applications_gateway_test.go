@@ -2,6 +2,7 @@ package orchestrator_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/databasesupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
@@ -31,7 +32,7 @@ func (data *applicationsTestData) TearDown() {
 
 func TestCreateApp(t *testing.T) {
 	testsupport.WithSetUp(&applicationsTestData{}, func(data *applicationsTestData) {
-		id, err := data.gateway.CreateIfAbsent(data.integrationTestId, "anObjectId", "aName", "aDescription")
+		id, err := data.gateway.Upsert(data.integrationTestId, "anObjectId", "aName", "aDescription")
 		assert.NotEmpty(t, id)
 		assert.NoError(t, err)
 	})
@@ -39,7 +40,7 @@ func TestCreateApp(t *testing.T) {
 
 func TestFindApps(t *testing.T) {
 	testsupport.WithSetUp(&applicationsTestData{}, func(data *applicationsTestData) {
-		_, _ = data.gateway.CreateIfAbsent(data.integrationTestId, "anObjectId", "aName", "aDescription")
+		_, _ = data.gateway.Upsert(data.integrationTestId, "anObjectId", "aName", "aDescription")
 		record, _ := data.gateway.FindByIntegrationId(data.integrationTestId)
 		assert.Equal(t, data.integrationTestId, record.IntegrationId)
 		assert.Equal(t, "anObjectId", record.ObjectId)
@@ -51,15 +52,15 @@ func TestFindApps(t *testing.T) {
 func TestFindApps_withDatabaseError(t *testing.T) {
 	open, _ := databasesupport.Open("")
 	gateway := orchestrator.ApplicationsDataGateway{DB: open}
-	_, _ = gateway.CreateIfAbsent("anId", "anObjectId", "aName", "aDescription")
+	_, _ = gateway.Upsert("anId", "anObjectId", "aName", "aDescription")
 	_, err := gateway.Find()
 	assert.Error(t, err)
 }
 
 func TestFindApps_ignoresDuplicates(t *testing.T) {
 	testsupport.WithSetUp(&applicationsTestData{}, func(data *applicationsTestData) {
-		_, _ = data.gateway.CreateIfAbsent(data.integrationTestId, "anObjectId", "aName", "aDescription")
-		_, _ = data.gateway.CreateIfAbsent(data.integrationTestId, "anObjectId", "aName", "aDescription")
+		_, _ = data.gateway.Upsert(data.integrationTestId, "anObjectId", "aName", "aDescription")
+		_, _ = data.gateway.Upsert(data.integrationTestId, "anObjectId", "aName", "aDescription")
 		find, _ := data.gateway.Find()
 		assert.Equal(t, 1, len(find))
 	})
@@ -67,7 +68,7 @@ func TestFindApps_ignoresDuplicates(t *testing.T) {
 
 func TestFindAppById(t *testing.T) {
 	testsupport.WithSetUp(&applicationsTestData{}, func(data *applicationsTestData) {
-		id, _ := data.gateway.CreateIfAbsent(data.integrationTestId, "anObjectId", "aName", "aDescription")
+		id, _ := data.gateway.Upsert(data.integrationTestId, "anObjectId", "aName", "aDescription")
 		record, _ := data.gateway.FindById(id)
 		assert.Equal(t, data.integrationTestId, record.IntegrationId)
 		assert.Equal(t, "anObjectId", record.ObjectId)
@@ -75,3 +76,87 @@ func TestFindAppById(t *testing.T) {
 		assert.Equal(t, "aDescription", record.Description)
 	})
 }
+
+func TestReconcileApps(t *testing.T) {
+	testsupport.WithSetUp(&applicationsTestData{}, func(data *applicationsTestData) {
+		_, _ = data.gateway.Upsert(data.integrationTestId, "anObjectId", "aName", "aDescription")
+		_, _ = data.gateway.Upsert(data.integrationTestId, "anotherObjectId", "anotherName", "aDescription")
+		_, _ = data.gateway.DB.Exec("update applications set last_seen_at=now() - interval '1 day'")
+
+		marked, err := data.gateway.Reconcile(data.integrationTestId, []string{"anObjectId"})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, marked)
+
+		seen, _ := data.gateway.FindByObjectId(data.integrationTestId, "anObjectId")
+		assert.False(t, seen.Missing)
+		assert.WithinDuration(t, time.Now(), seen.LastSeenAt, time.Hour)
+
+		missing, _ := data.gateway.FindByObjectId(data.integrationTestId, "anotherObjectId")
+		assert.True(t, missing.Missing)
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), missing.LastSeenAt, time.Hour)
+
+		markedAgain, _ := data.gateway.Reconcile(data.integrationTestId, []string{"anObjectId"})
+		assert.Equal(t, 0, markedAgain)
+
+		_, _ = data.gateway.Reconcile(data.integrationTestId, []string{"anObjectId", "anotherObjectId"})
+		found, _ := data.gateway.FindByObjectId(data.integrationTestId, "anotherObjectId")
+		assert.False(t, found.Missing)
+	})
+}
+
+func TestPurgeMissingApps(t *testing.T) {
+	testsupport.WithSetUp(&applicationsTestData{}, func(data *applicationsTestData) {
+		_, _ = data.gateway.Upsert(data.integrationTestId, "anObjectId", "aName", "aDescription")
+		_, _ = data.gateway.Upsert(data.integrationTestId, "anotherObjectId", "anotherName", "aDescription")
+		_, _ = data.gateway.Reconcile(data.integrationTestId, []string{})
+		_, _ = data.gateway.DB.Exec("update applications set last_seen_at=now() - interval '2 days' where object_id='anObjectId'")
+
+		purged, err := data.gateway.PurgeMissing(24 * time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		found, _ := data.gateway.Find()
+		assert.Equal(t, 1, len(found))
+		assert.Equal(t, "anotherObjectId", found[0].ObjectId)
+	})
+}
+
+func TestUpsertApps_refreshesNameAndDescription(t *testing.T) {
+	testsupport.WithSetUp(&applicationsTestData{}, func(data *applicationsTestData) {
+		id, _ := data.gateway.Upsert(data.integrationTestId, "anObjectId", "aName", "aDescription")
+		_, _ = data.gateway.DB.Exec("update applications set updated_at=now() - interval '1 day'")
+
+		sameId, _ := data.gateway.Upsert(data.integrationTestId, "anObjectId", "aName", "aDescription")
+		assert.Equal(t, id, sameId)
+		unchanged, _ := data.gateway.FindById(id)
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), unchanged.UpdatedAt, time.Hour)
+
+		renamedId, err := data.gateway.Upsert(data.integrationTestId, "anObjectId", "anotherName", "anotherDescription")
+		assert.NoError(t, err)
+		assert.Equal(t, id, renamedId)
+		renamed, _ := data.gateway.FindById(id)
+		assert.Equal(t, "anotherName", renamed.Name)
+		assert.Equal(t, "anotherDescription", renamed.Description)
+		assert.WithinDuration(t, time.Now(), renamed.UpdatedAt, time.Hour)
+		assert.True(t, renamed.CreatedAt.Before(renamed.UpdatedAt))
+	})
+}
+
+func TestUpsertApps_withSameObjectIdInAnotherIntegration(t *testing.T) {
+	testsupport.WithSetUp(&applicationsTestData{}, func(data *applicationsTestData) {
+		anotherIntegrationId := "50e00619-9f15-4e85-a7e9-f26d87ea12e8"
+		_, _ = data.gateway.DB.Exec("insert into integrations (id, name, provider, key) values ($1, 'anotherName', 'noop', 'aKey')", anotherIntegrationId)
+
+		id, _ := data.gateway.Upsert(data.integrationTestId, "anObjectId", "aName", "aDescription")
+		anotherId, err := data.gateway.Upsert(anotherIntegrationId, "anObjectId", "anotherName", "anotherDescription")
+		assert.NoError(t, err)
+		assert.NotEqual(t, id, anotherId)
+
+		found, _ := data.gateway.FindByObjectId(anotherIntegrationId, "anObjectId")
+		assert.Equal(t, anotherId, found.ID)
+		assert.Equal(t, "anotherName", found.Name)
+
+		_, err = data.gateway.FindByObjectId(anotherIntegrationId, "anUnknownObjectId")
+		assert.Error(t, err)
+	})
+}
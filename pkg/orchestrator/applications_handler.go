@@ -2,25 +2,35 @@ package orchestrator
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport/policytext"
 )
 
 type Applications struct {
 	Applications []Application `json:"applications"`
 }
 
+// Application is missing when no longer reported by its integration's provider, last seen at the given time.
 type Application struct {
-	ID            string `json:"id"`
-	IntegrationId string `json:"integration_id"`
-	ObjectId      string `json:"object_id"`
-	Name          string `json:"name"`
-	Description   string `json:"description"`
-	ProviderName  string `json:"provider_name"`
+	ID            string    `json:"id"`
+	IntegrationId string    `json:"integration_id"`
+	ObjectId      string    `json:"object_id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	ProviderName  string    `json:"provider_name"`
+	Missing       bool      `json:"missing"`
+	LastSeenAt    time.Time `json:"last_seen_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 type Policies struct {
@@ -28,14 +38,18 @@ type Policies struct {
 }
 
 type Policy struct {
-	Meta    Meta     `json:"meta" validate:"required"`
-	Actions []Action `json:"actions" validate:"required"`
-	Subject Subject  `json:"subject" validate:"required"`
-	Object  Object   `json:"object" validate:"required"`
+	ID        string     `json:"id,omitempty"`
+	Meta      Meta       `json:"meta" validate:"required"`
+	Actions   []Action   `json:"actions" validate:"required"`
+	Subject   Subject    `json:"subject" validate:"required"`
+	Object    Object     `json:"object" validate:"required"`
+	Effect    string     `json:"effect,omitempty" validate:"omitempty,oneof=allow deny"`
+	Condition *Condition `json:"condition,omitempty"`
 }
 
 type Meta struct {
-	Version string `json:"version" validate:"required"`
+	Version  string `json:"version" validate:"required"`
+	Revision string `json:"revision,omitempty"` // read only, reported by providers that track revisions
 }
 
 type Action struct {
@@ -50,6 +64,10 @@ type Object struct {
 	ResourceID string `json:"resource_id" validate:"required"`
 }
 
+type Condition struct {
+	Rule string `json:"rule" validate:"required"`
+}
+
 type ApplicationsHandler struct {
 	applicationsGateway ApplicationsDataGateway
 	integrationsGateway IntegrationsDataGateway
@@ -78,7 +96,7 @@ func (handler ApplicationsHandler) List(w http.ResponseWriter, _ *http.Request)
 
 	var list Applications
 	for _, rec := range records {
-		list.Applications = append(list.Applications, Application{ID: rec.ID, IntegrationId: rec.IntegrationId, ObjectId: rec.ObjectId, Name: rec.Name, Description: rec.Description, ProviderName: integrationNamesById[rec.IntegrationId]})
+		list.Applications = append(list.Applications, Application{ID: rec.ID, IntegrationId: rec.IntegrationId, ObjectId: rec.ObjectId, Name: rec.Name, Description: rec.Description, ProviderName: integrationNamesById[rec.IntegrationId], Missing: rec.Missing, LastSeenAt: rec.LastSeenAt, CreatedAt: rec.CreatedAt, UpdatedAt: rec.UpdatedAt})
 	}
 	data, _ := json.Marshal(list)
 	w.Header().Set("content-type", "application/json")
@@ -93,7 +111,7 @@ func (handler ApplicationsHandler) Show(w http.ResponseWriter, r *http.Request)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	app := Application{ID: record.ID, IntegrationId: record.IntegrationId, ObjectId: record.ObjectId, Name: record.Name, Description: record.Description}
+	app := Application{ID: record.ID, IntegrationId: record.IntegrationId, ObjectId: record.ObjectId, Name: record.Name, Description: record.Description, Missing: record.Missing, LastSeenAt: record.LastSeenAt, CreatedAt: record.CreatedAt, UpdatedAt: record.UpdatedAt}
 	data, _ := json.Marshal(app)
 	w.Header().Set("content-type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -101,37 +119,22 @@ func (handler ApplicationsHandler) Show(w http.ResponseWriter, r *http.Request)
 }
 
 func (handler ApplicationsHandler) GetPolicies(w http.ResponseWriter, r *http.Request) {
-	application, integration, provider, err := handler.applicationsService.GatherRecords(mux.Vars(r)["id"])
+	records, err := handler.applicationsService.GetPolicies(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	records, err := provider.GetPolicyInfo(integration, application)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if isPlainText(r.Header.Get("Accept")) {
+		w.Header().Set("content-type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(policytext.Format(records)))
 		return
 	}
 
 	list := make([]Policy, 0)
 	for _, rec := range records {
-		var actions []Action
-		for _, a := range rec.Actions {
-			actions = append(actions, Action{a.ActionUri})
-		}
-		list = append(
-			list, Policy{
-				Meta: Meta{
-					rec.Meta.Version,
-				},
-				Actions: actions,
-				Subject: Subject{
-					rec.Subject.Members,
-				},
-				Object: Object{
-					ResourceID: rec.Object.ResourceID,
-				},
-			})
+		list = append(list, policyFromInfo(rec))
 	}
 	data, _ := json.Marshal(Policies{list})
 	w.Header().Set("content-type", "application/json")
@@ -140,48 +143,222 @@ func (handler ApplicationsHandler) GetPolicies(w http.ResponseWriter, r *http.Re
 }
 
 func (handler ApplicationsHandler) SetPolicies(w http.ResponseWriter, r *http.Request) {
-	var policies Policies
-	if erroneousDecode := json.NewDecoder(r.Body).Decode(&policies); erroneousDecode != nil {
-		http.Error(w, erroneousDecode.Error(), http.StatusInternalServerError)
+	var policyInfos []policysupport.PolicyInfo
+	if isPlainText(r.Header.Get("Content-Type")) {
+		body, _ := io.ReadAll(r.Body)
+		parsed, parseErr := policytext.Parse(string(body))
+		if parseErr != nil {
+			http.Error(w, parseErr.Error(), http.StatusBadRequest)
+			return
+		}
+		if validatorErr := validator.New().Var(parsed, "omitempty,dive"); validatorErr != nil {
+			http.Error(w, "unable to validate policy.", http.StatusBadRequest)
+			return
+		}
+		policyInfos = parsed
+	} else {
+		var policies Policies
+		if erroneousDecode := json.NewDecoder(r.Body).Decode(&policies); erroneousDecode != nil {
+			http.Error(w, erroneousDecode.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		validatorErr := validator.New().Var(policies.Policies, "omitempty,dive")
+		if validatorErr != nil {
+			http.Error(w, "unable to validate policy.", http.StatusInternalServerError)
+			return
+		}
+
+		for _, policy := range policies.Policies {
+			policyInfos = append(policyInfos, policy.info())
+		}
+	}
+
+	status, setErr := handler.applicationsService.SetPolicies(mux.Vars(r)["id"], policyInfos, "set policies")
+	if writeSetError(w, status, setErr) {
 		return
 	}
+	w.WriteHeader(status)
+}
 
-	validatorErr := validator.New().Var(policies.Policies, "omitempty,dive")
-	if validatorErr != nil {
-		http.Error(w, "unable to validate policy.", http.StatusInternalServerError)
+type Snapshots struct {
+	Snapshots []Snapshot `json:"snapshots"`
+}
+
+type Snapshot struct {
+	ID            string    `json:"id"`
+	ApplicationId string    `json:"application_id"`
+	Phase         string    `json:"phase"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+	Policies      []Policy  `json:"policies"`
+}
+
+func (handler ApplicationsHandler) History(w http.ResponseWriter, r *http.Request) {
+	records, err := handler.applicationsService.History(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	var policyInfos []policysupport.PolicyInfo
-	for _, policy := range policies.Policies {
-		var actionInfos []policysupport.ActionInfo
-		for _, a := range policy.Actions {
-			actionInfos = append(actionInfos, policysupport.ActionInfo{ActionUri: a.ActionUri})
-		}
-		info := policysupport.PolicyInfo{
-			Meta: policysupport.MetaInfo{
-				Version: policy.Meta.Version,
-			},
-			Actions: actionInfos,
-			Subject: policysupport.SubjectInfo{
-				Members: policy.Subject.Members,
-			},
-			Object: policysupport.ObjectInfo{
-				ResourceID: policy.Object.ResourceID,
-			},
-		}
-		policyInfos = append(policyInfos, info)
+	list := make([]Snapshot, 0)
+	for _, rec := range records {
+		var policies Policies
+		_ = json.Unmarshal(rec.Policies, &policies)
+		list = append(list, Snapshot{ID: rec.ID, ApplicationId: rec.ApplicationId, Phase: rec.Phase, Reason: rec.Reason, CreatedAt: rec.CreatedAt, Policies: policies.Policies})
+	}
+	data, _ := json.Marshal(Snapshots{list})
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func (handler ApplicationsHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	status, err := handler.applicationsService.Rollback(vars["id"], vars["snapshotId"])
+	if errors.Is(err, ErrSnapshotNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
 	}
+	if writeSetError(w, status, err) {
+		return
+	}
+	w.WriteHeader(status)
+}
+
+type Drift struct {
+	ApplicationId string            `json:"application_id"`
+	Status        string            `json:"status"`
+	Message       string            `json:"message,omitempty"`
+	CheckedAt     *time.Time        `json:"checked_at,omitempty"`
+	DesiredAt     time.Time         `json:"desired_at"`
+	Diff          OrchestrationDiff `json:"diff"`
+}
 
-	application, integration, provider, err := handler.applicationsService.GatherRecords(mux.Vars(r)["id"])
+func (handler ApplicationsHandler) Drift(w http.ResponseWriter, r *http.Request) {
+	record, err := handler.applicationsService.Drift(mux.Vars(r)["id"])
+	if errors.Is(err, ErrDesiredPoliciesNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	status, setErr := provider.SetPolicyInfo(integration, application, policyInfos)
-	if setErr != nil || status != http.StatusCreated {
-		http.Error(w, "unable to update policy.", http.StatusInternalServerError)
+
+	drift := Drift{ApplicationId: record.ApplicationId, Status: record.DriftStatus, Message: record.DriftMessage, CheckedAt: record.CheckedAt, DesiredAt: record.UpdatedAt,
+		Diff: OrchestrationDiff{PolicyDiff: policysupport.PolicyDiff{ActionsAdded: []string{}, ActionsRemoved: []string{}, Members: []policysupport.MemberDiff{}, Rules: []policysupport.RuleDiff{}}}}
+	if record.Drift != nil {
+		_ = json.Unmarshal(record.Drift, &drift.Diff)
+	}
+	data, _ := json.Marshal(drift)
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func (handler ApplicationsHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	record, err := handler.applicationsService.GetPolicy(vars["id"], vars["policyId"])
+	if errors.Is(err, ErrPolicyNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(policyFromInfo(record))
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func (handler ApplicationsHandler) PutPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy Policy
+	if erroneousDecode := json.NewDecoder(r.Body).Decode(&policy); erroneousDecode != nil {
+		http.Error(w, erroneousDecode.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if validatorErr := validator.New().Struct(policy); validatorErr != nil {
+		http.Error(w, "unable to validate policy.", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	status, setErr := handler.applicationsService.PutPolicy(vars["id"], vars["policyId"], policy.info())
+	if writeSetError(w, status, setErr) {
 		return
 	}
 	w.WriteHeader(status)
 }
+
+func (handler ApplicationsHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	status, setErr := handler.applicationsService.DeletePolicy(vars["id"], vars["policyId"])
+	if writeSetError(w, status, setErr) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func isPlainText(header string) bool {
+	mediaType, _, _ := mime.ParseMediaType(header)
+	return mediaType == "text/plain"
+}
+
+func writeSetError(w http.ResponseWriter, status int, err error) bool {
+	if errors.Is(err, ErrPolicyNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return true
+	}
+	if status == http.StatusBadRequest && err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return true
+	}
+	if err != nil || status != http.StatusCreated {
+		http.Error(w, "unable to update policy.", http.StatusInternalServerError)
+		return true
+	}
+	return false
+}
+
+func policyFromInfo(info policysupport.PolicyInfo) Policy {
+	var actions []Action
+	for _, a := range info.Actions {
+		actions = append(actions, Action{a.ActionUri})
+	}
+	policy := Policy{
+		ID:      info.ID,
+		Meta:    Meta{Version: info.Meta.Version, Revision: info.Meta.Revision},
+		Actions: actions,
+		Subject: Subject{info.Subject.Members},
+		Object:  Object{ResourceID: info.Object.ResourceID},
+		Effect:  info.Effect,
+	}
+	if info.HasCondition() {
+		policy.Condition = &Condition{Rule: info.Condition.Rule}
+	}
+	return policy
+}
+
+func (policy Policy) info() policysupport.PolicyInfo {
+	var actionInfos []policysupport.ActionInfo
+	for _, a := range policy.Actions {
+		actionInfos = append(actionInfos, policysupport.ActionInfo{ActionUri: a.ActionUri})
+	}
+	info := policysupport.PolicyInfo{
+		ID:      policy.ID,
+		Meta:    policysupport.MetaInfo{Version: policy.Meta.Version},
+		Actions: actionInfos,
+		Subject: policysupport.SubjectInfo{Members: policy.Subject.Members},
+		Object:  policysupport.ObjectInfo{ResourceID: policy.Object.ResourceID},
+		Effect:  policy.Effect,
+	}
+	if policy.Condition != nil {
+		info.Condition = policysupport.ConditionInfo{Rule: policy.Condition.Rule}
+	}
+	return info
+}
@@ -8,9 +8,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/databasesupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/hawksupport"
@@ -19,6 +22,7 @@ import (
 	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator/test"
 	"github.com/hexa-org/policy-orchestrator/pkg/testsupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
+	"github.com/hiyosi/hawk"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -48,7 +52,7 @@ insert into applications (id, integration_id, object_id, name, description) valu
 
 	data.providers = make(map[string]orchestrator.Provider)
 	data.providers["noop"] = &orchestrator_test.NoopProvider{}
-	handlers, _ := orchestrator.LoadHandlers(data.db, hawksupport.NewCredentialStore(data.key), addr, data.providers)
+	handlers, _ := orchestrator.LoadHandlers(data.db, hawksupport.NewCredentialStore(data.key), addr, data.providers, orchestrator.Options{})
 	data.server = websupport.Create(addr, handlers, websupport.Options{})
 	go websupport.Start(data.server, listener)
 	healthsupport.WaitForHealthy(data.server)
@@ -75,6 +79,21 @@ func TestListApps(t *testing.T) {
 		assert.Equal(t, "aName", application.Name)
 		assert.Equal(t, "noop", application.ProviderName)
 		assert.Equal(t, "aDescription", application.Description)
+		assert.False(t, application.Missing)
+		assert.False(t, application.LastSeenAt.IsZero())
+		assert.False(t, application.CreatedAt.IsZero())
+		assert.False(t, application.UpdatedAt.IsZero())
+	})
+}
+
+func TestListApps_withMissingApplication(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		_, _ = data.db.Exec("update applications set missing=true")
+
+		resp, _ := hawksupport.HawkGet(&http.Client{}, "anId", data.key, fmt.Sprintf("http://%s/applications", data.server.Addr))
+		var apps orchestrator.Applications
+		_ = json.NewDecoder(resp.Body).Decode(&apps)
+		assert.True(t, apps.Applications[0].Missing)
 	})
 }
 
@@ -176,6 +195,45 @@ func TestSetPolicies(t *testing.T) {
 	})
 }
 
+func TestSetPolicies_withEffectAndCondition(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		var buf bytes.Buffer
+		policy := orchestrator.Policy{
+			Meta:      orchestrator.Meta{Version: "v0.5"},
+			Actions:   []orchestrator.Action{{ActionUri: "anAction"}},
+			Subject:   orchestrator.Subject{Members: []string{"anEmail"}},
+			Object:    orchestrator.Object{ResourceID: "aResourceId"},
+			Effect:    "deny",
+			Condition: &orchestrator.Condition{Rule: "req.ip sw 127"},
+		}
+		_ = json.NewEncoder(&buf).Encode(orchestrator.Policies{Policies: []orchestrator.Policy{policy}})
+
+		url := fmt.Sprintf("http://%s/applications/%s/policies", data.server.Addr, data.applicationTestId)
+
+		resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", data.key, url, bytes.NewReader(buf.Bytes()))
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+}
+
+func TestSetPolicies_withUnknownEffect(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		var buf bytes.Buffer
+		policy := orchestrator.Policy{
+			Meta:    orchestrator.Meta{Version: "v0.5"},
+			Actions: []orchestrator.Action{{ActionUri: "anAction"}},
+			Subject: orchestrator.Subject{Members: []string{"anEmail"}},
+			Object:  orchestrator.Object{ResourceID: "aResourceId"},
+			Effect:  "maybe",
+		}
+		_ = json.NewEncoder(&buf).Encode(orchestrator.Policies{Policies: []orchestrator.Policy{policy}})
+
+		url := fmt.Sprintf("http://%s/applications/%s/policies", data.server.Addr, data.applicationTestId)
+
+		resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", data.key, url, bytes.NewReader(buf.Bytes()))
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	})
+}
+
 func TestSetPolicies_withDatabaseError(t *testing.T) {
 	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
 		_ = data.db.Close()
@@ -212,3 +270,200 @@ func TestSetPolicies_withMissingJson(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 	})
 }
+
+func TestGetPolicies_asText(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		url := fmt.Sprintf("http://%s/applications/%s/policies", data.server.Addr, data.applicationTestId)
+
+		req, _ := http.NewRequest("GET", url, nil)
+		req.Header.Set("Accept", "text/plain")
+		resp, _ := http.DefaultClient.Do(authorized(req, data.key))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/plain", resp.Header.Get("content-type"))
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), `policy "aPolicyId" version "aVersion"`)
+		assert.Contains(t, string(body), `permit subject in ["aUser"]`)
+	})
+}
+
+func TestSetPolicies_withText(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		url := fmt.Sprintf("http://%s/applications/%s/policies", data.server.Addr, data.applicationTestId)
+
+		text := `deny subject in ["anEmail"] action in ["anAction"] on resource "aResourceId";`
+		req, _ := http.NewRequest("POST", url, strings.NewReader(text))
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		resp, _ := http.DefaultClient.Do(authorized(req, data.key))
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		updated := data.providers["noop"].(*orchestrator_test.NoopProvider).Updated
+		assert.Equal(t, "deny", updated[0].Effect)
+		assert.Equal(t, "anAction", updated[0].Actions[0].ActionUri)
+	})
+}
+
+func TestSetPolicies_withErroneousText(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		url := fmt.Sprintf("http://%s/applications/%s/policies", data.server.Addr, data.applicationTestId)
+
+		req, _ := http.NewRequest("POST", url, strings.NewReader("permit subject\nof"))
+		req.Header.Set("Content-Type", "text/plain")
+		resp, _ := http.DefaultClient.Do(authorized(req, data.key))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "line 2, column 1")
+	})
+}
+
+func TestGetPolicy(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		url := fmt.Sprintf("http://%s/applications/%s/policies/aPolicyId", data.server.Addr, data.applicationTestId)
+
+		resp, _ := hawksupport.HawkGet(&http.Client{}, "anId", data.key, url)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var policy orchestrator.Policy
+		_ = json.NewDecoder(resp.Body).Decode(&policy)
+		assert.Equal(t, "aPolicyId", policy.ID)
+		assert.Equal(t, "anAction", policy.Actions[0].ActionUri)
+	})
+}
+
+func TestGetPolicy_withUnknownPolicy(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		url := fmt.Sprintf("http://%s/applications/%s/policies/oops", data.server.Addr, data.applicationTestId)
+
+		resp, _ := hawksupport.HawkGet(&http.Client{}, "anId", data.key, url)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestPutPolicy(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		var buf bytes.Buffer
+		policy := orchestrator.Policy{
+			Meta:    orchestrator.Meta{Version: "v0.5"},
+			Actions: []orchestrator.Action{{ActionUri: "aNewAction"}},
+			Subject: orchestrator.Subject{Members: []string{"anEmail"}},
+			Object:  orchestrator.Object{ResourceID: "aResourceId"},
+		}
+		_ = json.NewEncoder(&buf).Encode(policy)
+
+		url := fmt.Sprintf("http://%s/applications/%s/policies/aPolicyId", data.server.Addr, data.applicationTestId)
+
+		resp, _ := hawksupport.HawkPut(&http.Client{}, "anId", data.key, url, bytes.NewReader(buf.Bytes()))
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		updated := data.providers["noop"].(*orchestrator_test.NoopProvider).Updated
+		assert.Equal(t, "aPolicyId", updated[0].ID)
+		assert.Equal(t, "aNewAction", updated[0].Actions[0].ActionUri)
+	})
+}
+
+func TestPutPolicy_withInvalidPolicy(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		url := fmt.Sprintf("http://%s/applications/%s/policies/aPolicyId", data.server.Addr, data.applicationTestId)
+
+		resp, _ := hawksupport.HawkPut(&http.Client{}, "anId", data.key, url, bytes.NewReader([]byte("{}")))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestDeletePolicy(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		url := fmt.Sprintf("http://%s/applications/%s/policies/aPolicyId", data.server.Addr, data.applicationTestId)
+
+		resp, _ := hawksupport.HawkDelete(&http.Client{}, "anId", data.key, url)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		updated := data.providers["noop"].(*orchestrator_test.NoopProvider).Updated
+		assert.Equal(t, 1, len(updated))
+		assert.Equal(t, "anotherAction", updated[0].Actions[0].ActionUri)
+	})
+}
+
+func TestDeletePolicy_withUnknownPolicy(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		url := fmt.Sprintf("http://%s/applications/%s/policies/oops", data.server.Addr, data.applicationTestId)
+
+		resp, _ := hawksupport.HawkDelete(&http.Client{}, "anId", data.key, url)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func authorized(req *http.Request, key string) *http.Request {
+	client := hawk.NewClient(&hawk.Credential{ID: "anId", Key: key, Alg: hawk.SHA256}, &hawk.Option{TimeStamp: time.Now().Unix(), Nonce: "nonce"})
+	header, _ := client.Header(req.Method, req.URL.String())
+	req.Header.Set("authorization", header)
+	return req
+}
+
+func TestPolicyHistory(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		deleteUrl := fmt.Sprintf("http://%s/applications/%s/policies/aPolicyId", data.server.Addr, data.applicationTestId)
+		_, _ = hawksupport.HawkDelete(&http.Client{}, "anId", data.key, deleteUrl)
+
+		url := fmt.Sprintf("http://%s/applications/%s/policies/history", data.server.Addr, data.applicationTestId)
+		resp, _ := hawksupport.HawkGet(&http.Client{}, "anId", data.key, url)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var snapshots orchestrator.Snapshots
+		_ = json.NewDecoder(resp.Body).Decode(&snapshots)
+		assert.Equal(t, 2, len(snapshots.Snapshots))
+		assert.Equal(t, "delete policy aPolicyId", snapshots.Snapshots[0].Reason)
+		assert.Equal(t, 2, len(snapshots.Snapshots[0].Policies))
+	})
+}
+
+func TestPolicyRollback(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		var snapshotId string
+		_ = data.db.QueryRow(`insert into policy_snapshots (application_id, phase, reason, policies) values ($1, 'before', 'aReason', $2) returning id`,
+			data.applicationTestId, `{"policies":[{"meta":{"version":"aVersion"},"actions":[{"action_uri":"anAction"}],"subject":{"members":["aUser"]},"object":{"resource_id":"anId"}}]}`).Scan(&snapshotId)
+
+		url := fmt.Sprintf("http://%s/applications/%s/policies/rollback/%s", data.server.Addr, data.applicationTestId, snapshotId)
+		resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", data.key, url, nil)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		updated := data.providers["noop"].(*orchestrator_test.NoopProvider).Updated
+		assert.Equal(t, 1, len(updated))
+		assert.Equal(t, "anAction", updated[0].Actions[0].ActionUri)
+	})
+}
+
+func TestPolicyRollback_withUnknownSnapshot(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		url := fmt.Sprintf("http://%s/applications/%s/policies/rollback/%s", data.server.Addr, data.applicationTestId, "7409776a-367a-483a-a194-5ccf9c4ff210")
+		resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", data.key, url, nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestDrift(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		gateway := orchestrator.DesiredPoliciesDataGateway{DB: data.db}
+		_ = gateway.Save(data.applicationTestId, []byte(`{"policies":[]}`))
+		_ = gateway.RecordDrift(data.applicationTestId, orchestrator.DriftDetected, []byte(`{"actions_added":["anAction"],"actions_removed":[],"members":[]}`), "")
+
+		url := fmt.Sprintf("http://%s/applications/%s/drift", data.server.Addr, data.applicationTestId)
+		resp, _ := hawksupport.HawkGet(&http.Client{}, "anId", data.key, url)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var drift orchestrator.Drift
+		_ = json.NewDecoder(resp.Body).Decode(&drift)
+		assert.Equal(t, data.applicationTestId, drift.ApplicationId)
+		assert.Equal(t, orchestrator.DriftDetected, drift.Status)
+		assert.Equal(t, []string{"anAction"}, drift.Diff.ActionsAdded)
+		assert.NotNil(t, drift.CheckedAt)
+	})
+}
+
+func TestDrift_withoutDesiredPolicies(t *testing.T) {
+	testsupport.WithSetUp(&applicationsHandlerData{}, func(data *applicationsHandlerData) {
+		url := fmt.Sprintf("http://%s/applications/%s/drift", data.server.Addr, data.applicationTestId)
+		resp, _ := hawksupport.HawkGet(&http.Client{}, "anId", data.key, url)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -1,17 +1,30 @@
 package orchestrator
 
 import (
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"path"
 	"strings"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/workflowsupport"
 )
 
+var ErrPolicyNotFound = errors.New("policy not found")
+var ErrSnapshotNotFound = errors.New("policy snapshot not found")
+var ErrDesiredPoliciesNotFound = errors.New("no desired policies recorded")
+
 type ApplicationsService struct {
-	ApplicationsGateway ApplicationsDataGateway
-	IntegrationsGateway IntegrationsDataGateway
-	Providers           map[string]Provider
+	ApplicationsGateway    ApplicationsDataGateway
+	IntegrationsGateway    IntegrationsDataGateway
+	PolicySnapshotsGateway PolicySnapshotsDataGateway
+	DesiredPoliciesGateway DesiredPoliciesDataGateway
+	MappingsGateway        MappingsDataGateway
+	Providers              map[string]Provider
 }
 
 func (service ApplicationsService) GatherRecords(identifier string) (ApplicationInfo, IntegrationInfo, Provider, error) {
@@ -27,44 +40,469 @@ func (service ApplicationsService) GatherRecords(identifier string) (Application
 	}
 	integration := IntegrationInfo{Name: integrationRecord.Name, Key: integrationRecord.Key}
 
-	return application, integration, service.Providers[strings.ToLower(integrationRecord.Provider)], err // todo - test for lower?
+	provider, found := service.Providers[strings.ToLower(integrationRecord.Provider)] // todo - test for lower?
+	if !found {
+		return ApplicationInfo{}, IntegrationInfo{}, nil, fmt.Errorf("unsupported provider %s", integrationRecord.Provider)
+	}
+	return application, integration, provider, err
 }
 
-func (service ApplicationsService) Apply(jsonRequest Orchestration) error {
-	fromApplication, fromIntegration, fromProvider, fromErr := service.GatherRecords(jsonRequest.From)
-	if fromErr != nil {
-		return fromErr
+func (service ApplicationsService) GetPolicies(identifier string) ([]policysupport.PolicyInfo, error) {
+	application, integration, provider, err := service.GatherRecords(identifier)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := provider.GetPolicyInfo(integration, application)
+	if err != nil {
+		return nil, err
 	}
+	return policysupport.EnsureIdentifiers(infos), nil
+}
 
-	toApplication, toIntegration, toProvider, toErr := service.GatherRecords(jsonRequest.To)
-	if toErr != nil {
-		return toErr
+func (service ApplicationsService) GetPolicy(identifier, policyId string) (policysupport.PolicyInfo, error) {
+	infos, err := service.GetPolicies(identifier)
+	if err != nil {
+		return policysupport.PolicyInfo{}, err
+	}
+	for _, info := range infos {
+		if info.ID == policyId {
+			return info, nil
+		}
+	}
+	return policysupport.PolicyInfo{}, ErrPolicyNotFound
+}
+
+func (service ApplicationsService) SetPolicies(identifier string, policies []policysupport.PolicyInfo, reason string) (int, error) {
+	application, integration, provider, err := service.GatherRecords(identifier)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	current, err := provider.GetPolicyInfo(integration, application)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return service.write(identifier, application, integration, provider, current, policies, reason)
+}
+
+func (service ApplicationsService) PutPolicy(identifier, policyId string, policy policysupport.PolicyInfo) (int, error) {
+	application, integration, provider, err := service.GatherRecords(identifier)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	infos, err := provider.GetPolicyInfo(integration, application)
+	if err != nil {
+		return http.StatusInternalServerError, err
 	}
 
-	if toProvider != fromProvider {
-		return errors.New("sorry, orchestration across providers is work in progress")
+	policy.ID = policyId
+	replaced := false
+	modified := make([]policysupport.PolicyInfo, 0)
+	for _, info := range policysupport.EnsureIdentifiers(infos) {
+		if info.ID == policyId {
+			info = policy
+			replaced = true
+		}
+		modified = append(modified, info)
 	}
+	if !replaced {
+		modified = append(modified, policy)
+	}
+	return service.write(identifier, application, integration, provider, infos, modified, "put policy "+policyId)
+}
 
-	fromPolicies, getFroErr := fromProvider.GetPolicyInfo(fromIntegration, fromApplication)
-	if getFroErr != nil {
-		return getFroErr
+func (service ApplicationsService) DeletePolicy(identifier, policyId string) (int, error) {
+	application, integration, provider, err := service.GatherRecords(identifier)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	infos, err := provider.GetPolicyInfo(integration, application)
+	if err != nil {
+		return http.StatusInternalServerError, err
 	}
 
-	toPolicies, getToErr := fromProvider.GetPolicyInfo(fromIntegration, fromApplication)
-	if getToErr != nil {
-		return getToErr
+	found := false
+	remaining := make([]policysupport.PolicyInfo, 0)
+	for _, info := range policysupport.EnsureIdentifiers(infos) {
+		if info.ID == policyId {
+			found = true
+			continue
+		}
+		remaining = append(remaining, info)
+	}
+	if !found {
+		return http.StatusNotFound, ErrPolicyNotFound
 	}
+	return service.write(identifier, application, integration, provider, infos, remaining, "delete policy "+policyId)
+}
+
+func (service ApplicationsService) History(identifier string) ([]PolicySnapshotRecord, error) {
+	return service.PolicySnapshotsGateway.FindByApplicationId(identifier)
+}
+
+func (service ApplicationsService) Drift(identifier string) (DesiredPoliciesRecord, error) {
+	record, err := service.DesiredPoliciesGateway.FindByApplicationId(identifier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return record, ErrDesiredPoliciesNotFound
+	}
+	return record, err
+}
 
-	modifiedToPoliciesRetainingResourceId, err := service.RetainResource(fromPolicies, toPolicies)
+func (service ApplicationsService) Rollback(identifier, snapshotId string) (int, error) {
+	record, err := service.PolicySnapshotsGateway.FindById(snapshotId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && record.ApplicationId != identifier) {
+		return http.StatusNotFound, ErrSnapshotNotFound
+	}
 	if err != nil {
-		return err
+		return http.StatusInternalServerError, err
+	}
+
+	infos, unmarshalErr := unmarshalPolicies(record.Policies)
+	if unmarshalErr != nil {
+		return http.StatusInternalServerError, unmarshalErr
+	}
+	return service.SetPolicies(identifier, infos, "rollback to "+snapshotId)
+}
+
+// write snapshots the provider's policies before and after updating them, a write is refused if
+// the prior policies cannot be recorded. The policies read back become the desired state used to detect drift.
+func (service ApplicationsService) write(identifier string, application ApplicationInfo, integration IntegrationInfo, provider Provider, current, policies []policysupport.PolicyInfo, reason string) (int, error) {
+	if err := service.snapshot(identifier, "before", reason, current); err != nil {
+		return http.StatusInternalServerError, err
 	}
 
-	status, setErr := toProvider.SetPolicyInfo(toIntegration, toApplication, modifiedToPoliciesRetainingResourceId)
+	status, setErr := provider.SetPolicyInfo(integration, application, policies)
 	if setErr != nil || status != http.StatusCreated {
-		return setErr
+		return status, setErr
+	}
+
+	desired := policies
+	after, err := provider.GetPolicyInfo(integration, application)
+	if err == nil {
+		desired = after
+		err = service.snapshot(identifier, "after", reason, after)
+	}
+	if err != nil {
+		log.Println("Unable to snapshot policies after update: " + err.Error())
+	}
+	if desiredErr := service.DesiredPoliciesGateway.Save(identifier, marshalPolicies(desired)); desiredErr != nil {
+		log.Println("Unable to record desired policies: " + desiredErr.Error())
+	}
+	return status, setErr
+}
+
+func (service ApplicationsService) snapshot(identifier, phase, reason string, infos []policysupport.PolicyInfo) error {
+	_, err := service.PolicySnapshotsGateway.Create(identifier, phase, reason, marshalPolicies(infos))
+	return err
+}
+
+func marshalPolicies(infos []policysupport.PolicyInfo) []byte {
+	list := make([]Policy, 0)
+	for _, info := range infos {
+		list = append(list, policyFromInfo(info))
+	}
+	data, _ := json.Marshal(Policies{list})
+	return data
+}
+
+func unmarshalPolicies(data []byte) ([]policysupport.PolicyInfo, error) {
+	var policies Policies
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, err
+	}
+	infos := make([]policysupport.PolicyInfo, 0)
+	for _, policy := range policies.Policies {
+		infos = append(infos, policy.info())
+	}
+	return infos, nil
+}
+
+type orchestrationPlan struct {
+	application ApplicationInfo
+	integration IntegrationInfo
+	provider    Provider
+	current     []policysupport.PolicyInfo
+	proposed    []policysupport.PolicyInfo
+	unmapped    []string
+}
+
+const (
+	StepReadSource  = "read source policies"
+	StepReadTarget  = "read target policies"
+	StepTranslate   = "translate policies"
+	StepWriteTarget = "write target policies"
+)
+
+// OrchestrationSteps are the steps of an orchestration, in the order they run.
+var OrchestrationSteps = []string{StepReadSource, StepReadTarget, StepTranslate, StepWriteTarget}
+
+// TransformOptions adjust the policies an orchestration writes. KeepResource keeps the source's resource ids rather
+// than the target's. Actions limits the orchestration to policies for those action uris, the target keeping its
+// policies for any other action; all actions are orchestrated when empty.
+type TransformOptions struct {
+	KeepResource bool     `json:"keep_resource,omitempty"`
+	Actions      []string `json:"actions,omitempty"`
+}
+
+// choose keeps the source policies' selected actions, dropping policies left without any.
+func (options TransformOptions) choose(policies []policysupport.PolicyInfo) []policysupport.PolicyInfo {
+	if len(options.Actions) == 0 {
+		return policies
+	}
+	return options.filter(policies, true)
+}
+
+// merge adds the target's policies for actions that are not selected to the selected source policies.
+func (options TransformOptions) merge(selected, target []policysupport.PolicyInfo) []policysupport.PolicyInfo {
+	if len(options.Actions) == 0 {
+		return selected
+	}
+	return append(selected, options.filter(target, false)...)
+}
+
+func (options TransformOptions) filter(policies []policysupport.PolicyInfo, selected bool) []policysupport.PolicyInfo {
+	wanted := make(map[string]bool)
+	for _, action := range options.Actions {
+		wanted[action] = true
+	}
+	filtered := make([]policysupport.PolicyInfo, 0)
+	for _, policy := range policies {
+		actions := make([]policysupport.ActionInfo, 0)
+		for _, action := range policy.Actions {
+			if wanted[action.ActionUri] == selected {
+				actions = append(actions, action)
+			}
+		}
+		if len(actions) > 0 {
+			policy.Actions = actions
+			filtered = append(filtered, policy)
+		}
+	}
+	return filtered
+}
+
+// StepTracker is told the outcome of each orchestration step, the orchestration stops at the first error it returns.
+type StepTracker func(step string, err error) error
+
+func untracked(_ string, err error) error {
+	return err
+}
+
+// Apply returns the source members that could be neither mapped nor translated, they are not written to the target.
+func (service ApplicationsService) Apply(jsonRequest Orchestration) ([]string, error) {
+	return service.ApplyTracked(jsonRequest, untracked)
+}
+
+// ApplyTracked applies the source application's policies to the target, reporting each step to track.
+func (service ApplicationsService) ApplyTracked(jsonRequest Orchestration, track StepTracker) ([]string, error) {
+	plan, err := service.plan(jsonRequest, track)
+	if err != nil {
+		return nil, err
+	}
+
+	status, setErr := service.write(jsonRequest.To, plan.application, plan.integration, plan.provider, plan.current, plan.proposed, "orchestration from "+jsonRequest.From)
+	if setErr == nil && status != http.StatusCreated {
+		setErr = fmt.Errorf("unable to set policies, provider responded %s", http.StatusText(status))
+	}
+	if status == http.StatusBadRequest {
+		setErr = workflowsupport.Permanent(setErr) // the provider rejects these policies however often they are written
+	}
+	return plan.unmapped, track(StepWriteTarget, setErr)
+}
+
+// Preview returns the changes an orchestration would make and the source members it would leave out.
+func (service ApplicationsService) Preview(jsonRequest Orchestration) (policysupport.PolicyDiff, []string, error) {
+	plan, err := service.plan(jsonRequest, untracked)
+	if err != nil {
+		return policysupport.PolicyDiff{}, nil, err
+	}
+	return policysupport.Diff(plan.current, plan.proposed), plan.unmapped, nil
+}
+
+// Targets resolves the orchestration's target applications, its single target, its listed targets and any applications
+// matching its selector, in that order and without duplicates. The source application is never a target of itself.
+func (service ApplicationsService) Targets(jsonRequest Orchestration) ([]string, error) {
+	targets := make([]string, 0)
+	seen := map[string]bool{jsonRequest.From: true}
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			targets = append(targets, id)
+		}
+	}
+
+	add(jsonRequest.To)
+	for _, id := range jsonRequest.Targets {
+		add(id)
+	}
+	if jsonRequest.Selector == nil {
+		return targets, nil
+	}
+
+	selector := *jsonRequest.Selector
+	if selector.IntegrationId == "" && selector.Name == "" {
+		return nil, errors.New("selector requires an integration_id or a name")
+	}
+	if _, err := path.Match(selector.Name, ""); err != nil {
+		return nil, fmt.Errorf("invalid selector name %q: %w", selector.Name, err)
+	}
+	records, err := service.ApplicationsGateway.Find()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if selector.matches(record) {
+			add(record.ID)
+		}
+	}
+	return targets, nil
+}
+
+func (service ApplicationsService) plan(jsonRequest Orchestration, track StepTracker) (orchestrationPlan, error) {
+	fromPolicies, fromProvider, fromErr := service.read(jsonRequest.From)
+	if err := track(StepReadSource, fromErr); err != nil {
+		return orchestrationPlan{}, err
+	}
+
+	toApplication, toIntegration, toProvider, toErr := service.GatherRecords(jsonRequest.To)
+	var toPolicies []policysupport.PolicyInfo
+	if toErr == nil {
+		toPolicies, toErr = toProvider.GetPolicyInfo(toIntegration, toApplication)
+	}
+	if err := track(StepReadTarget, toErr); err != nil {
+		return orchestrationPlan{}, err
+	}
+
+	var unmapped []string
+	mapTo, translateErr := service.translatorFor(fromProvider, toProvider, toIntegration, toApplication)
+	if translateErr == nil {
+		fromPolicies, unmapped, translateErr = service.Map(fromProvider, mapTo, fromPolicies)
+	}
+	if err := track(StepTranslate, translateErr); err != nil {
+		return orchestrationPlan{}, err
+	}
+
+	fromPolicies = jsonRequest.Options.choose(fromPolicies)
+	if !jsonRequest.Options.KeepResource {
+		modifiedToPoliciesRetainingResourceId, err := service.RetainResource(fromPolicies, toPolicies)
+		if err != nil {
+			return orchestrationPlan{}, track(StepWriteTarget, err)
+		}
+		fromPolicies = modifiedToPoliciesRetainingResourceId
+	}
+	return orchestrationPlan{toApplication, toIntegration, toProvider, toPolicies, jsonRequest.Options.merge(fromPolicies, toPolicies), unmapped}, nil
+}
+
+// targetedProvider translates with the translator its provider built for the target application.
+type targetedProvider struct {
+	Provider
+	PolicyTranslator
+}
+
+func (service ApplicationsService) translatorFor(fromProvider, toProvider Provider, integration IntegrationInfo, application ApplicationInfo) (Provider, error) {
+	targeted, ok := toProvider.(TargetTranslator)
+	if !ok || toProvider == fromProvider {
+		return toProvider, nil
+	}
+	translator, err := targeted.TranslatorFor(integration, application)
+	if err != nil {
+		return nil, err
+	}
+	return targetedProvider{toProvider, translator}, nil
+}
+
+func (service ApplicationsService) read(identifier string) ([]policysupport.PolicyInfo, Provider, error) {
+	application, integration, provider, err := service.GatherRecords(identifier)
+	if err != nil {
+		return nil, nil, err
+	}
+	policies, err := provider.GetPolicyInfo(integration, application)
+	return policies, provider, err
+}
+
+func (service ApplicationsService) Translate(fromProvider, toProvider Provider, policies []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
+	canonical := policies
+	if translator, ok := fromProvider.(PolicyTranslator); ok {
+		found, err := translator.ToCanonical(policies)
+		if err != nil {
+			return nil, err
+		}
+		canonical = found
+	}
+
+	if translator, ok := toProvider.(PolicyTranslator); ok {
+		return translator.FromCanonical(canonical)
+	}
+	return canonical, nil
+}
+
+// Map moves policies to another provider. Members and actions found in the mapping tables take their mapped values,
+// the rest are translated. Members that can be neither mapped nor translated are dropped, along with policies left
+// without members, and returned; an action that cannot be mapped or translated fails the move.
+func (service ApplicationsService) Map(fromProvider, toProvider Provider, policies []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, []string, error) {
+	mappings := map[string]map[string]string{MappingMember: {}, MappingAction: {}}
+	if service.MappingsGateway.DB != nil {
+		found, err := service.MappingsGateway.FindBetween(fromProvider.Name(), toProvider.Name())
+		if err != nil {
+			return nil, nil, err
+		}
+		mappings = found
+	}
+	translate := func(policy policysupport.PolicyInfo) (policysupport.PolicyInfo, error) {
+		if toProvider == fromProvider {
+			return policy, nil
+		}
+		translated, err := service.Translate(fromProvider, toProvider, []policysupport.PolicyInfo{policy})
+		if err != nil {
+			return policysupport.PolicyInfo{}, workflowsupport.Permanent(err) // unlike reading the mappings, retrying won't help
+		}
+		return translated[0], nil
+	}
+
+	mapped := make([]policysupport.PolicyInfo, 0)
+	unmapped := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, policy := range policies {
+		pending := policy
+		pending.Actions = make([]policysupport.ActionInfo, 0)
+		actions := make([]policysupport.ActionInfo, 0)
+		for _, action := range policy.Actions {
+			if value, found := mappings[MappingAction][action.ActionUri]; found {
+				actions = append(actions, policysupport.ActionInfo{ActionUri: value})
+				continue
+			}
+			pending.Actions = append(pending.Actions, action)
+		}
+
+		pending.Subject = policysupport.SubjectInfo{Members: []string{}}
+		result, err := translate(pending)
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Actions = append(result.Actions, actions...)
+		result.Subject = policysupport.SubjectInfo{Members: make([]string, 0)}
+
+		for _, member := range policy.Subject.Members {
+			if value, found := mappings[MappingMember][member]; found {
+				result.Subject.Members = append(result.Subject.Members, value)
+				continue
+			}
+			pending.Subject = policysupport.SubjectInfo{Members: []string{member}}
+			translated, memberErr := translate(pending)
+			if memberErr != nil {
+				if !seen[member] {
+					seen[member] = true
+					unmapped = append(unmapped, member)
+				}
+				continue
+			}
+			result.Subject.Members = append(result.Subject.Members, translated.Subject.Members...)
+		}
+		if len(policy.Subject.Members) > 0 && len(result.Subject.Members) == 0 {
+			continue
+		}
+		mapped = append(mapped, result)
 	}
-	return nil
+	return mapped, unmapped, nil
 }
 
 func (service ApplicationsService) RetainResource(fromPolicies, toPolicies []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
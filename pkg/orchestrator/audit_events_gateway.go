@@ -0,0 +1,83 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type AuditEventRecord struct {
+	ID           string
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceId   string
+	PayloadHash  string
+	Outcome      string
+	Status       int
+	CreatedAt    time.Time
+}
+
+// AuditFilter narrows audit events, zero values are ignored.
+type AuditFilter struct {
+	From       time.Time
+	To         time.Time
+	Actor      string
+	ResourceId string
+}
+
+// AuditEventsDataGateway only appends and reads, the audit_events table rejects updates and deletes.
+type AuditEventsDataGateway struct {
+	DB *sql.DB
+}
+
+func (gateway AuditEventsDataGateway) Create(record AuditEventRecord) (string, error) {
+	var id string
+	err := gateway.DB.QueryRow(`insert into audit_events (actor, action, resource_type, resource_id, payload_hash, outcome, status) values ($1, $2, $3, $4, $5, $6, $7) returning id`,
+		record.Actor, record.Action, record.ResourceType, record.ResourceId, record.PayloadHash, record.Outcome, record.Status).Scan(&id)
+	return id, err
+}
+
+func (gateway AuditEventsDataGateway) Find(filter AuditFilter) ([]AuditEventRecord, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if !filter.From.IsZero() {
+		where("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("created_at < $%d", filter.To)
+	}
+	if filter.Actor != "" {
+		where("actor = $%d", filter.Actor)
+	}
+	if filter.ResourceId != "" {
+		where("resource_id = $%d", filter.ResourceId)
+	}
+
+	query := "select id, actor, action, resource_type, coalesce(resource_id, ''), payload_hash, outcome, status, created_at from audit_events"
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	rows, err := gateway.DB.Query(query+" order by created_at desc", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	records := make([]AuditEventRecord, 0)
+	for rows.Next() {
+		var record AuditEventRecord
+		if erroneousScan := rows.Scan(&record.ID, &record.Actor, &record.Action, &record.ResourceType, &record.ResourceId, &record.PayloadHash, &record.Outcome, &record.Status, &record.CreatedAt); erroneousScan != nil {
+			return nil, erroneousScan
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,61 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+type AuditEvents struct {
+	Events []AuditEvent `json:"events"`
+}
+
+type AuditEvent struct {
+	ID           string    `json:"id"`
+	Actor        string    `json:"actor"`
+	Action       string    `json:"action"`
+	ResourceType string    `json:"resource_type"`
+	ResourceId   string    `json:"resource_id"`
+	PayloadHash  string    `json:"payload_hash"`
+	Outcome      string    `json:"outcome"`
+	Status       int       `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type AuditHandler struct {
+	gateway AuditEventsDataGateway
+}
+
+// List accepts optional from and to RFC 3339 times along with actor and resource filters.
+func (handler AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := AuditFilter{Actor: query.Get("actor"), ResourceId: query.Get("resource")}
+	for name, found := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s time %q, expected RFC 3339", name, value), http.StatusBadRequest)
+				return
+			}
+			*found = parsed
+		}
+	}
+
+	records, err := handler.gateway.Find(filter)
+	if err != nil {
+		log.Println("Error accessing database: " + err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	list := AuditEvents{Events: make([]AuditEvent, 0)}
+	for _, rec := range records {
+		list.Events = append(list.Events, AuditEvent{rec.ID, rec.Actor, rec.Action, rec.ResourceType, rec.ResourceId, rec.PayloadHash, rec.Outcome, rec.Status, rec.CreatedAt})
+	}
+	data, _ := json.Marshal(list)
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,82 @@
+package orchestrator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/hexa-org/policy-orchestrator/pkg/hawksupport"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+type Auditor struct {
+	gateway AuditEventsDataGateway
+}
+
+// Audit records an event for each request handled by next, it expects to run within HawkMiddleware.
+// The resource function names the application or integration acted upon.
+func (a Auditor) Audit(action string, resourceType string, resource func(r *http.Request, payload []byte) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(payload))
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+
+		outcome := OutcomeSuccess
+		if recorder.status >= http.StatusBadRequest {
+			outcome = OutcomeFailure
+		}
+		sum := sha256.Sum256(payload)
+		record := AuditEventRecord{
+			Actor:        hawksupport.CredentialId(r),
+			Action:       action,
+			ResourceType: resourceType,
+			ResourceId:   resource(r, payload),
+			PayloadHash:  hex.EncodeToString(sum[:]),
+			Outcome:      outcome,
+			Status:       recorder.status,
+		}
+		if _, err := a.gateway.Create(record); err != nil {
+			log.Printf("Unable to record audit event %s by %s: %s", action, record.Actor, err.Error())
+		}
+	}
+}
+
+func routeId(r *http.Request, _ []byte) string {
+	return mux.Vars(r)["id"]
+}
+
+func noResource(_ *http.Request, _ []byte) string {
+	return ""
+}
+
+func orchestrationTarget(_ *http.Request, payload []byte) string {
+	var orchestration Orchestration
+	_ = json.Unmarshal(payload, &orchestration)
+	targets := orchestration.Targets
+	if orchestration.To != "" {
+		targets = append([]string{orchestration.To}, targets...)
+	}
+	return strings.Join(targets, ",")
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
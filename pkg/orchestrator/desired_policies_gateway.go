@@ -0,0 +1,69 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	DriftUnknown   = "unknown"
+	DriftInSync    = "in_sync"
+	DriftDetected  = "drifted"
+	DriftReapplied = "reapplied"
+	DriftErroneous = "error"
+)
+
+type DesiredPoliciesRecord struct {
+	ApplicationId string
+	Policies      []byte
+	UpdatedAt     time.Time
+	DriftStatus   string
+	Drift         []byte
+	DriftMessage  string
+	CheckedAt     *time.Time
+}
+
+type DesiredPoliciesDataGateway struct {
+	DB *sql.DB
+}
+
+// Save replaces an application's desired policies, the drift status is unknown until next checked.
+func (gateway DesiredPoliciesDataGateway) Save(applicationId string, policies []byte) error {
+	_, err := gateway.DB.Exec(`insert into desired_policies (application_id, policies) values ($1, $2)
+on conflict (application_id) do update set policies=excluded.policies, updated_at=now(), drift_status='unknown', drift=null, drift_message=null, checked_at=null`,
+		applicationId, policies)
+	return err
+}
+
+func (gateway DesiredPoliciesDataGateway) RecordDrift(applicationId string, status string, drift []byte, message string) error {
+	_, err := gateway.DB.Exec("update desired_policies set drift_status=$2, drift=$3, drift_message=$4, checked_at=now() where application_id=$1",
+		applicationId, status, drift, message)
+	return err
+}
+
+func (gateway DesiredPoliciesDataGateway) Find() ([]DesiredPoliciesRecord, error) {
+	rows, err := gateway.DB.Query("select application_id, policies, updated_at, drift_status, drift, coalesce(drift_message, ''), checked_at from desired_policies")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	records := make([]DesiredPoliciesRecord, 0)
+	for rows.Next() {
+		var record DesiredPoliciesRecord
+		if erroneousScan := rows.Scan(&record.ApplicationId, &record.Policies, &record.UpdatedAt, &record.DriftStatus, &record.Drift, &record.DriftMessage, &record.CheckedAt); erroneousScan != nil {
+			return nil, erroneousScan
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (gateway DesiredPoliciesDataGateway) FindByApplicationId(applicationId string) (DesiredPoliciesRecord, error) {
+	row := gateway.DB.QueryRow("select application_id, policies, updated_at, drift_status, drift, coalesce(drift_message, ''), checked_at from desired_policies where application_id=$1", applicationId)
+	var record DesiredPoliciesRecord
+	err := row.Scan(&record.ApplicationId, &record.Policies, &record.UpdatedAt, &record.DriftStatus, &record.Drift, &record.DriftMessage, &record.CheckedAt)
+	return record, err
+}
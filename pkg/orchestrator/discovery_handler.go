@@ -0,0 +1,87 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Discovery struct {
+	IntegrationId    string     `json:"integration_id"`
+	IntervalSeconds  int        `json:"interval_seconds"`
+	LastRunAt        *time.Time `json:"last_run_at"`
+	DurationMs       int64      `json:"duration_ms"`
+	ApplicationCount int        `json:"application_count"`
+	LastError        string     `json:"last_error,omitempty"`
+}
+
+type DiscoveryHandler struct {
+	integrationsGateway IntegrationsDataGateway
+	discoveryGateway    IntegrationDiscoveryDataGateway
+	worker              DiscoveryWorker
+}
+
+func (handler DiscoveryHandler) Show(w http.ResponseWriter, r *http.Request) {
+	handler.writeDiscovery(w, mux.Vars(r)["id"])
+}
+
+// Run discovers the integration's applications immediately, a failed discovery is reported by the returned last error.
+func (handler DiscoveryHandler) Run(w http.ResponseWriter, r *http.Request) {
+	record, err := handler.integrationsGateway.FindById(mux.Vars(r)["id"])
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "integration not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = handler.worker.Discover(record)
+	handler.writeDiscovery(w, record.ID)
+}
+
+// Update sets the integration's discovery interval, given in whole seconds.
+func (handler DiscoveryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var jsonRequest Discovery
+	if err := json.NewDecoder(r.Body).Decode(&jsonRequest); err != nil || jsonRequest.IntervalSeconds < 1 {
+		http.Error(w, "unable to update discovery, a positive interval_seconds is required.", http.StatusBadRequest)
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if _, err := handler.discoveryGateway.FindByIntegrationId(id); errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "integration not found", http.StatusNotFound)
+		return
+	}
+	if err := handler.discoveryGateway.SetInterval(id, time.Duration(jsonRequest.IntervalSeconds)*time.Second); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	handler.writeDiscovery(w, id)
+}
+
+func (handler DiscoveryHandler) writeDiscovery(w http.ResponseWriter, id string) {
+	record, err := handler.discoveryGateway.FindByIntegrationId(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "integration not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, _ := json.Marshal(Discovery{
+		IntegrationId:    record.IntegrationId,
+		IntervalSeconds:  int(record.Interval.Seconds()),
+		LastRunAt:        record.LastRunAt,
+		DurationMs:       record.Duration.Milliseconds(),
+		ApplicationCount: record.ApplicationCount,
+		LastError:        record.LastError,
+	})
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
@@ -1,64 +1,121 @@
 package orchestrator
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"time"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/workflowsupport"
 )
 
+const DefaultMissingGracePeriod = 7 * 24 * time.Hour
+
+// DiscoveryJob discovers the applications of the integration named by the job's payload.
+const DiscoveryJob = "discovery"
+
+// DiscoveryWorker reconciles each integration's applications with those its provider reports. Applications no longer
+// reported are marked missing and purged once unseen for longer than the grace period.
 type DiscoveryWorker struct {
-	Providers map[string]Provider
-	Gateway   ApplicationsDataGateway
+	Providers        map[string]Provider
+	Gateway          ApplicationsDataGateway
+	GracePeriod      time.Duration
+	DiscoveryGateway IntegrationDiscoveryDataGateway
+	Integrations     IntegrationsDataGateway
 }
 
-func (n *DiscoveryWorker) Run(work interface{}) error {
-	for _, p := range n.Providers {
-		log.Printf("Found discovery provider %s.", p.Name())
-
-		for _, record := range work.([]IntegrationRecord) {
-			log.Printf("Finding applications for integration provider %s.", p.Name())
-			applications, _ := p.DiscoverApplications(IntegrationInfo{Name: record.Provider, Key: record.Key})
+// RunJob fails when discovery fails so the job is retried, jobs for deleted integrations succeed without discovering.
+func (n *DiscoveryWorker) RunJob(job workflowsupport.Job) error {
+	record, err := n.Integrations.FindById(string(job.Payload))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if discoverErr := n.Discover(record); discoverErr != nil {
+		return discoverErr
+	}
+	return n.purge()
+}
 
-			log.Printf("Found %d applications for integration provider %s.", len(applications), p.Name())
-			for _, app := range applications {
-				_, _ = n.Gateway.CreateIfAbsent(record.ID, app.ObjectID, app.Name, app.Description) // idempotent work
-			}
-		}
+func (n *DiscoveryWorker) purge() error {
+	purged, err := n.Gateway.PurgeMissing(n.GracePeriod)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("Purged %d applications missing for longer than %s.", purged, n.GracePeriod)
 	}
 	return nil
 }
 
-type DiscoveryWorkFinder struct {
-	Results chan bool
-	Gateway IntegrationsDataGateway
+// Discover runs the integration's provider and records the run's duration, application count and error.
+func (n *DiscoveryWorker) Discover(record IntegrationRecord) error {
+	startedAt := time.Now()
+	count, err := n.discover(record)
+	message := ""
+	if err != nil {
+		log.Printf("Unable to find applications for integration %s, keeping existing applications: %s", record.ID, err.Error())
+		message = err.Error()
+	}
+	if recordErr := n.DiscoveryGateway.RecordRun(record.ID, startedAt, time.Since(startedAt), count, message); recordErr != nil {
+		log.Printf("Unable to record discovery for integration %s: %s", record.ID, recordErr.Error())
+	}
+	return err
 }
 
-func NewDiscoveryWorkFinder(gateway IntegrationsDataGateway) DiscoveryWorkFinder {
-	return DiscoveryWorkFinder{
-		Results: make(chan bool),
-		Gateway: gateway,
+func (n *DiscoveryWorker) discover(record IntegrationRecord) (int, error) {
+	p, found := n.Providers[strings.ToLower(record.Provider)]
+	if !found {
+		return 0, fmt.Errorf("unknown provider %s", record.Provider)
+	}
+	log.Printf("Finding applications for integration provider %s.", p.Name())
+	applications, err := p.DiscoverApplications(IntegrationInfo{Name: record.Provider, Key: record.Key})
+	if err != nil {
+		return 0, err
 	}
-}
 
-func (finder *DiscoveryWorkFinder) MarkErroneous() {
-	finder.Results <- false
+	log.Printf("Found %d applications for integration provider %s.", len(applications), p.Name())
+	objectIds := make([]string, 0)
+	for _, app := range applications {
+		if _, upsertErr := n.Gateway.Upsert(record.ID, app.ObjectID, app.Name, app.Description); upsertErr != nil {
+			return 0, upsertErr
+		}
+		objectIds = append(objectIds, app.ObjectID)
+	}
+	missing, err := n.Gateway.Reconcile(record.ID, objectIds)
+	if err != nil {
+		return 0, err
+	}
+	if missing > 0 {
+		log.Printf("Marked %d applications missing for integration %s.", missing, record.ID)
+	}
+	return len(applications), nil
 }
 
-func (finder *DiscoveryWorkFinder) MarkCompleted() {
-	finder.Results <- true
-}
+// discoverySlack absorbs the delay between a scheduler tick and the recorded start of the previous run.
+const discoverySlack = 5 * time.Second
 
-func (finder *DiscoveryWorkFinder) Stop() {
-	close(finder.Results)
+// DiscoveryWorkFinder finds integrations due for discovery, the scheduler's delay bounds the shortest effective interval.
+type DiscoveryWorkFinder struct {
+	Gateway IntegrationDiscoveryDataGateway
 }
 
-func (finder *DiscoveryWorkFinder) FindRequested() []interface{} {
-	found, err := finder.Gateway.Find()
+func (finder *DiscoveryWorkFinder) FindJobs() []workflowsupport.JobRequest {
+	states, err := finder.Gateway.Find()
 	if err != nil {
+		log.Printf("Unable to find integrations due for discovery: %s", err.Error())
 		return nil
 	}
-	var results []interface{}
-	if len(found) > 0 {
-		results = append(results, found)
-		return results
+	now := time.Now()
+	requests := make([]workflowsupport.JobRequest, 0)
+	for _, state := range states {
+		if state.Due(now, discoverySlack) {
+			requests = append(requests, workflowsupport.JobRequest{Kind: DiscoveryJob, Key: state.IntegrationId, Payload: []byte(state.IntegrationId)})
+		}
 	}
-	return results
+	return requests
 }
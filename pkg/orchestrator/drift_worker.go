@@ -0,0 +1,98 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/workflowsupport"
+)
+
+// DriftJob checks the drift of the application named by the job's payload.
+const DriftJob = "drift"
+
+// DriftWorker compares an application's desired policies with those found at its provider and optionally
+// re-applies the desired policies.
+type DriftWorker struct {
+	Service ApplicationsService
+	Gateway DesiredPoliciesDataGateway
+	Reapply bool
+}
+
+// RunJob fails when drift cannot be checked or re-applied so the job is retried.
+func (worker *DriftWorker) RunJob(job workflowsupport.Job) error {
+	record, err := worker.Gateway.FindByApplicationId(string(job.Payload))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return worker.Check(record)
+}
+
+// Check records the application's drift, returning the error recorded when drift could not be checked or re-applied.
+func (worker *DriftWorker) Check(record DesiredPoliciesRecord) error {
+	desired, err := unmarshalPolicies(record.Policies)
+	if err != nil {
+		worker.record(record.ApplicationId, DriftErroneous, policysupport.PolicyDiff{}, err.Error())
+		return err
+	}
+	actual, err := worker.Service.GetPolicies(record.ApplicationId)
+	if err != nil {
+		worker.record(record.ApplicationId, DriftErroneous, policysupport.PolicyDiff{}, err.Error())
+		return err
+	}
+
+	diff := policysupport.Diff(desired, actual)
+	if diff.IsEmpty() {
+		worker.record(record.ApplicationId, DriftInSync, diff, "")
+		return nil
+	}
+	if !worker.Reapply {
+		log.Printf("Found policy drift for application %s.", record.ApplicationId)
+		worker.record(record.ApplicationId, DriftDetected, diff, "")
+		return nil
+	}
+
+	log.Printf("Re-applying desired policies for application %s.", record.ApplicationId)
+	status, setErr := worker.Service.SetPolicies(record.ApplicationId, desired, "reapply desired policies")
+	if setErr != nil || status != http.StatusCreated {
+		message := http.StatusText(status)
+		if setErr != nil {
+			message = setErr.Error()
+		}
+		worker.record(record.ApplicationId, DriftDetected, diff, "unable to reapply desired policies, "+message)
+		return errors.New("unable to reapply desired policies, " + message)
+	}
+	worker.record(record.ApplicationId, DriftReapplied, diff, "")
+	return nil
+}
+
+func (worker *DriftWorker) record(applicationId string, status string, diff policysupport.PolicyDiff, message string) {
+	data, _ := json.Marshal(orchestrationDiff(diff, nil))
+	if err := worker.Gateway.RecordDrift(applicationId, status, data, message); err != nil {
+		log.Printf("Unable to record drift for application %s: %s", applicationId, err.Error())
+	}
+}
+
+// DriftWorkFinder finds every application with desired policies, each is checked once per scheduler delay.
+type DriftWorkFinder struct {
+	Gateway DesiredPoliciesDataGateway
+}
+
+func (finder *DriftWorkFinder) FindJobs() []workflowsupport.JobRequest {
+	records, err := finder.Gateway.Find()
+	if err != nil {
+		log.Printf("Unable to find desired policies: %s", err.Error())
+		return nil
+	}
+	requests := make([]workflowsupport.JobRequest, 0)
+	for _, record := range records {
+		requests = append(requests, workflowsupport.JobRequest{Kind: DriftJob, Key: record.ApplicationId, Payload: []byte(record.ApplicationId)})
+	}
+	return requests
+}
@@ -0,0 +1,135 @@
+package orchestrator_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator/test"
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/testsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/workflowsupport"
+	"github.com/stretchr/testify/assert"
+)
+
+func driftWorker(data *applicationsServiceData, reapply bool) (orchestrator.DriftWorker, *orchestrator_test.NoopProvider) {
+	noopProvider := &orchestrator_test.NoopProvider{}
+	data.providers["noop"] = noopProvider
+	desiredGateway := orchestrator.DesiredPoliciesDataGateway{DB: data.db}
+	service := orchestrator.ApplicationsService{
+		ApplicationsGateway:    orchestrator.ApplicationsDataGateway{DB: data.db},
+		IntegrationsGateway:    orchestrator.IntegrationsDataGateway{DB: data.db},
+		PolicySnapshotsGateway: orchestrator.PolicySnapshotsDataGateway{DB: data.db},
+		DesiredPoliciesGateway: desiredGateway,
+		Providers:              data.providers,
+	}
+	return orchestrator.DriftWorker{Service: service, Gateway: desiredGateway, Reapply: reapply}, noopProvider
+}
+
+func TestDriftWorker_inSync(t *testing.T) {
+	testsupport.WithSetUp(&applicationsServiceData{}, func(data *applicationsServiceData) {
+		worker, _ := driftWorker(data, false)
+		status, _ := worker.Service.SetPolicies(data.fromApp, []policysupport.PolicyInfo{}, "aReason")
+		assert.Equal(t, 201, status)
+
+		assert.NoError(t, worker.RunJob(workflowsupport.Job{Payload: []byte(data.fromApp)}))
+
+		record, _ := worker.Gateway.FindByApplicationId(data.fromApp)
+		assert.Equal(t, orchestrator.DriftInSync, record.DriftStatus)
+		assert.NotNil(t, record.CheckedAt)
+	})
+}
+
+func TestDriftWorker_drifted(t *testing.T) {
+	testsupport.WithSetUp(&applicationsServiceData{}, func(data *applicationsServiceData) {
+		worker, noopProvider := driftWorker(data, false)
+		_ = worker.Gateway.Save(data.fromApp, []byte(`{"policies":[{"meta":{"version":"aVersion"},"actions":[{"action_uri":"anAction"}],"subject":{"members":["aUser","anotherUser"]},"object":{"resource_id":"anId"}}]}`))
+
+		assert.NoError(t, worker.RunJob(workflowsupport.Job{Payload: []byte(data.fromApp)}))
+
+		record, _ := worker.Gateway.FindByApplicationId(data.fromApp)
+		assert.Equal(t, orchestrator.DriftDetected, record.DriftStatus)
+		var diff orchestrator.OrchestrationDiff
+		_ = json.Unmarshal(record.Drift, &diff)
+		assert.Equal(t, []string{"anotherAction"}, diff.ActionsAdded)
+		assert.Equal(t, []policysupport.MemberDiff{
+			{ActionUri: "anAction", Added: []string{}, Removed: []string{"anotherUser"}},
+			{ActionUri: "anotherAction", Added: []string{"anotherUser"}, Removed: []string{}},
+		}, diff.Members)
+		assert.Nil(t, noopProvider.Updated)
+	})
+}
+
+func TestDriftWorker_driftedCondition(t *testing.T) {
+	testsupport.WithSetUp(&applicationsServiceData{}, func(data *applicationsServiceData) {
+		worker, _ := driftWorker(data, false)
+		_ = worker.Gateway.Save(data.fromApp, []byte(`{"policies":[`+
+			`{"meta":{"version":"aVersion"},"actions":[{"action_uri":"anAction"}],"subject":{"members":["aUser"]},"object":{"resource_id":"anId"},"condition":{"rule":"req.ip sw 127"}},`+
+			`{"meta":{"version":"aVersion"},"actions":[{"action_uri":"anotherAction"}],"subject":{"members":["anotherUser"]},"object":{"resource_id":"anId"}}]}`))
+
+		assert.NoError(t, worker.RunJob(workflowsupport.Job{Payload: []byte(data.fromApp)}))
+
+		record, _ := worker.Gateway.FindByApplicationId(data.fromApp)
+		assert.Equal(t, orchestrator.DriftDetected, record.DriftStatus)
+		var diff orchestrator.OrchestrationDiff
+		_ = json.Unmarshal(record.Drift, &diff)
+		assert.Equal(t, []policysupport.MemberDiff{}, diff.Members)
+		assert.Equal(t, []policysupport.RuleDiff{
+			{ActionUri: "anAction", Added: []string{`allow on "anId"`}, Removed: []string{`allow on "anId" when req.ip sw 127`}},
+		}, diff.Rules)
+	})
+}
+
+func TestDriftWorker_reapplies(t *testing.T) {
+	testsupport.WithSetUp(&applicationsServiceData{}, func(data *applicationsServiceData) {
+		worker, noopProvider := driftWorker(data, true)
+		_ = worker.Gateway.Save(data.fromApp, []byte(`{"policies":[{"meta":{"version":"aVersion"},"actions":[{"action_uri":"anAction"}],"subject":{"members":["aUser","anotherUser"]},"object":{"resource_id":"anId"}}]}`))
+
+		assert.NoError(t, worker.RunJob(workflowsupport.Job{Payload: []byte(data.fromApp)}))
+
+		record, _ := worker.Gateway.FindByApplicationId(data.fromApp)
+		assert.Equal(t, orchestrator.DriftReapplied, record.DriftStatus)
+		assert.Equal(t, 1, len(noopProvider.Updated))
+		assert.Equal(t, []string{"aUser", "anotherUser"}, noopProvider.Updated[0].Subject.Members)
+
+		history, _ := worker.Service.History(data.fromApp)
+		assert.Equal(t, "reapply desired policies", history[0].Reason)
+	})
+}
+
+func TestDriftWorker_withProviderError(t *testing.T) {
+	testsupport.WithSetUp(&applicationsServiceData{}, func(data *applicationsServiceData) {
+		worker, noopProvider := driftWorker(data, true)
+		_ = worker.Gateway.Save(data.fromApp, []byte(`{"policies":[]}`))
+		noopProvider.Err = errors.New("oops")
+
+		assert.EqualError(t, worker.RunJob(workflowsupport.Job{Payload: []byte(data.fromApp)}), "oops")
+
+		record, _ := worker.Gateway.FindByApplicationId(data.fromApp)
+		assert.Equal(t, orchestrator.DriftErroneous, record.DriftStatus)
+		assert.Equal(t, "oops", record.DriftMessage)
+	})
+}
+
+func TestDriftWorker_RunJob(t *testing.T) {
+	testsupport.WithSetUp(&applicationsServiceData{}, func(data *applicationsServiceData) {
+		worker, noopProvider := driftWorker(data, false)
+		_ = worker.Gateway.Save(data.fromApp, []byte(`{"policies":[]}`))
+
+		finder := orchestrator.DriftWorkFinder{Gateway: worker.Gateway}
+		requests := finder.FindJobs()
+		assert.Equal(t, 1, len(requests))
+		assert.Equal(t, orchestrator.DriftJob, requests[0].Kind)
+
+		job := workflowsupport.Job{Kind: requests[0].Kind, Payload: requests[0].Payload}
+		assert.NoError(t, worker.RunJob(job))
+		record, _ := worker.Gateway.FindByApplicationId(data.fromApp)
+		assert.Equal(t, orchestrator.DriftInSync, record.DriftStatus)
+
+		noopProvider.Err = errors.New("oops")
+		assert.Equal(t, "oops", worker.RunJob(job).Error())
+
+		assert.NoError(t, worker.RunJob(workflowsupport.Job{Kind: orchestrator.DriftJob, Payload: []byte(data.toApp)}))
+	})
+}
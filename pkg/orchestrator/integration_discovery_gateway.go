@@ -0,0 +1,80 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"time"
+)
+
+const DefaultDiscoveryInterval = time.Minute
+
+// IntegrationDiscoveryRecord holds an integration's discovery interval and the outcome of its last run,
+// LastRunAt is nil until discovery first runs.
+type IntegrationDiscoveryRecord struct {
+	IntegrationId    string
+	Interval         time.Duration
+	LastRunAt        *time.Time
+	Duration         time.Duration
+	ApplicationCount int
+	LastError        string
+}
+
+// Due reports whether discovery should run, slack absorbs scheduling delays between runs.
+func (record IntegrationDiscoveryRecord) Due(now time.Time, slack time.Duration) bool {
+	return record.LastRunAt == nil || now.Sub(*record.LastRunAt) >= record.Interval-slack
+}
+
+type IntegrationDiscoveryDataGateway struct {
+	DB *sql.DB
+}
+
+const discoverySelect = `select i.id, coalesce(d.interval_seconds, 60), d.last_run_at, coalesce(d.duration_ms, 0), coalesce(d.application_count, 0), coalesce(d.last_error, '')
+from integrations i left join integration_discovery d on d.integration_id = i.id`
+
+// Find returns the discovery state of every integration, including those not yet discovered.
+func (gateway IntegrationDiscoveryDataGateway) Find() ([]IntegrationDiscoveryRecord, error) {
+	rows, err := gateway.DB.Query(discoverySelect)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	records := make([]IntegrationDiscoveryRecord, 0)
+	for rows.Next() {
+		record, erroneousScan := scanDiscovery(rows)
+		if erroneousScan != nil {
+			return nil, erroneousScan
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// FindByIntegrationId returns sql.ErrNoRows for unknown integrations.
+func (gateway IntegrationDiscoveryDataGateway) FindByIntegrationId(integrationId string) (IntegrationDiscoveryRecord, error) {
+	return scanDiscovery(gateway.DB.QueryRow(discoverySelect+" where i.id=$1", integrationId))
+}
+
+func (gateway IntegrationDiscoveryDataGateway) RecordRun(integrationId string, startedAt time.Time, duration time.Duration, applicationCount int, message string) error {
+	_, err := gateway.DB.Exec(`insert into integration_discovery (integration_id, last_run_at, duration_ms, application_count, last_error) values ($1, $2, $3, $4, nullif($5, ''))
+on conflict (integration_id) do update set last_run_at=excluded.last_run_at, duration_ms=excluded.duration_ms, application_count=excluded.application_count, last_error=excluded.last_error`,
+		integrationId, startedAt.UTC(), duration.Milliseconds(), applicationCount, message)
+	return err
+}
+
+func (gateway IntegrationDiscoveryDataGateway) SetInterval(integrationId string, interval time.Duration) error {
+	_, err := gateway.DB.Exec(`insert into integration_discovery (integration_id, interval_seconds) values ($1, $2)
+on conflict (integration_id) do update set interval_seconds=excluded.interval_seconds`,
+		integrationId, int(interval.Seconds()))
+	return err
+}
+
+func scanDiscovery(row interface{ Scan(...interface{}) error }) (IntegrationDiscoveryRecord, error) {
+	var record IntegrationDiscoveryRecord
+	var intervalSeconds, durationMs int64
+	err := row.Scan(&record.IntegrationId, &intervalSeconds, &record.LastRunAt, &durationMs, &record.ApplicationCount, &record.LastError)
+	record.Interval = time.Duration(intervalSeconds) * time.Second
+	record.Duration = time.Duration(durationMs) * time.Millisecond
+	return record, err
+}
@@ -1,7 +1,13 @@
 package orchestrator
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/databasesupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/encryptionsupport"
 )
 
 type IntegrationRecord struct {
@@ -9,21 +15,32 @@ type IntegrationRecord struct {
 	Name     string
 	Provider string
 	Key      []byte
+	KeyError string
 }
 
+// IntegrationsDataGateway encrypts integration keys with its envelope, keys are stored unencrypted without one.
 type IntegrationsDataGateway struct {
-	DB *sql.DB
+	DB       *sql.DB
+	Envelope *encryptionsupport.Envelope
 }
 
+var ErrMasterKeyRequired = errors.New("integration key is encrypted, a master key is required")
+
 func (gateway IntegrationsDataGateway) Create(name string, provider string, key []byte) (string, error) {
+	sealed, dataKey, masterKeyId, err := gateway.seal(key)
+	if err != nil {
+		return "", err
+	}
 	var id string
-	err := gateway.DB.QueryRow("insert into integrations (name, provider, key) values ($1, $2, $3) returning id",
-		name, provider, key).Scan(&id)
+	err = gateway.DB.QueryRow("insert into integrations (name, provider, key, data_key, master_key_id) values ($1, $2, $3, $4, $5) returning id",
+		name, provider, sealed, dataKey, masterKeyId).Scan(&id)
 	return id, err
 }
 
+// Find returns integrations whose keys cannot be opened, such as those sealed with another master key,
+// without a key and with the reason in KeyError.
 func (gateway IntegrationsDataGateway) Find() ([]IntegrationRecord, error) {
-	rows, err := gateway.DB.Query("select id, name, provider, key from integrations")
+	rows, err := gateway.DB.Query("select id, name, provider, key, data_key from integrations")
 	if err != nil {
 		return nil, err
 	}
@@ -34,22 +51,127 @@ func (gateway IntegrationsDataGateway) Find() ([]IntegrationRecord, error) {
 	records := make([]IntegrationRecord, 0)
 	for rows.Next() {
 		var record IntegrationRecord
-		if erroneousScan := rows.Scan(&record.ID, &record.Name, &record.Provider, &record.Key); erroneousScan != nil {
+		var dataKey []byte
+		if erroneousScan := rows.Scan(&record.ID, &record.Name, &record.Provider, &record.Key, &dataKey); erroneousScan != nil {
 			return nil, erroneousScan
 		}
+		if record.Key, err = gateway.open(record.Key, dataKey); err != nil {
+			log.Printf("Unable to open key of integration %s: %s", record.ID, err.Error())
+			record.Key = nil
+			record.KeyError = err.Error()
+		}
 		records = append(records, record)
 	}
 	return records, nil
 }
 
+// Update renames an integration and, when a key is given, replaces its key. Discovered applications are kept.
+func (gateway IntegrationsDataGateway) Update(id string, name string, key []byte) error {
+	var result sql.Result
+	var err error
+	if key == nil {
+		result, err = gateway.DB.Exec("update integrations set name=$2 where id=$1", id, name)
+	} else {
+		sealed, dataKey, masterKeyId, sealErr := gateway.seal(key)
+		if sealErr != nil {
+			return sealErr
+		}
+		result, err = gateway.DB.Exec("update integrations set name=$2, key=$3, data_key=$4, master_key_id=$5 where id=$1", id, name, sealed, dataKey, masterKeyId)
+	}
+	if err != nil {
+		return err
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 func (gateway IntegrationsDataGateway) Delete(id string) error {
 	_, err := gateway.DB.Exec("delete from integrations where id=$1", id)
 	return err
 }
 
 func (gateway IntegrationsDataGateway) FindById(id string) (IntegrationRecord, error) {
-	row := gateway.DB.QueryRow("select id, name, provider, key from integrations where id=$1", id)
+	row := gateway.DB.QueryRow("select id, name, provider, key, data_key from integrations where id=$1", id)
 	var record IntegrationRecord
-	err := row.Scan(&record.ID, &record.Name, &record.Provider, &record.Key)
+	var dataKey []byte
+	if err := row.Scan(&record.ID, &record.Name, &record.Provider, &record.Key, &dataKey); err != nil {
+		return record, err
+	}
+	key, err := gateway.open(record.Key, dataKey)
+	record.Key = key
 	return record, err
 }
+
+// RotateKeys moves every integration key to the gateway's envelope within a single transaction.
+// Wrapped data keys are re-wrapped with the new master key, unencrypted keys are encrypted.
+// Keys already held by the new master key are skipped so an interrupted rotation may be repeated.
+func (gateway IntegrationsDataGateway) RotateKeys(previous *encryptionsupport.Envelope) (int, error) {
+	if gateway.Envelope == nil {
+		return 0, ErrMasterKeyRequired
+	}
+	rotated, err := databasesupport.WithTransaction(gateway.DB, context.Background(), sql.TxOptions{}, func(tx *sql.Tx) (interface{}, error) {
+		rows, err := tx.Query("select id, key, data_key from integrations where master_key_id is distinct from $1 for update", gateway.Envelope.KeyId())
+		if err != nil {
+			return 0, err
+		}
+		type found struct {
+			id           string
+			key, dataKey []byte
+		}
+		pending := make([]found, 0)
+		for rows.Next() {
+			var f found
+			if erroneousScan := rows.Scan(&f.id, &f.key, &f.dataKey); erroneousScan != nil {
+				_ = rows.Close()
+				return 0, erroneousScan
+			}
+			pending = append(pending, f)
+		}
+		_ = rows.Close()
+
+		for _, f := range pending {
+			key, dataKey := f.key, f.dataKey
+			if dataKey == nil {
+				if key, dataKey, err = gateway.Envelope.Seal(f.key); err != nil {
+					return 0, err
+				}
+			} else {
+				if previous == nil {
+					return 0, ErrMasterKeyRequired
+				}
+				if dataKey, err = previous.Rewrap(f.dataKey, gateway.Envelope); err != nil {
+					return 0, err
+				}
+			}
+			if _, err = tx.Exec("update integrations set key=$2, data_key=$3, master_key_id=$4 where id=$1", f.id, key, dataKey, gateway.Envelope.KeyId()); err != nil {
+				return 0, err
+			}
+		}
+		return len(pending), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rotated.(int), nil
+}
+
+func (gateway IntegrationsDataGateway) seal(key []byte) ([]byte, []byte, *string, error) {
+	if gateway.Envelope == nil {
+		return key, nil, nil, nil
+	}
+	sealed, dataKey, err := gateway.Envelope.Seal(key)
+	keyId := gateway.Envelope.KeyId()
+	return sealed, dataKey, &keyId, err
+}
+
+func (gateway IntegrationsDataGateway) open(key []byte, dataKey []byte) ([]byte, error) {
+	if dataKey == nil {
+		return key, nil
+	}
+	if gateway.Envelope == nil {
+		return nil, ErrMasterKeyRequired
+	}
+	return gateway.Envelope.Open(key, dataKey)
+}
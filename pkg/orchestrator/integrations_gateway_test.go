@@ -1,9 +1,12 @@
 package orchestrator_test
 
 import (
+	"bytes"
+	"database/sql"
 	"testing"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/databasesupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/encryptionsupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
 	"github.com/hexa-org/policy-orchestrator/pkg/testsupport"
 	"github.com/stretchr/testify/assert"
@@ -68,3 +71,94 @@ func TestFindIntegrationById(t *testing.T) {
 		assert.Equal(t, []byte("aKey"), record.Key)
 	})
 }
+
+func TestIntegrationKeys_areEncrypted(t *testing.T) {
+	testsupport.WithSetUp(&integrationsTestData{}, func(data *integrationsTestData) {
+		envelope, _ := encryptionsupport.NewEnvelope(bytes.Repeat([]byte{1}, 32))
+		gateway := orchestrator.IntegrationsDataGateway{DB: data.gateway.DB, Envelope: envelope}
+		id, _ := gateway.Create("aName", "noop", []byte("aKey"))
+
+		var stored []byte
+		var masterKeyId string
+		_ = data.gateway.DB.QueryRow("select key, master_key_id from integrations where id=$1", id).Scan(&stored, &masterKeyId)
+		assert.NotContains(t, string(stored), "aKey")
+		assert.Equal(t, envelope.KeyId(), masterKeyId)
+
+		record, _ := gateway.FindById(id)
+		assert.Equal(t, []byte("aKey"), record.Key)
+		all, _ := gateway.Find()
+		assert.Equal(t, []byte("aKey"), all[0].Key)
+
+		_, err := data.gateway.FindById(id)
+		assert.ErrorIs(t, err, orchestrator.ErrMasterKeyRequired)
+		_, _ = data.gateway.Create("anotherName", "noop", []byte("anotherKey"))
+		withoutMasterKey, err := data.gateway.Find()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(withoutMasterKey))
+		for _, record := range withoutMasterKey {
+			if record.ID == id {
+				assert.Nil(t, record.Key)
+				assert.Equal(t, orchestrator.ErrMasterKeyRequired.Error(), record.KeyError)
+				continue
+			}
+			assert.Equal(t, []byte("anotherKey"), record.Key)
+			assert.Empty(t, record.KeyError)
+		}
+	})
+}
+
+func TestRotateIntegrationKeys(t *testing.T) {
+	testsupport.WithSetUp(&integrationsTestData{}, func(data *integrationsTestData) {
+		previous, _ := encryptionsupport.NewEnvelope(bytes.Repeat([]byte{1}, 32))
+		next, _ := encryptionsupport.NewEnvelope(bytes.Repeat([]byte{2}, 32))
+		_, _ = data.gateway.Create("aName", "noop", []byte("aKey"))
+		_, _ = orchestrator.IntegrationsDataGateway{DB: data.gateway.DB, Envelope: previous}.Create("anotherName", "noop", []byte("anotherKey"))
+
+		gateway := orchestrator.IntegrationsDataGateway{DB: data.gateway.DB, Envelope: next}
+		_, err := gateway.RotateKeys(nil)
+		assert.ErrorIs(t, err, orchestrator.ErrMasterKeyRequired)
+
+		rotated, err := gateway.RotateKeys(previous)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, rotated)
+
+		all, _ := gateway.Find()
+		keys := []string{string(all[0].Key), string(all[1].Key)}
+		assert.ElementsMatch(t, []string{"aKey", "anotherKey"}, keys)
+
+		_, err = data.gateway.RotateKeys(previous)
+		assert.ErrorIs(t, err, orchestrator.ErrMasterKeyRequired)
+	})
+}
+
+func TestUpdateIntegration(t *testing.T) {
+	testsupport.WithSetUp(&integrationsTestData{}, func(data *integrationsTestData) {
+		id, _ := data.gateway.Create("aName", "noop", []byte("aKey"))
+
+		assert.NoError(t, data.gateway.Update(id, "anotherName", nil))
+		record, _ := data.gateway.FindById(id)
+		assert.Equal(t, "anotherName", record.Name)
+		assert.Equal(t, []byte("aKey"), record.Key)
+
+		assert.NoError(t, data.gateway.Update(id, "anotherName", []byte("anotherKey")))
+		record, _ = data.gateway.FindById(id)
+		assert.Equal(t, "noop", record.Provider)
+		assert.Equal(t, []byte("anotherKey"), record.Key)
+
+		err := data.gateway.Update("50e00619-9f15-4e85-a7e9-f26d87ea12e7", "aName", nil)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestUpdateIntegration_keepsApplications(t *testing.T) {
+	testsupport.WithSetUp(&integrationsTestData{}, func(data *integrationsTestData) {
+		id, _ := data.gateway.Create("aName", "noop", []byte("aKey"))
+		applicationsGateway := orchestrator.ApplicationsDataGateway{DB: data.gateway.DB}
+		_, _ = applicationsGateway.Upsert(id, "anObjectId", "aName", "aDescription")
+
+		_ = data.gateway.Update(id, "aName", []byte("anotherKey"))
+
+		applications, _ := applicationsGateway.Find()
+		assert.Equal(t, 1, len(applications))
+	})
+}
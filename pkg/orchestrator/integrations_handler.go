@@ -1,33 +1,52 @@
 package orchestrator
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/hexa-org/policy-orchestrator/pkg/encryptionsupport"
 )
 
 type Integrations struct {
 	Integrations []Integration `json:"integrations"`
 }
 
+// Integration accepts a key on create, the key is never returned and is identified by a fingerprint instead.
 type Integration struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Provider string `json:"provider"`
-	Key      []byte `json:"key"`
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Provider       string `json:"provider"`
+	Key            []byte `json:"key,omitempty"`
+	KeyFingerprint string `json:"key_fingerprint,omitempty"`
+	KeyError       string `json:"key_error,omitempty"`
 }
 
 type IntegrationsHandler struct {
-	gateway IntegrationsDataGateway
-	worker  DiscoveryWorker
+	gateway   IntegrationsDataGateway
+	worker    DiscoveryWorker
+	providers map[string]Provider
 }
 
 func (handler IntegrationsHandler) List(w http.ResponseWriter, _ *http.Request) {
-	records, _ := handler.gateway.Find()
+	records, err := handler.gateway.Find()
+	if err != nil {
+		log.Println("Error accessing integrations: " + err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	var list Integrations
 	for _, rec := range records {
-		list.Integrations = append(list.Integrations, Integration{rec.ID, rec.Name, rec.Provider, rec.Key})
+		integration := Integration{ID: rec.ID, Name: rec.Name, Provider: rec.Provider, KeyError: rec.KeyError}
+		if rec.KeyError == "" {
+			integration.KeyFingerprint = encryptionsupport.Fingerprint(rec.Key)
+		}
+		list.Integrations = append(list.Integrations, integration)
 	}
 	data, _ := json.Marshal(list)
 	w.Header().Set("content-type", "application/json")
@@ -38,15 +57,80 @@ func (handler IntegrationsHandler) List(w http.ResponseWriter, _ *http.Request)
 func (handler IntegrationsHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var jsonRequest Integration
 	_ = json.NewDecoder(r.Body).Decode(&jsonRequest)
-	_, err := handler.gateway.Create(jsonRequest.Name, jsonRequest.Provider, jsonRequest.Key)
+	if err := handler.checkCredentials(jsonRequest.Provider, jsonRequest.Key); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id, err := handler.gateway.Create(jsonRequest.Name, jsonRequest.Provider, jsonRequest.Key)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	handler.RunWorker() // todo - make async?
+	handler.RunWorker(id) // todo - make async?
 	w.WriteHeader(http.StatusCreated)
 }
 
+func (handler IntegrationsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var jsonRequest Integration
+	if err := json.NewDecoder(r.Body).Decode(&jsonRequest); err != nil || jsonRequest.Name == "" {
+		http.Error(w, "unable to update integration, a name is required.", http.StatusBadRequest)
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if jsonRequest.Key != nil {
+		record, findErr := handler.gateway.FindById(id)
+		if errors.Is(findErr, sql.ErrNoRows) {
+			http.Error(w, "integration not found", http.StatusNotFound)
+			return
+		}
+		if findErr != nil {
+			http.Error(w, findErr.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := handler.checkCredentials(record.Provider, jsonRequest.Key); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	err := handler.gateway.Update(id, jsonRequest.Name, jsonRequest.Key)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "integration not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if jsonRequest.Key != nil {
+		handler.RunWorker(id)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Test checks a provider and key without saving them, providers unable to check credentials are reported as not implemented.
+func (handler IntegrationsHandler) Test(w http.ResponseWriter, r *http.Request) {
+	var jsonRequest Integration
+	if err := json.NewDecoder(r.Body).Decode(&jsonRequest); err != nil {
+		http.Error(w, "unable to test integration, invalid request.", http.StatusBadRequest)
+		return
+	}
+	provider, found := handler.providers[strings.ToLower(jsonRequest.Provider)]
+	if !found {
+		http.Error(w, fmt.Sprintf("unknown provider %s", jsonRequest.Provider), http.StatusBadRequest)
+		return
+	}
+	checker, ok := provider.(CredentialChecker)
+	if !ok {
+		http.Error(w, fmt.Sprintf("provider %s is unable to check credentials", jsonRequest.Provider), http.StatusNotImplemented)
+		return
+	}
+	if err := checker.CheckCredentials(IntegrationInfo{Name: provider.Name(), Key: jsonRequest.Key}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 func (handler IntegrationsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	if err := handler.gateway.Delete(mux.Vars(r)["id"]); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -55,7 +139,21 @@ func (handler IntegrationsHandler) Delete(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusOK)
 }
 
-func (handler IntegrationsHandler) RunWorker() {
-	find, _ := handler.gateway.Find()
-	_ = handler.worker.Run(find)
+// checkCredentials passes keys of unknown providers and of providers without a credential check.
+func (handler IntegrationsHandler) checkCredentials(providerName string, key []byte) error {
+	provider, found := handler.providers[strings.ToLower(providerName)]
+	if !found {
+		return nil
+	}
+	if checker, ok := provider.(CredentialChecker); ok {
+		return checker.CheckCredentials(IntegrationInfo{Name: provider.Name(), Key: key})
+	}
+	return nil
+}
+
+// RunWorker discovers a single integration's applications, recording the outcome in its discovery state.
+func (handler IntegrationsHandler) RunWorker(id string) {
+	if record, err := handler.gateway.FindById(id); err == nil {
+		_ = handler.worker.Discover(record)
+	}
 }
@@ -6,15 +6,19 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"testing"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/databasesupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/encryptionsupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/hawksupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/healthsupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator/test"
 	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -22,10 +26,11 @@ import (
 
 type HandlerSuite struct {
 	suite.Suite
-	db      *sql.DB
-	server  *http.Server
-	key     string
-	gateway orchestrator.IntegrationsDataGateway
+	db       *sql.DB
+	server   *http.Server
+	key      string
+	gateway  orchestrator.IntegrationsDataGateway
+	provider *orchestrator_test.NoopProvider
 }
 
 func TestIntegrationsHandler(t *testing.T) {
@@ -43,7 +48,8 @@ func (s *HandlerSuite) SetupTest() {
 	hash := sha256.Sum256([]byte("aKey"))
 	s.key = hex.EncodeToString(hash[:])
 
-	handlers, _ := orchestrator.LoadHandlers(s.db, hawksupport.NewCredentialStore(s.key), addr, map[string]orchestrator.Provider{})
+	s.provider = &orchestrator_test.NoopProvider{}
+	handlers, _ := orchestrator.LoadHandlers(s.db, hawksupport.NewCredentialStore(s.key), addr, map[string]orchestrator.Provider{"noop": s.provider}, orchestrator.Options{})
 	s.server = websupport.Create(addr, handlers, websupport.Options{})
 
 	go websupport.Start(s.server, listener)
@@ -67,7 +73,24 @@ func (s *HandlerSuite) TestList() {
 	integration := jsonResponse.Integrations[0]
 	assert.Equal(s.T(), "aName", integration.Name)
 	assert.Equal(s.T(), "noop", integration.Provider)
-	assert.Equal(s.T(), []byte("aKey"), integration.Key)
+	assert.Nil(s.T(), integration.Key)
+	assert.Equal(s.T(), encryptionsupport.Fingerprint([]byte("aKey")), integration.KeyFingerprint)
+}
+
+func (s *HandlerSuite) TestList_withUnopenedKey() {
+	envelope, _ := encryptionsupport.NewEnvelope(bytes.Repeat([]byte{1}, 32))
+	_, _ = orchestrator.IntegrationsDataGateway{DB: s.db, Envelope: envelope}.Create("aName", "noop", []byte("aKey"))
+
+	resp, _ := hawksupport.HawkGet(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations", s.server.Addr))
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var jsonResponse orchestrator.Integrations
+	_ = json.NewDecoder(resp.Body).Decode(&jsonResponse)
+
+	integration := jsonResponse.Integrations[0]
+	assert.Equal(s.T(), "aName", integration.Name)
+	assert.Empty(s.T(), integration.KeyFingerprint)
+	assert.Equal(s.T(), orchestrator.ErrMasterKeyRequired.Error(), integration.KeyError)
 }
 
 func (s *HandlerSuite) TestCreate() {
@@ -84,6 +107,40 @@ func (s *HandlerSuite) TestCreate() {
 	assert.Equal(s.T(), []byte("aKey"), record.Key)
 }
 
+func (s *HandlerSuite) TestCreate_withFailedCredentialCheck() {
+	s.provider.CheckErr = errors.New("invalid tenant")
+	marshal, _ := json.Marshal(orchestrator.Integration{Name: "aName", Provider: "noop", Key: []byte("aKey")})
+	resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations", s.server.Addr), bytes.NewReader(marshal))
+	assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(s.T(), "invalid tenant\n", string(body))
+
+	records, _ := s.gateway.Find()
+	assert.Equal(s.T(), 0, len(records))
+}
+
+func (s *HandlerSuite) TestTest() {
+	marshal, _ := json.Marshal(orchestrator.Integration{Provider: "noop", Key: []byte("aKey")})
+	resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/test", s.server.Addr), bytes.NewReader(marshal))
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	records, _ := s.gateway.Find()
+	assert.Equal(s.T(), 0, len(records))
+}
+
+func (s *HandlerSuite) TestTest_withFailedCredentialCheck() {
+	s.provider.CheckErr = errors.New("invalid tenant")
+	marshal, _ := json.Marshal(orchestrator.Integration{Provider: "noop", Key: []byte("aKey")})
+	resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/test", s.server.Addr), bytes.NewReader(marshal))
+	assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *HandlerSuite) TestTest_withUnknownProvider() {
+	marshal, _ := json.Marshal(orchestrator.Integration{Provider: "unknown", Key: []byte("aKey")})
+	resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/test", s.server.Addr), bytes.NewReader(marshal))
+	assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
 func (s *HandlerSuite) TestDelete() {
 	id, _ := s.gateway.Create("aName", "noop", []byte("aKey"))
 
@@ -100,3 +157,91 @@ func (s *HandlerSuite) TestDelete_withUnknownID() {
 	resp, _ := hawksupport.HawkGet(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/%s", s.server.Addr, "0000"))
 	assert.Equal(s.T(), resp.StatusCode, http.StatusInternalServerError)
 }
+
+func (s *HandlerSuite) TestUpdate() {
+	id, _ := s.gateway.Create("aName", "noop", []byte("aKey"))
+
+	marshal, _ := json.Marshal(orchestrator.Integration{Name: "anotherName", Key: []byte("anotherKey")})
+	resp, _ := hawksupport.HawkPut(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/%s", s.server.Addr, id), bytes.NewReader(marshal))
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	record, _ := s.gateway.FindById(id)
+	assert.Equal(s.T(), "anotherName", record.Name)
+	assert.Equal(s.T(), []byte("anotherKey"), record.Key)
+}
+
+func (s *HandlerSuite) TestUpdate_withFailedCredentialCheck() {
+	id, _ := s.gateway.Create("aName", "noop", []byte("aKey"))
+	s.provider.CheckErr = errors.New("invalid tenant")
+
+	marshal, _ := json.Marshal(orchestrator.Integration{Name: "anotherName", Key: []byte("anotherKey")})
+	resp, _ := hawksupport.HawkPut(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/%s", s.server.Addr, id), bytes.NewReader(marshal))
+	assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+
+	record, _ := s.gateway.FindById(id)
+	assert.Equal(s.T(), "aName", record.Name)
+}
+
+func (s *HandlerSuite) TestUpdate_withUnknownID() {
+	marshal, _ := json.Marshal(orchestrator.Integration{Name: "anotherName"})
+	resp, _ := hawksupport.HawkPut(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/%s", s.server.Addr, "50e00619-9f15-4e85-a7e9-f26d87ea12e7"), bytes.NewReader(marshal))
+	assert.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+}
+
+func (s *HandlerSuite) TestUpdate_withMissingName() {
+	id, _ := s.gateway.Create("aName", "noop", []byte("aKey"))
+
+	resp, _ := hawksupport.HawkPut(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/%s", s.server.Addr, id), bytes.NewReader([]byte(`{"key":"YUtleQ=="}`)))
+	assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *HandlerSuite) TestShowDiscovery() {
+	id, _ := s.gateway.Create("aName", "noop", []byte("aKey"))
+
+	resp, _ := hawksupport.HawkGet(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/%s/discovery", s.server.Addr, id))
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var jsonResponse orchestrator.Discovery
+	_ = json.NewDecoder(resp.Body).Decode(&jsonResponse)
+	assert.Equal(s.T(), id, jsonResponse.IntegrationId)
+	assert.Equal(s.T(), 60, jsonResponse.IntervalSeconds)
+}
+
+func (s *HandlerSuite) TestRunDiscovery() {
+	id, _ := s.gateway.Create("aName", "noop", []byte("aKey"))
+	s.provider.Err = errors.New("oops")
+
+	resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/%s/discovery", s.server.Addr, id), bytes.NewReader([]byte{}))
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var jsonResponse orchestrator.Discovery
+	_ = json.NewDecoder(resp.Body).Decode(&jsonResponse)
+	assert.Equal(s.T(), "oops", jsonResponse.LastError)
+	assert.NotNil(s.T(), jsonResponse.LastRunAt)
+}
+
+func (s *HandlerSuite) TestRunDiscovery_withUnknownID() {
+	resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/%s/discovery", s.server.Addr, "50e00619-9f15-4e85-a7e9-f26d87ea12e7"), bytes.NewReader([]byte{}))
+	assert.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+}
+
+func (s *HandlerSuite) TestUpdateDiscovery() {
+	id, _ := s.gateway.Create("aName", "noop", []byte("aKey"))
+
+	resp, _ := hawksupport.HawkPut(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/%s/discovery", s.server.Addr, id), bytes.NewReader([]byte(`{"interval_seconds":300}`)))
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var jsonResponse orchestrator.Discovery
+	_ = json.NewDecoder(resp.Body).Decode(&jsonResponse)
+	assert.Equal(s.T(), 300, jsonResponse.IntervalSeconds)
+}
+
+func (s *HandlerSuite) TestUpdateDiscovery_withInvalidInterval() {
+	id, _ := s.gateway.Create("aName", "noop", []byte("aKey"))
+
+	resp, _ := hawksupport.HawkPut(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/%s/discovery", s.server.Addr, id), bytes.NewReader([]byte(`{"interval_seconds":0}`)))
+	assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = hawksupport.HawkPut(&http.Client{}, "anId", s.key, fmt.Sprintf("http://%s/integrations/%s/discovery", s.server.Addr, "50e00619-9f15-4e85-a7e9-f26d87ea12e7"), bytes.NewReader([]byte(`{"interval_seconds":30}`)))
+	assert.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,94 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrMappingExists is returned when a value is already mapped between the same providers.
+var ErrMappingExists = errors.New("a mapping already exists for that value")
+
+const (
+	MappingMember = "member"
+	MappingAction = "action"
+)
+
+// MappingRecord replaces a member or action of one provider with its counterpart in another during orchestration.
+type MappingRecord struct {
+	ID           string
+	Kind         string
+	FromProvider string
+	FromValue    string
+	ToProvider   string
+	ToValue      string
+	CreatedAt    time.Time
+}
+
+type MappingsDataGateway struct {
+	DB *sql.DB
+}
+
+func (gateway MappingsDataGateway) Create(mapping MappingRecord) (string, error) {
+	var id string
+	err := gateway.DB.QueryRow(`insert into mappings (kind, from_provider, from_value, to_provider, to_value) values ($1, $2, $3, $4, $5) returning id`,
+		mapping.Kind, mapping.FromProvider, mapping.FromValue, mapping.ToProvider, mapping.ToValue).Scan(&id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return "", ErrMappingExists
+	}
+	return id, err
+}
+
+func (gateway MappingsDataGateway) Delete(id string) error {
+	_, err := gateway.DB.Exec("delete from mappings where id=$1", id)
+	return err
+}
+
+func (gateway MappingsDataGateway) Find() ([]MappingRecord, error) {
+	return gateway.query(`select id, kind, from_provider, from_value, to_provider, to_value, created_at from mappings
+order by kind, from_provider, to_provider, from_value`)
+}
+
+func (gateway MappingsDataGateway) FindById(id string) (MappingRecord, error) {
+	var record MappingRecord
+	err := gateway.DB.QueryRow(`select id, kind, from_provider, from_value, to_provider, to_value, created_at from mappings where id=$1`, id).
+		Scan(&record.ID, &record.Kind, &record.FromProvider, &record.FromValue, &record.ToProvider, &record.ToValue, &record.CreatedAt)
+	return record, err
+}
+
+// FindBetween returns the mappings from one provider to another, keyed by kind and then by the source value.
+func (gateway MappingsDataGateway) FindBetween(fromProvider, toProvider string) (map[string]map[string]string, error) {
+	records, err := gateway.query(`select id, kind, from_provider, from_value, to_provider, to_value, created_at from mappings
+where from_provider=$1 and to_provider=$2`, fromProvider, toProvider)
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]map[string]string{MappingMember: {}, MappingAction: {}}
+	for _, record := range records {
+		found[record.Kind][record.FromValue] = record.ToValue
+	}
+	return found, nil
+}
+
+func (gateway MappingsDataGateway) query(query string, args ...interface{}) ([]MappingRecord, error) {
+	rows, err := gateway.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	records := make([]MappingRecord, 0)
+	for rows.Next() {
+		var record MappingRecord
+		if erroneousScan := rows.Scan(&record.ID, &record.Kind, &record.FromProvider, &record.FromValue, &record.ToProvider, &record.ToValue, &record.CreatedAt); erroneousScan != nil {
+			return nil, erroneousScan
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,124 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Mappings struct {
+	Mappings []Mapping `json:"mappings"`
+}
+
+// Mapping replaces from_value, a member or action of from_provider, with to_value when orchestrating to to_provider.
+type Mapping struct {
+	ID           string    `json:"id"`
+	Kind         string    `json:"kind"`
+	FromProvider string    `json:"from_provider"`
+	FromValue    string    `json:"from_value"`
+	ToProvider   string    `json:"to_provider"`
+	ToValue      string    `json:"to_value"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type MappingsHandler struct {
+	gateway   MappingsDataGateway
+	providers map[string]Provider
+}
+
+func (handler MappingsHandler) List(w http.ResponseWriter, _ *http.Request) {
+	records, err := handler.gateway.Find()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	list := Mappings{Mappings: make([]Mapping, 0)}
+	for _, record := range records {
+		list.Mappings = append(list.Mappings, mapping(record))
+	}
+	data, _ := json.Marshal(list)
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func (handler MappingsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	record, err := handler.decode(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id, err := handler.gateway.Create(record)
+	if errors.Is(err, ErrMappingExists) {
+		http.Error(w, "unable to save mapping, "+err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	created, err := handler.gateway.FindById(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, _ := json.Marshal(mapping(created))
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("location", "/mappings/"+id)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(data)
+}
+
+func (handler MappingsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := handler.gateway.FindById(id); errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "mapping not found", http.StatusNotFound)
+		return
+	}
+	if err := handler.gateway.Delete(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// decode validates the mapping's kind, its values and that both of its providers are supported.
+func (handler MappingsHandler) decode(r *http.Request) (MappingRecord, error) {
+	var jsonRequest Mapping
+	if err := json.NewDecoder(r.Body).Decode(&jsonRequest); err != nil || strings.TrimSpace(jsonRequest.FromValue) == "" || strings.TrimSpace(jsonRequest.ToValue) == "" {
+		return MappingRecord{}, errors.New("unable to save mapping, a from_value and a to_value are required")
+	}
+	if jsonRequest.Kind != MappingMember && jsonRequest.Kind != MappingAction {
+		return MappingRecord{}, fmt.Errorf("unable to save mapping, kind must be %q or %q", MappingMember, MappingAction)
+	}
+	for _, name := range []string{jsonRequest.FromProvider, jsonRequest.ToProvider} {
+		if _, found := handler.providers[strings.ToLower(name)]; !found {
+			return MappingRecord{}, fmt.Errorf("unable to save mapping, unsupported provider %q", name)
+		}
+	}
+	return MappingRecord{
+		Kind:         jsonRequest.Kind,
+		FromProvider: strings.ToLower(jsonRequest.FromProvider),
+		FromValue:    strings.TrimSpace(jsonRequest.FromValue),
+		ToProvider:   strings.ToLower(jsonRequest.ToProvider),
+		ToValue:      strings.TrimSpace(jsonRequest.ToValue),
+	}, nil
+}
+
+func mapping(record MappingRecord) Mapping {
+	return Mapping{
+		ID:           record.ID,
+		Kind:         record.Kind,
+		FromProvider: record.FromProvider,
+		FromValue:    record.FromValue,
+		ToProvider:   record.ToProvider,
+		ToValue:      record.ToValue,
+		CreatedAt:    record.CreatedAt,
+	}
+}
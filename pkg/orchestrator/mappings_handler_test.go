@@ -0,0 +1,93 @@
+package orchestrator_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/hawksupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/healthsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator/test"
+	"github.com/hexa-org/policy-orchestrator/pkg/testsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
+	"github.com/stretchr/testify/assert"
+)
+
+type mappingsHandlerData struct {
+	mappingsTestData
+	server *http.Server
+	key    string
+}
+
+func (data *mappingsHandlerData) SetUp() {
+	data.mappingsTestData.SetUp()
+
+	listener, _ := net.Listen("tcp", "localhost:0")
+	addr := listener.Addr().String()
+
+	hash := sha256.Sum256([]byte("aKey"))
+	data.key = hex.EncodeToString(hash[:])
+
+	providers := map[string]orchestrator.Provider{"noop": &orchestrator_test.NoopProvider{}, "azure": &orchestrator_test.NoopProvider{}}
+	handlers, _ := orchestrator.LoadHandlers(data.gateway.DB, hawksupport.NewCredentialStore(data.key), addr, providers, orchestrator.Options{})
+	data.server = websupport.Create(addr, handlers, websupport.Options{})
+	go websupport.Start(data.server, listener)
+	healthsupport.WaitForHealthy(data.server)
+}
+
+func (data *mappingsHandlerData) TearDown() {
+	websupport.Stop(data.server)
+	data.mappingsTestData.TearDown()
+}
+
+func (data *mappingsHandlerData) post(mapping orchestrator.Mapping) *http.Response {
+	marshal, _ := json.Marshal(mapping)
+	resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", data.key, fmt.Sprintf("http://%s/mappings", data.server.Addr), bytes.NewReader(marshal))
+	return resp
+}
+
+func TestMappingsHandler_createListAndDelete(t *testing.T) {
+	testsupport.WithSetUp(&mappingsHandlerData{}, func(data *mappingsHandlerData) {
+		resp := data.post(orchestrator.Mapping{Kind: "member", FromProvider: "noop", FromValue: "aUser", ToProvider: "Azure", ToValue: " aPrincipalId "})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created orchestrator.Mapping
+		_ = json.NewDecoder(resp.Body).Decode(&created)
+		assert.Equal(t, "/mappings/"+created.ID, resp.Header.Get("location"))
+		assert.Equal(t, "azure", created.ToProvider)
+		assert.Equal(t, "aPrincipalId", created.ToValue)
+
+		duplicate := data.post(orchestrator.Mapping{Kind: "member", FromProvider: "noop", FromValue: "aUser", ToProvider: "azure", ToValue: "anotherPrincipalId"})
+		assert.Equal(t, http.StatusConflict, duplicate.StatusCode)
+
+		resp, _ = hawksupport.HawkGet(&http.Client{}, "anId", data.key, fmt.Sprintf("http://%s/mappings", data.server.Addr))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var list orchestrator.Mappings
+		_ = json.NewDecoder(resp.Body).Decode(&list)
+		assert.Equal(t, 1, len(list.Mappings))
+		assert.Equal(t, "aUser", list.Mappings[0].FromValue)
+
+		resp, _ = hawksupport.HawkDelete(&http.Client{}, "anId", data.key, fmt.Sprintf("http://%s/mappings/%s", data.server.Addr, created.ID))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = hawksupport.HawkDelete(&http.Client{}, "anId", data.key, fmt.Sprintf("http://%s/mappings/%s", data.server.Addr, created.ID))
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestMappingsHandler_createWithBadRequest(t *testing.T) {
+	testsupport.WithSetUp(&mappingsHandlerData{}, func(data *mappingsHandlerData) {
+		for _, mapping := range []orchestrator.Mapping{
+			{Kind: "member", FromProvider: "noop", ToProvider: "azure", ToValue: "aValue"},
+			{Kind: "member", FromProvider: "noop", FromValue: "aValue", ToProvider: "azure"},
+			{Kind: "aKind", FromProvider: "noop", FromValue: "aValue", ToProvider: "azure", ToValue: "aValue"},
+			{Kind: "action", FromProvider: "oops", FromValue: "aValue", ToProvider: "azure", ToValue: "aValue"},
+		} {
+			assert.Equal(t, http.StatusBadRequest, data.post(mapping).StatusCode)
+		}
+	})
+}
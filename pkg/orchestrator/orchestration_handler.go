@@ -1,26 +1,271 @@
 package orchestrator
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"path"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/workflowsupport"
 )
 
 type OrchestrationHandler struct {
 	applicationsService ApplicationsService
+	queue               *workflowsupport.JobQueue
 }
 
+// Orchestration applies the policies of one application to another. It fans out to several applications when it
+// lists targets or selects them.
 type Orchestration struct {
-	From string `json:"from"`
-	To   string `json:"to"`
+	From     string           `json:"from"`
+	To       string           `json:"to,omitempty"`
+	Targets  []string         `json:"targets,omitempty"`
+	Selector *TargetSelector  `json:"selector,omitempty"`
+	Options  TransformOptions `json:"options"`
+}
+
+// TargetSelector selects the applications of an integration, those whose name matches a pattern such as "payments-*",
+// or both. Applications no longer discovered are not selected.
+type TargetSelector struct {
+	IntegrationId string `json:"integration_id,omitempty"`
+	Name          string `json:"name,omitempty"`
+}
+
+func (s TargetSelector) matches(record ApplicationRecord) bool {
+	if record.Missing || (s.IntegrationId != "" && s.IntegrationId != record.IntegrationId) {
+		return false
+	}
+	matched, _ := path.Match(s.Name, record.Name)
+	return s.Name == "" || matched
+}
+
+func (o Orchestration) fansOut() bool {
+	return len(o.Targets) > 0 || o.Selector != nil
+}
+
+type OrchestrationResults struct {
+	Results []OrchestrationResult `json:"results"`
+}
+
+// OrchestrationResult is the outcome for one target of a fan-out, a failed target does not stop the others.
+type OrchestrationResult struct {
+	To       string                  `json:"to"`
+	Job      *OrchestrationJobStatus `json:"job,omitempty"`
+	Diff     *OrchestrationDiff      `json:"diff,omitempty"`
+	Unmapped []string                `json:"unmapped,omitempty"`
+	Error    string                  `json:"error,omitempty"`
+}
+
+// OrchestrationDiff lists, as unmapped, the source members that would be left out of the target's policies.
+type OrchestrationDiff struct {
+	policysupport.PolicyDiff
+	Unmapped []string `json:"unmapped,omitempty"`
+}
+
+type OrchestrationJobs struct {
+	Jobs []OrchestrationJobStatus `json:"jobs"`
+}
+
+type OrchestrationJobStatus struct {
+	ID          string              `json:"id"`
+	State       string              `json:"state"`
+	From        string              `json:"from"`
+	To          string              `json:"to"`
+	Progress    int                 `json:"progress"`
+	Steps       []OrchestrationStep `json:"steps"`
+	Attempts    int                 `json:"attempts"`
+	MaxAttempts int                 `json:"max_attempts"`
+	Error       string              `json:"error,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
 }
 
 func (o OrchestrationHandler) Update(writer http.ResponseWriter, request *http.Request) {
 	var jsonRequest Orchestration
 	_ = json.NewDecoder(request.Body).Decode(&jsonRequest)
-	err := o.applicationsService.Apply(jsonRequest)
+	dryRun := request.URL.Query().Get("dryRun") == "true"
+	if jsonRequest.fansOut() {
+		o.fanOut(writer, jsonRequest, dryRun)
+		return
+	}
+	if dryRun {
+		o.preview(writer, jsonRequest)
+		return
+	}
+	o.enqueue(writer, jsonRequest)
+}
+
+// enqueue accepts an orchestration between known applications, its job runs in the background.
+func (o OrchestrationHandler) enqueue(writer http.ResponseWriter, jsonRequest Orchestration) {
+	id, status, err := o.enqueueTarget(jsonRequest)
+	if err != nil {
+		http.Error(writer, err.Error(), status)
+		return
+	}
+	writer.Header().Set("location", "/orchestration/jobs/"+id)
+	o.writeJob(writer, id, http.StatusAccepted)
+}
+
+// enqueueTarget queues a job for the orchestration's single target, keeping its options.
+func (o OrchestrationHandler) enqueueTarget(jsonRequest Orchestration) (string, int, error) {
+	for _, id := range []string{jsonRequest.From, jsonRequest.To} {
+		if _, err := o.applicationsService.ApplicationsGateway.FindById(id); err != nil {
+			return "", http.StatusBadRequest, fmt.Errorf("unable to orchestrate, unknown application %q", id)
+		}
+	}
+	payload, _ := json.Marshal(Orchestration{From: jsonRequest.From, To: jsonRequest.To, Options: jsonRequest.Options})
+	id, err := o.queue.Enqueue(workflowsupport.JobRequest{Kind: OrchestrationJob, Payload: payload})
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	return id, http.StatusAccepted, nil
+}
+
+// fanOut queues a job, or previews the changes, for each target and reports the outcome per target. Failing targets
+// are reported without aborting the rest.
+func (o OrchestrationHandler) fanOut(writer http.ResponseWriter, jsonRequest Orchestration, dryRun bool) {
+	if _, err := o.applicationsService.ApplicationsGateway.FindById(jsonRequest.From); err != nil {
+		http.Error(writer, fmt.Sprintf("unable to orchestrate, unknown application %q", jsonRequest.From), http.StatusBadRequest)
+		return
+	}
+	targets, err := o.applicationsService.Targets(jsonRequest)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(targets) == 0 {
+		http.Error(writer, "unable to orchestrate, no target applications", http.StatusBadRequest)
+		return
+	}
+
+	results := OrchestrationResults{Results: make([]OrchestrationResult, 0)}
+	for _, to := range targets {
+		result := OrchestrationResult{To: to}
+		target := Orchestration{From: jsonRequest.From, To: to, Options: jsonRequest.Options}
+		if dryRun {
+			diff, unmapped, previewErr := o.applicationsService.Preview(target)
+			if previewErr != nil {
+				result.Error = previewErr.Error()
+			} else {
+				found := orchestrationDiff(diff, unmapped)
+				result.Diff = &found
+			}
+		} else {
+			result.Job, result.Error = o.enqueueResult(target)
+		}
+		results.Results = append(results.Results, result)
+	}
+
+	status := http.StatusAccepted
+	if dryRun {
+		status = http.StatusOK
+	}
+	data, _ := json.Marshal(results)
+	writer.Header().Set("content-type", "application/json")
+	writer.WriteHeader(status)
+	_, _ = writer.Write(data)
+}
+
+func (o OrchestrationHandler) enqueueResult(target Orchestration) (*OrchestrationJobStatus, string) {
+	id, _, err := o.enqueueTarget(target)
+	if err != nil {
+		return nil, err.Error()
+	}
+	job, err := o.queue.Store.FindById(id)
+	if err != nil {
+		return nil, err.Error()
+	}
+	status := orchestrationJobStatus(job)
+	return &status, ""
+}
+
+// Jobs lists the most recent orchestration jobs, newest first.
+func (o OrchestrationHandler) Jobs(writer http.ResponseWriter, _ *http.Request) {
+	jobs, err := o.queue.Store.FindByKind(OrchestrationJob, 50)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	list := OrchestrationJobs{Jobs: make([]OrchestrationJobStatus, 0)}
+	for _, job := range jobs {
+		list.Jobs = append(list.Jobs, orchestrationJobStatus(job))
+	}
+	data, _ := json.Marshal(list)
+	writer.Header().Set("content-type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write(data)
+}
+
+func (o OrchestrationHandler) Job(writer http.ResponseWriter, request *http.Request) {
+	o.writeJob(writer, mux.Vars(request)["id"], http.StatusOK)
+}
+
+func (o OrchestrationHandler) writeJob(writer http.ResponseWriter, id string, status int) {
+	job, err := o.queue.Store.FindById(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && job.Kind != OrchestrationJob) {
+		http.Error(writer, "orchestration job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, _ := json.Marshal(orchestrationJobStatus(job))
+	writer.Header().Set("content-type", "application/json")
+	writer.WriteHeader(status)
+	_, _ = writer.Write(data)
+}
+
+func orchestrationJobStatus(job workflowsupport.Job) OrchestrationJobStatus {
+	var orchestration Orchestration
+	_ = json.Unmarshal(job.Payload, &orchestration)
+	steps := make([]OrchestrationStep, 0)
+	if job.Result != nil {
+		_ = json.Unmarshal(job.Result, &steps)
+	}
+	return OrchestrationJobStatus{
+		ID:          job.ID,
+		State:       job.State,
+		From:        orchestration.From,
+		To:          orchestration.To,
+		Progress:    job.Progress,
+		Steps:       steps,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		Error:       job.LastError,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+	}
+}
+
+func (o OrchestrationHandler) preview(writer http.ResponseWriter, jsonRequest Orchestration) {
+	diff, unmapped, err := o.applicationsService.Preview(jsonRequest)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(writer, "application not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	writer.WriteHeader(http.StatusCreated)
+
+	data, _ := json.Marshal(orchestrationDiff(diff, unmapped))
+	writer.Header().Set("content-type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_, _ = writer.Write(data)
+}
+
+func orchestrationDiff(diff policysupport.PolicyDiff, unmapped []string) OrchestrationDiff {
+	if diff.Members == nil {
+		diff.Members = make([]policysupport.MemberDiff, 0)
+	}
+	if diff.Rules == nil {
+		diff.Rules = make([]policysupport.RuleDiff, 0)
+	}
+	return OrchestrationDiff{PolicyDiff: diff, Unmapped: unmapped}
 }
@@ -0,0 +1,79 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/workflowsupport"
+)
+
+// OrchestrationJob applies the policies of the orchestration in the job's payload.
+const OrchestrationJob = "orchestration"
+
+const (
+	StepPending   = "pending"
+	StepSucceeded = "succeeded"
+	StepFailed    = "failed"
+)
+
+// OrchestrationStep lists, as unmapped on the translate step, the source members left out of the target's policies.
+type OrchestrationStep struct {
+	Name     string   `json:"name"`
+	Status   string   `json:"status"`
+	Error    string   `json:"error,omitempty"`
+	Unmapped []string `json:"unmapped,omitempty"`
+}
+
+// OrchestrationWorker runs orchestration jobs, reporting the outcome of each step as the job's result.
+type OrchestrationWorker struct {
+	Service ApplicationsService
+	Jobs    workflowsupport.JobStore
+}
+
+func (worker *OrchestrationWorker) RunJob(job workflowsupport.Job) error {
+	var orchestration Orchestration
+	if err := json.Unmarshal(job.Payload, &orchestration); err != nil {
+		return workflowsupport.Permanent(err)
+	}
+
+	steps := make([]OrchestrationStep, 0)
+	for _, name := range OrchestrationSteps {
+		steps = append(steps, OrchestrationStep{Name: name, Status: StepPending})
+	}
+	worker.report(job.ID, steps)
+	unmapped, err := worker.Service.ApplyTracked(orchestration, func(step string, err error) error {
+		for i := range steps {
+			if steps[i].Name != step {
+				continue
+			}
+			steps[i].Status = StepSucceeded
+			if err != nil {
+				steps[i].Status, steps[i].Error = StepFailed, err.Error()
+			}
+		}
+		worker.report(job.ID, steps)
+		return err
+	})
+	if len(unmapped) > 0 {
+		for i := range steps {
+			if steps[i].Name == StepTranslate {
+				steps[i].Unmapped = unmapped
+			}
+		}
+		worker.report(job.ID, steps)
+	}
+	return err
+}
+
+func (worker *OrchestrationWorker) report(id string, steps []OrchestrationStep) {
+	completed := 0
+	for _, step := range steps {
+		if step.Status == StepSucceeded {
+			completed++
+		}
+	}
+	data, _ := json.Marshal(steps)
+	if err := worker.Jobs.Report(id, completed*100/len(steps), data); err != nil {
+		log.Printf("Unable to report progress of orchestration job %s: %s", id, err.Error())
+	}
+}
@@ -2,36 +2,98 @@ package orchestrator
 
 import (
 	"database/sql"
+	"os"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/hexa-org/policy-orchestrator/pkg/encryptionsupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/hawksupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/workflowsupport"
 	"github.com/hiyosi/hawk"
 )
 
-func LoadHandlers(database *sql.DB, store hawk.CredentialStore, hostPort string, providers map[string]Provider) (func(router *mux.Router), *workflowsupport.WorkScheduler) {
+type Options struct {
+	ReapplyDrift       bool                        // re-apply an application's desired policies when drift is found
+	Envelope           *encryptionsupport.Envelope // encrypts integration keys at rest
+	MissingGracePeriod time.Duration               // purge applications missing from discovery for longer, defaults to a week
+	Replica            string                      // names this replica when electing the scheduler's leader, defaults to the host name
+}
+
+// SchedulerLease names the lease held by the replica enqueueing scheduled discovery and drift checks.
+const SchedulerLease = "orchestrator.scheduler"
+
+func LoadHandlers(database *sql.DB, store hawk.CredentialStore, hostPort string, providers map[string]Provider, options Options) (func(router *mux.Router), *workflowsupport.WorkScheduler) {
 	applicationsGateway := ApplicationsDataGateway{database}
-	integrationsGateway := IntegrationsDataGateway{database}
-	applicationsService := ApplicationsService{ApplicationsGateway: applicationsGateway, IntegrationsGateway: integrationsGateway, Providers: providers}
+	integrationsGateway := IntegrationsDataGateway{database, options.Envelope}
+	snapshotsGateway := PolicySnapshotsDataGateway{database}
+	desiredGateway := DesiredPoliciesDataGateway{database}
+	auditGateway := AuditEventsDataGateway{database}
+	discoveryGateway := IntegrationDiscoveryDataGateway{database}
+	mappingsGateway := MappingsDataGateway{database}
+	applicationsService := ApplicationsService{ApplicationsGateway: applicationsGateway, IntegrationsGateway: integrationsGateway, PolicySnapshotsGateway: snapshotsGateway, DesiredPoliciesGateway: desiredGateway, MappingsGateway: mappingsGateway, Providers: providers}
 
-	worker := DiscoveryWorker{providers, applicationsGateway}
-	finder := NewDiscoveryWorkFinder(integrationsGateway)
+	gracePeriod := options.MissingGracePeriod
+	if gracePeriod == 0 {
+		gracePeriod = DefaultMissingGracePeriod
+	}
+	worker := DiscoveryWorker{providers, applicationsGateway, gracePeriod, discoveryGateway, integrationsGateway}
+	driftWorker := DriftWorker{applicationsService, desiredGateway, options.ReapplyDrift}
 
 	applicationsHandler := ApplicationsHandler{applicationsGateway, integrationsGateway, applicationsService}
-	integrationsHandler := IntegrationsHandler{integrationsGateway, worker}
-	orchestrationHandler := OrchestrationHandler{applicationsService: applicationsService}
+	integrationsHandler := IntegrationsHandler{integrationsGateway, worker, providers}
+	discoveryHandler := DiscoveryHandler{integrationsGateway, discoveryGateway, worker}
+	auditHandler := AuditHandler{auditGateway}
+	auditor := Auditor{auditGateway}
 
-	list := []workflowsupport.Worker{&worker}
-	scheduler := workflowsupport.NewScheduler(&finder, list, 60_000)
+	replica := options.Replica
+	if replica == "" {
+		replica, _ = os.Hostname()
+	}
+	orchestrationWorker := OrchestrationWorker{applicationsService, workflowsupport.JobStore{DB: database}}
+	syncRulesGateway := SyncRulesDataGateway{DB: database}
+	syncWorker := SyncWorker{applicationsService, syncRulesGateway}
+	syncRulesHandler := SyncRulesHandler{applicationsService, syncRulesGateway, syncWorker}
+	mappingsHandler := MappingsHandler{mappingsGateway, providers}
+	queue := workflowsupport.NewJobQueue(database, map[string]workflowsupport.JobWorker{DiscoveryJob: &worker, DriftJob: &driftWorker, OrchestrationJob: &orchestrationWorker, SyncJob: &syncWorker})
+	queue.Replica = replica
+	orchestrationHandler := OrchestrationHandler{applicationsService, queue}
+	finders := []workflowsupport.JobFinder{&DiscoveryWorkFinder{discoveryGateway}, &DriftWorkFinder{desiredGateway}, &SyncWorkFinder{syncRulesGateway}}
+	scheduler := workflowsupport.NewJobScheduler(queue, finders, 60_000, 1_000)
+	scheduler.Leader = workflowsupport.NewLeader(database, SchedulerLease, replica)
 
 	return func(router *mux.Router) {
 		router.HandleFunc("/applications", hawksupport.HawkMiddleware(applicationsHandler.List, store, hostPort)).Methods("GET")
 		router.HandleFunc("/applications/{id}", hawksupport.HawkMiddleware(applicationsHandler.Show, store, hostPort)).Methods("GET")
 		router.HandleFunc("/applications/{id}/policies", hawksupport.HawkMiddleware(applicationsHandler.GetPolicies, store, hostPort)).Methods("GET")
-		router.HandleFunc("/applications/{id}/policies", hawksupport.HawkMiddleware(applicationsHandler.SetPolicies, store, hostPort)).Methods("POST")
+		router.HandleFunc("/applications/{id}/policies", hawksupport.HawkMiddleware(auditor.Audit("policies.set", "application", routeId, applicationsHandler.SetPolicies), store, hostPort)).Methods("POST")
+		router.HandleFunc("/applications/{id}/drift", hawksupport.HawkMiddleware(applicationsHandler.Drift, store, hostPort)).Methods("GET")
+		router.HandleFunc("/applications/{id}/policies/history", hawksupport.HawkMiddleware(applicationsHandler.History, store, hostPort)).Methods("GET")
+		router.HandleFunc("/applications/{id}/policies/rollback/{snapshotId}", hawksupport.HawkMiddleware(auditor.Audit("policies.rollback", "application", routeId, applicationsHandler.Rollback), store, hostPort)).Methods("POST")
+		router.HandleFunc("/applications/{id}/policies/{policyId:.+}", hawksupport.HawkMiddleware(applicationsHandler.GetPolicy, store, hostPort)).Methods("GET")
+		router.HandleFunc("/applications/{id}/policies/{policyId:.+}", hawksupport.HawkMiddleware(auditor.Audit("policy.put", "application", routeId, applicationsHandler.PutPolicy), store, hostPort)).Methods("PUT")
+		router.HandleFunc("/applications/{id}/policies/{policyId:.+}", hawksupport.HawkMiddleware(auditor.Audit("policy.delete", "application", routeId, applicationsHandler.DeletePolicy), store, hostPort)).Methods("DELETE")
 		router.HandleFunc("/integrations", hawksupport.HawkMiddleware(integrationsHandler.List, store, hostPort)).Methods("GET")
-		router.HandleFunc("/integrations", hawksupport.HawkMiddleware(integrationsHandler.Create, store, hostPort)).Methods("POST")
-		router.HandleFunc("/integrations/{id}", hawksupport.HawkMiddleware(integrationsHandler.Delete, store, hostPort)).Methods("GET")
-		router.HandleFunc("/orchestration", hawksupport.HawkMiddleware(orchestrationHandler.Update, store, hostPort)).Methods("POST")
+		router.HandleFunc("/integrations", hawksupport.HawkMiddleware(auditor.Audit("integration.create", "integration", noResource, integrationsHandler.Create), store, hostPort)).Methods("POST")
+		router.HandleFunc("/integrations/test", hawksupport.HawkMiddleware(integrationsHandler.Test, store, hostPort)).Methods("POST")
+		router.HandleFunc("/integrations/{id}/discovery", hawksupport.HawkMiddleware(discoveryHandler.Show, store, hostPort)).Methods("GET")
+		router.HandleFunc("/integrations/{id}/discovery", hawksupport.HawkMiddleware(auditor.Audit("integration.discover", "integration", routeId, discoveryHandler.Run), store, hostPort)).Methods("POST")
+		router.HandleFunc("/integrations/{id}/discovery", hawksupport.HawkMiddleware(auditor.Audit("integration.discovery_interval", "integration", routeId, discoveryHandler.Update), store, hostPort)).Methods("PUT")
+		router.HandleFunc("/integrations/{id}", hawksupport.HawkMiddleware(auditor.Audit("integration.update", "integration", routeId, integrationsHandler.Update), store, hostPort)).Methods("PUT")
+		router.HandleFunc("/integrations/{id}", hawksupport.HawkMiddleware(auditor.Audit("integration.delete", "integration", routeId, integrationsHandler.Delete), store, hostPort)).Methods("GET")
+		router.HandleFunc("/audit", hawksupport.HawkMiddleware(auditHandler.List, store, hostPort)).Methods("GET")
+		router.HandleFunc("/orchestration", hawksupport.HawkMiddleware(auditor.Audit("orchestration.preview", "application", orchestrationTarget, orchestrationHandler.Update), store, hostPort)).Methods("POST").Queries("dryRun", "true")
+		router.HandleFunc("/orchestration/jobs", hawksupport.HawkMiddleware(orchestrationHandler.Jobs, store, hostPort)).Methods("GET")
+		router.HandleFunc("/orchestration/jobs/{id}", hawksupport.HawkMiddleware(orchestrationHandler.Job, store, hostPort)).Methods("GET")
+		router.HandleFunc("/orchestration", hawksupport.HawkMiddleware(auditor.Audit("orchestration.update", "application", orchestrationTarget, orchestrationHandler.Update), store, hostPort)).Methods("POST")
+		router.HandleFunc("/sync-rules", hawksupport.HawkMiddleware(syncRulesHandler.List, store, hostPort)).Methods("GET")
+		router.HandleFunc("/sync-rules", hawksupport.HawkMiddleware(auditor.Audit("sync_rule.create", "sync_rule", noResource, syncRulesHandler.Create), store, hostPort)).Methods("POST")
+		router.HandleFunc("/sync-rules/{id}", hawksupport.HawkMiddleware(syncRulesHandler.Show, store, hostPort)).Methods("GET")
+		router.HandleFunc("/sync-rules/{id}", hawksupport.HawkMiddleware(auditor.Audit("sync_rule.update", "sync_rule", routeId, syncRulesHandler.Update), store, hostPort)).Methods("PUT")
+		router.HandleFunc("/sync-rules/{id}", hawksupport.HawkMiddleware(auditor.Audit("sync_rule.delete", "sync_rule", routeId, syncRulesHandler.Delete), store, hostPort)).Methods("DELETE")
+		router.HandleFunc("/sync-rules/{id}/runs", hawksupport.HawkMiddleware(syncRulesHandler.Runs, store, hostPort)).Methods("GET")
+		router.HandleFunc("/sync-rules/{id}/runs", hawksupport.HawkMiddleware(auditor.Audit("sync_rule.run", "sync_rule", routeId, syncRulesHandler.Run), store, hostPort)).Methods("POST")
+		router.HandleFunc("/mappings", hawksupport.HawkMiddleware(mappingsHandler.List, store, hostPort)).Methods("GET")
+		router.HandleFunc("/mappings", hawksupport.HawkMiddleware(auditor.Audit("mapping.create", "mapping", noResource, mappingsHandler.Create), store, hostPort)).Methods("POST")
+		router.HandleFunc("/mappings/{id}", hawksupport.HawkMiddleware(auditor.Audit("mapping.delete", "mapping", routeId, mappingsHandler.Delete), store, hostPort)).Methods("DELETE")
 	}, &scheduler
 }
@@ -9,6 +9,25 @@ type Provider interface {
 	SetPolicyInfo(IntegrationInfo, ApplicationInfo, []policysupport.PolicyInfo) (status int, foundErr error)
 }
 
+// PolicyTranslator is implemented by providers whose action uris or members differ from the
+// canonical form described in policysupport, allowing policies to move between providers.
+type PolicyTranslator interface {
+	ToCanonical([]policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error)
+	FromCanonical([]policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error)
+}
+
+// TargetTranslator is implemented by providers that can only translate some canonical members after looking
+// them up in the target application, such as identities that must name an existing account.
+type TargetTranslator interface {
+	TranslatorFor(IntegrationInfo, ApplicationInfo) (PolicyTranslator, error)
+}
+
+// CredentialChecker is implemented by providers able to verify an integration key, typically with
+// a lightweight call to the provider, so that a mistyped key is rejected before it is saved.
+type CredentialChecker interface {
+	CheckCredentials(IntegrationInfo) error
+}
+
 type IntegrationInfo struct {
 	Name string
 	Key  []byte
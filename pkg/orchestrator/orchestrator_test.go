@@ -29,7 +29,7 @@ func TestOrchestratorHandlers(t *testing.T) {
 	providers := make(map[string]orchestrator.Provider)
 	providers["noop"] = &orchestrator_test.NoopProvider{}
 
-	handlers, _ := orchestrator.LoadHandlers(db, store, listener.Addr().String(), providers)
+	handlers, _ := orchestrator.LoadHandlers(db, store, listener.Addr().String(), providers, orchestrator.Options{})
 	server := websupport.Create(listener.Addr().String(), handlers, websupport.Options{})
 
 	go websupport.Start(server, listener)
@@ -0,0 +1,53 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"time"
+)
+
+type PolicySnapshotRecord struct {
+	ID            string
+	ApplicationId string
+	Phase         string
+	Reason        string
+	Policies      []byte
+	CreatedAt     time.Time
+}
+
+type PolicySnapshotsDataGateway struct {
+	DB *sql.DB
+}
+
+func (gateway PolicySnapshotsDataGateway) Create(applicationId string, phase string, reason string, policies []byte) (string, error) {
+	var id string
+	err := gateway.DB.QueryRow(`insert into policy_snapshots (application_id, phase, reason, policies) values ($1, $2, $3, $4) returning id`,
+		applicationId, phase, reason, policies).Scan(&id)
+	return id, err
+}
+
+func (gateway PolicySnapshotsDataGateway) FindByApplicationId(applicationId string) ([]PolicySnapshotRecord, error) {
+	rows, err := gateway.DB.Query("select id, application_id, phase, reason, policies, created_at from policy_snapshots where application_id=$1 order by created_at desc", applicationId)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	records := make([]PolicySnapshotRecord, 0)
+	for rows.Next() {
+		var record PolicySnapshotRecord
+		if erroneousScan := rows.Scan(&record.ID, &record.ApplicationId, &record.Phase, &record.Reason, &record.Policies, &record.CreatedAt); erroneousScan != nil {
+			return nil, erroneousScan
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (gateway PolicySnapshotsDataGateway) FindById(id string) (PolicySnapshotRecord, error) {
+	row := gateway.DB.QueryRow("select id, application_id, phase, reason, policies, created_at from policy_snapshots where id=$1", id)
+	var record PolicySnapshotRecord
+	err := row.Scan(&record.ID, &record.ApplicationId, &record.Phase, &record.Reason, &record.Policies, &record.CreatedAt)
+	return record, err
+}
@@ -0,0 +1,163 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SyncRuleRecord keeps a source application's policies applied to its targets, the listed applications and any
+// matching the selector. LastRunAt is nil until the rule first runs.
+type SyncRuleRecord struct {
+	ID            string
+	Name          string
+	SourceId      string
+	Targets       []string
+	Selector      *TargetSelector
+	Interval      time.Duration
+	Options       TransformOptions
+	Enabled       bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	LastRunAt     *time.Time
+	LastSucceeded bool
+	LastError     string
+}
+
+// Due reports whether an enabled rule should run, slack absorbs scheduling delays between runs.
+func (record SyncRuleRecord) Due(now time.Time, slack time.Duration) bool {
+	return record.Enabled && (record.LastRunAt == nil || now.Sub(*record.LastRunAt) >= record.Interval-slack)
+}
+
+type SyncRunRecord struct {
+	ID        string
+	RuleId    string
+	StartedAt time.Time
+	Duration  time.Duration
+	Succeeded bool
+	Results   []byte
+	Error     string
+}
+
+type SyncRulesDataGateway struct {
+	DB *sql.DB
+}
+
+const syncRuleSelect = `select s.id, s.name, s.source_id, s.targets, s.selector, s.interval_seconds, s.options, s.enabled, s.created_at, s.updated_at,
+r.started_at, coalesce(r.succeeded, false), coalesce(r.error, '')
+from sync_rules s left join lateral (select started_at, succeeded, error from sync_rule_runs where rule_id = s.id order by started_at desc limit 1) r on true`
+
+func (gateway SyncRulesDataGateway) Create(rule SyncRuleRecord) (string, error) {
+	targets, selector, options := marshalRule(rule)
+	var id string
+	err := gateway.DB.QueryRow(`insert into sync_rules (name, source_id, targets, selector, interval_seconds, options, enabled) values ($1, $2, $3, $4, $5, $6, $7) returning id`,
+		rule.Name, rule.SourceId, targets, selector, int(rule.Interval.Seconds()), options, rule.Enabled).Scan(&id)
+	return id, err
+}
+
+// Update replaces a rule's definition, sql.ErrNoRows for unknown rules. Its runs are kept.
+func (gateway SyncRulesDataGateway) Update(id string, rule SyncRuleRecord) error {
+	targets, selector, options := marshalRule(rule)
+	result, err := gateway.DB.Exec(`update sync_rules set name=$2, source_id=$3, targets=$4, selector=$5, interval_seconds=$6, options=$7, enabled=$8, updated_at=now() where id=$1`,
+		id, rule.Name, rule.SourceId, targets, selector, int(rule.Interval.Seconds()), options, rule.Enabled)
+	if err != nil {
+		return err
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (gateway SyncRulesDataGateway) Delete(id string) error {
+	_, err := gateway.DB.Exec("delete from sync_rules where id=$1", id)
+	return err
+}
+
+func (gateway SyncRulesDataGateway) Find() ([]SyncRuleRecord, error) {
+	rows, err := gateway.DB.Query(syncRuleSelect + " order by s.name")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	records := make([]SyncRuleRecord, 0)
+	for rows.Next() {
+		record, erroneousScan := scanSyncRule(rows)
+		if erroneousScan != nil {
+			return nil, erroneousScan
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (gateway SyncRulesDataGateway) FindById(id string) (SyncRuleRecord, error) {
+	return scanSyncRule(gateway.DB.QueryRow(syncRuleSelect+" where s.id=$1", id))
+}
+
+func (gateway SyncRulesDataGateway) RecordRun(ruleId string, startedAt time.Time, duration time.Duration, succeeded bool, results []byte, message string) error {
+	_, err := gateway.DB.Exec(`insert into sync_rule_runs (rule_id, started_at, duration_ms, succeeded, results, error) values ($1, $2, $3, $4, $5, nullif($6, ''))`,
+		ruleId, startedAt.UTC(), duration.Milliseconds(), succeeded, results, message)
+	return err
+}
+
+// FindRuns returns a rule's most recent runs, newest first.
+func (gateway SyncRulesDataGateway) FindRuns(ruleId string, limit int) ([]SyncRunRecord, error) {
+	rows, err := gateway.DB.Query(`select id, rule_id, started_at, duration_ms, succeeded, results, coalesce(error, '') from sync_rule_runs
+where rule_id=$1 order by started_at desc limit $2`, ruleId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	records := make([]SyncRunRecord, 0)
+	for rows.Next() {
+		var record SyncRunRecord
+		var durationMs int64
+		if erroneousScan := rows.Scan(&record.ID, &record.RuleId, &record.StartedAt, &durationMs, &record.Succeeded, &record.Results, &record.Error); erroneousScan != nil {
+			return nil, erroneousScan
+		}
+		record.Duration = time.Duration(durationMs) * time.Millisecond
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func marshalRule(rule SyncRuleRecord) (interface{}, []byte, []byte) {
+	targets := rule.Targets
+	if targets == nil {
+		targets = make([]string, 0)
+	}
+	var selector []byte
+	if rule.Selector != nil {
+		selector, _ = json.Marshal(rule.Selector)
+	}
+	options, _ := json.Marshal(rule.Options)
+	return pq.Array(targets), selector, options
+}
+
+func scanSyncRule(row interface{ Scan(...interface{}) error }) (SyncRuleRecord, error) {
+	var record SyncRuleRecord
+	var targets pq.StringArray
+	var selector, options []byte
+	var intervalSeconds int64
+	if err := row.Scan(&record.ID, &record.Name, &record.SourceId, &targets, &selector, &intervalSeconds, &options, &record.Enabled,
+		&record.CreatedAt, &record.UpdatedAt, &record.LastRunAt, &record.LastSucceeded, &record.LastError); err != nil {
+		return record, err
+	}
+	record.Targets = targets
+	record.Interval = time.Duration(intervalSeconds) * time.Second
+	if selector != nil {
+		record.Selector = &TargetSelector{}
+		_ = json.Unmarshal(selector, record.Selector)
+	}
+	_ = json.Unmarshal(options, &record.Options)
+	return record, nil
+}
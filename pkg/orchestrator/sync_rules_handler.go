@@ -0,0 +1,232 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type SyncRules struct {
+	SyncRules []SyncRule `json:"sync_rules"`
+}
+
+// SyncRule accepts a missing enabled as true, rules are returned with the outcome of their last run.
+type SyncRule struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	Source          string           `json:"source"`
+	Targets         []string         `json:"targets"`
+	Selector        *TargetSelector  `json:"selector,omitempty"`
+	IntervalSeconds int              `json:"interval_seconds"`
+	Options         TransformOptions `json:"options"`
+	Enabled         *bool            `json:"enabled,omitempty"`
+	LastRunAt       *time.Time       `json:"last_run_at"`
+	LastSucceeded   bool             `json:"last_succeeded"`
+	LastError       string           `json:"last_error,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+type SyncRuns struct {
+	Runs []SyncRun `json:"runs"`
+}
+
+type SyncRun struct {
+	ID         string                `json:"id"`
+	StartedAt  time.Time             `json:"started_at"`
+	DurationMs int64                 `json:"duration_ms"`
+	Succeeded  bool                  `json:"succeeded"`
+	Results    []OrchestrationResult `json:"results"`
+	Error      string                `json:"error,omitempty"`
+}
+
+type SyncRulesHandler struct {
+	applicationsService ApplicationsService
+	gateway             SyncRulesDataGateway
+	worker              SyncWorker
+}
+
+func (handler SyncRulesHandler) List(w http.ResponseWriter, _ *http.Request) {
+	records, err := handler.gateway.Find()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	list := SyncRules{SyncRules: make([]SyncRule, 0)}
+	for _, record := range records {
+		list.SyncRules = append(list.SyncRules, syncRule(record))
+	}
+	data, _ := json.Marshal(list)
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func (handler SyncRulesHandler) Show(w http.ResponseWriter, r *http.Request) {
+	handler.writeRule(w, mux.Vars(r)["id"], http.StatusOK)
+}
+
+func (handler SyncRulesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	record, err := handler.decode(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id, err := handler.gateway.Create(record)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("location", "/sync-rules/"+id)
+	handler.writeRule(w, id, http.StatusCreated)
+}
+
+func (handler SyncRulesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	record, err := handler.decode(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id := mux.Vars(r)["id"]
+	err = handler.gateway.Update(id, record)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "sync rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	handler.writeRule(w, id, http.StatusOK)
+}
+
+func (handler SyncRulesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := handler.gateway.Delete(mux.Vars(r)["id"]); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Run syncs the rule immediately, a failed run is reported by the returned last error.
+func (handler SyncRulesHandler) Run(w http.ResponseWriter, r *http.Request) {
+	record, err := handler.gateway.FindById(mux.Vars(r)["id"])
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "sync rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if syncErr := handler.worker.Sync(record); syncErr != nil {
+		http.Error(w, syncErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	handler.writeRule(w, record.ID, http.StatusOK)
+}
+
+// Runs lists the rule's most recent runs, newest first.
+func (handler SyncRulesHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := handler.gateway.FindById(id); errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "sync rule not found", http.StatusNotFound)
+		return
+	}
+	records, err := handler.gateway.FindRuns(id, 50)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	list := SyncRuns{Runs: make([]SyncRun, 0)}
+	for _, record := range records {
+		results := make([]OrchestrationResult, 0)
+		_ = json.Unmarshal(record.Results, &results)
+		list.Runs = append(list.Runs, SyncRun{
+			ID:         record.ID,
+			StartedAt:  record.StartedAt,
+			DurationMs: record.Duration.Milliseconds(),
+			Succeeded:  record.Succeeded,
+			Results:    results,
+			Error:      record.Error,
+		})
+	}
+	data, _ := json.Marshal(list)
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// decode validates a rule's source and listed targets exist and that it has targets to sync.
+func (handler SyncRulesHandler) decode(r *http.Request) (SyncRuleRecord, error) {
+	var jsonRequest SyncRule
+	if err := json.NewDecoder(r.Body).Decode(&jsonRequest); err != nil || jsonRequest.Name == "" || jsonRequest.IntervalSeconds < 1 {
+		return SyncRuleRecord{}, errors.New("unable to save sync rule, a name and a positive interval_seconds are required")
+	}
+	if len(jsonRequest.Targets) == 0 && jsonRequest.Selector == nil {
+		return SyncRuleRecord{}, errors.New("unable to save sync rule, targets or a selector are required")
+	}
+	for _, id := range append([]string{jsonRequest.Source}, jsonRequest.Targets...) {
+		if _, err := handler.applicationsService.ApplicationsGateway.FindById(id); err != nil {
+			return SyncRuleRecord{}, fmt.Errorf("unable to save sync rule, unknown application %q", id)
+		}
+	}
+	if _, err := handler.applicationsService.Targets(Orchestration{From: jsonRequest.Source, Selector: jsonRequest.Selector}); err != nil {
+		return SyncRuleRecord{}, fmt.Errorf("unable to save sync rule, %w", err)
+	}
+
+	enabled := jsonRequest.Enabled == nil || *jsonRequest.Enabled
+	return SyncRuleRecord{
+		Name:     jsonRequest.Name,
+		SourceId: jsonRequest.Source,
+		Targets:  jsonRequest.Targets,
+		Selector: jsonRequest.Selector,
+		Interval: time.Duration(jsonRequest.IntervalSeconds) * time.Second,
+		Options:  jsonRequest.Options,
+		Enabled:  enabled,
+	}, nil
+}
+
+func (handler SyncRulesHandler) writeRule(w http.ResponseWriter, id string, status int) {
+	record, err := handler.gateway.FindById(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "sync rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, _ := json.Marshal(syncRule(record))
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func syncRule(record SyncRuleRecord) SyncRule {
+	targets := record.Targets
+	if targets == nil {
+		targets = make([]string, 0)
+	}
+	enabled := record.Enabled
+	return SyncRule{
+		ID:              record.ID,
+		Name:            record.Name,
+		Source:          record.SourceId,
+		Targets:         targets,
+		Selector:        record.Selector,
+		IntervalSeconds: int(record.Interval.Seconds()),
+		Options:         record.Options,
+		Enabled:         &enabled,
+		LastRunAt:       record.LastRunAt,
+		LastSucceeded:   record.LastSucceeded,
+		LastError:       record.LastError,
+		CreatedAt:       record.CreatedAt,
+		UpdatedAt:       record.UpdatedAt,
+	}
+}
@@ -0,0 +1,150 @@
+package orchestrator_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/hawksupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/healthsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator/test"
+	"github.com/hexa-org/policy-orchestrator/pkg/testsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/websupport"
+	"github.com/stretchr/testify/assert"
+)
+
+type syncRulesHandlerData struct {
+	syncRulesTestData
+	server *http.Server
+	key    string
+}
+
+func (data *syncRulesHandlerData) SetUp() {
+	data.syncRulesTestData.SetUp()
+
+	listener, _ := net.Listen("tcp", "localhost:0")
+	addr := listener.Addr().String()
+
+	hash := sha256.Sum256([]byte("aKey"))
+	data.key = hex.EncodeToString(hash[:])
+
+	providers := map[string]orchestrator.Provider{"noop": &orchestrator_test.NoopProvider{}}
+	handlers, _ := orchestrator.LoadHandlers(data.gateway.DB, hawksupport.NewCredentialStore(data.key), addr, providers, orchestrator.Options{})
+	data.server = websupport.Create(addr, handlers, websupport.Options{})
+	go websupport.Start(data.server, listener)
+	healthsupport.WaitForHealthy(data.server)
+}
+
+func (data *syncRulesHandlerData) TearDown() {
+	websupport.Stop(data.server)
+	data.syncRulesTestData.TearDown()
+}
+
+func (data *syncRulesHandlerData) url(path string) string {
+	return fmt.Sprintf("http://%s%s", data.server.Addr, path)
+}
+
+func (data *syncRulesHandlerData) create(t *testing.T, rule orchestrator.SyncRule) orchestrator.SyncRule {
+	marshal, _ := json.Marshal(rule)
+	resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", data.key, data.url("/sync-rules"), bytes.NewReader(marshal))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created orchestrator.SyncRule
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Equal(t, "/sync-rules/"+created.ID, resp.Header.Get("location"))
+	return created
+}
+
+func TestSyncRulesHandler_createAndList(t *testing.T) {
+	testsupport.WithSetUp(&syncRulesHandlerData{}, func(data *syncRulesHandlerData) {
+		created := data.create(t, orchestrator.SyncRule{Name: "aRule", Source: data.fromApp, Targets: []string{data.toApp}, IntervalSeconds: 3600,
+			Options: orchestrator.TransformOptions{KeepResource: true}})
+		assert.True(t, *created.Enabled)
+		assert.Nil(t, created.LastRunAt)
+
+		resp, _ := hawksupport.HawkGet(&http.Client{}, "anId", data.key, data.url("/sync-rules"))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var list orchestrator.SyncRules
+		_ = json.NewDecoder(resp.Body).Decode(&list)
+		assert.Equal(t, 1, len(list.SyncRules))
+		assert.Equal(t, "aRule", list.SyncRules[0].Name)
+		assert.Equal(t, data.fromApp, list.SyncRules[0].Source)
+		assert.Equal(t, []string{data.toApp}, list.SyncRules[0].Targets)
+		assert.Equal(t, 3600, list.SyncRules[0].IntervalSeconds)
+		assert.True(t, list.SyncRules[0].Options.KeepResource)
+
+		resp, _ = hawksupport.HawkGet(&http.Client{}, "anId", data.key, data.url("/sync-rules/"+created.ID))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestSyncRulesHandler_createWithBadRequest(t *testing.T) {
+	testsupport.WithSetUp(&syncRulesHandlerData{}, func(data *syncRulesHandlerData) {
+		for _, rule := range []orchestrator.SyncRule{
+			{Source: data.fromApp, Targets: []string{data.toApp}, IntervalSeconds: 60},
+			{Name: "aRule", Source: data.fromApp, Targets: []string{data.toApp}},
+			{Name: "aRule", Source: data.fromApp, IntervalSeconds: 60},
+			{Name: "aRule", Source: data.fromApp, Targets: []string{"50e00619-9f15-4e85-a7e9-f26d87ea12e7"}, IntervalSeconds: 60},
+			{Name: "aRule", Source: "oops", Targets: []string{data.toApp}, IntervalSeconds: 60},
+			{Name: "aRule", Source: data.fromApp, Selector: &orchestrator.TargetSelector{}, IntervalSeconds: 60},
+		} {
+			marshal, _ := json.Marshal(rule)
+			resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", data.key, data.url("/sync-rules"), bytes.NewReader(marshal))
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+}
+
+func TestSyncRulesHandler_updateAndDelete(t *testing.T) {
+	testsupport.WithSetUp(&syncRulesHandlerData{}, func(data *syncRulesHandlerData) {
+		created := data.create(t, orchestrator.SyncRule{Name: "aRule", Source: data.fromApp, Targets: []string{data.toApp}, IntervalSeconds: 3600})
+
+		disabled := false
+		marshal, _ := json.Marshal(orchestrator.SyncRule{Name: "aNewName", Source: data.fromApp, Selector: &orchestrator.TargetSelector{Name: "another*"}, IntervalSeconds: 60, Enabled: &disabled})
+		resp, _ := hawksupport.HawkPut(&http.Client{}, "anId", data.key, data.url("/sync-rules/"+created.ID), bytes.NewReader(marshal))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var updated orchestrator.SyncRule
+		_ = json.NewDecoder(resp.Body).Decode(&updated)
+		assert.Equal(t, "aNewName", updated.Name)
+		assert.Equal(t, []string{}, updated.Targets)
+		assert.Equal(t, "another*", updated.Selector.Name)
+		assert.False(t, *updated.Enabled)
+
+		resp, _ = hawksupport.HawkPut(&http.Client{}, "anId", data.key, data.url("/sync-rules/50e00619-9f15-4e85-a7e9-f26d87ea12e7"), bytes.NewReader(marshal))
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp, _ = hawksupport.HawkDelete(&http.Client{}, "anId", data.key, data.url("/sync-rules/"+created.ID))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = hawksupport.HawkGet(&http.Client{}, "anId", data.key, data.url("/sync-rules/"+created.ID))
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestSyncRulesHandler_runAndRuns(t *testing.T) {
+	testsupport.WithSetUp(&syncRulesHandlerData{}, func(data *syncRulesHandlerData) {
+		created := data.create(t, orchestrator.SyncRule{Name: "aRule", Source: data.fromApp, Targets: []string{data.toApp, data.toAppDifferent}, IntervalSeconds: 3600})
+
+		resp, _ := hawksupport.HawkPost(&http.Client{}, "anId", data.key, data.url("/sync-rules/"+created.ID+"/runs"), bytes.NewReader(nil))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var ran orchestrator.SyncRule
+		_ = json.NewDecoder(resp.Body).Decode(&ran)
+		assert.NotNil(t, ran.LastRunAt)
+		assert.False(t, ran.LastSucceeded)
+		assert.Equal(t, data.toAppDifferent+": unsupported provider azure", ran.LastError)
+
+		resp, _ = hawksupport.HawkGet(&http.Client{}, "anId", data.key, data.url("/sync-rules/"+created.ID+"/runs"))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var runs orchestrator.SyncRuns
+		_ = json.NewDecoder(resp.Body).Decode(&runs)
+		assert.Equal(t, 1, len(runs.Runs))
+		assert.Equal(t, []orchestrator.OrchestrationResult{{To: data.toApp}, {To: data.toAppDifferent, Error: "unsupported provider azure"}}, runs.Runs[0].Results)
+
+		resp, _ = hawksupport.HawkGet(&http.Client{}, "anId", data.key, data.url("/sync-rules/50e00619-9f15-4e85-a7e9-f26d87ea12e7/runs"))
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,87 @@
+package orchestrator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/workflowsupport"
+)
+
+// SyncJob runs the sync rule named by the job's payload.
+const SyncJob = "sync"
+
+// syncSlack absorbs the delay between a scheduler tick and the recorded start of the previous run.
+const syncSlack = 5 * time.Second
+
+// SyncWorker applies a rule's source policies to each of its targets and records the run.
+type SyncWorker struct {
+	Service ApplicationsService
+	Rules   SyncRulesDataGateway
+}
+
+// RunJob fails only when the rule's run cannot be recorded, failing targets are reported by the run and retried on
+// the rule's schedule.
+func (worker *SyncWorker) RunJob(job workflowsupport.Job) error {
+	rule, err := worker.Rules.FindById(string(job.Payload))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return worker.Sync(rule)
+}
+
+// Sync orchestrates each target in turn, a failing target does not stop the rest.
+func (worker *SyncWorker) Sync(rule SyncRuleRecord) error {
+	startedAt := time.Now()
+	results := make([]OrchestrationResult, 0)
+	failures := make([]string, 0)
+
+	targets, err := worker.Service.Targets(Orchestration{From: rule.SourceId, Targets: rule.Targets, Selector: rule.Selector})
+	if err != nil {
+		failures = append(failures, err.Error())
+	}
+	for _, to := range targets {
+		result := OrchestrationResult{To: to}
+		unmapped, applyErr := worker.Service.Apply(Orchestration{From: rule.SourceId, To: to, Options: rule.Options})
+		result.Unmapped = unmapped
+		if applyErr != nil {
+			result.Error = applyErr.Error()
+			failures = append(failures, fmt.Sprintf("%s: %s", to, applyErr.Error()))
+		}
+		results = append(results, result)
+	}
+	if len(failures) > 0 {
+		log.Printf("Sync rule %s failed: %s", rule.Name, strings.Join(failures, "; "))
+	}
+
+	data, _ := json.Marshal(results)
+	return worker.Rules.RecordRun(rule.ID, startedAt, time.Since(startedAt), len(failures) == 0, data, strings.Join(failures, "; "))
+}
+
+// SyncWorkFinder finds enabled rules due to run, the scheduler's delay bounds the shortest effective interval.
+type SyncWorkFinder struct {
+	Gateway SyncRulesDataGateway
+}
+
+func (finder *SyncWorkFinder) FindJobs() []workflowsupport.JobRequest {
+	rules, err := finder.Gateway.Find()
+	if err != nil {
+		log.Printf("Unable to find sync rules due to run: %s", err.Error())
+		return nil
+	}
+	now := time.Now()
+	requests := make([]workflowsupport.JobRequest, 0)
+	for _, rule := range rules {
+		if rule.Due(now, syncSlack) {
+			requests = append(requests, workflowsupport.JobRequest{Kind: SyncJob, Key: rule.ID, Payload: []byte(rule.ID)})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,67 @@
+package orchestrator_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator/test"
+	"github.com/hexa-org/policy-orchestrator/pkg/testsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/workflowsupport"
+	"github.com/stretchr/testify/assert"
+)
+
+func syncWorker(data *syncRulesTestData, providers map[string]orchestrator.Provider) orchestrator.SyncWorker {
+	db := data.gateway.DB
+	service := orchestrator.ApplicationsService{
+		ApplicationsGateway:    orchestrator.ApplicationsDataGateway{DB: db},
+		IntegrationsGateway:    orchestrator.IntegrationsDataGateway{DB: db},
+		PolicySnapshotsGateway: orchestrator.PolicySnapshotsDataGateway{DB: db},
+		DesiredPoliciesGateway: orchestrator.DesiredPoliciesDataGateway{DB: db},
+		Providers:              providers,
+	}
+	return orchestrator.SyncWorker{Service: service, Rules: data.gateway}
+}
+
+func TestSyncWorker_syncsEachTarget(t *testing.T) {
+	testsupport.WithSetUp(&syncRulesTestData{}, func(data *syncRulesTestData) {
+		provider := &orchestrator_test.NoopProvider{}
+		worker := syncWorker(data, map[string]orchestrator.Provider{"noop": provider})
+		id, _ := data.gateway.Create(orchestrator.SyncRuleRecord{Name: "aRule", SourceId: data.fromApp, Targets: []string{data.toAppDifferent, data.toApp},
+			Interval: time.Hour, Options: orchestrator.TransformOptions{Actions: []string{"anAction"}}, Enabled: true})
+
+		assert.NoError(t, worker.RunJob(workflowsupport.Job{Kind: orchestrator.SyncJob, Payload: []byte(id)}))
+		assert.Equal(t, 2, len(provider.Updated))
+
+		record, _ := data.gateway.FindById(id)
+		assert.NotNil(t, record.LastRunAt)
+		assert.False(t, record.LastSucceeded)
+		assert.Equal(t, data.toAppDifferent+": unsupported provider azure", record.LastError)
+
+		runs, _ := data.gateway.FindRuns(id, 10)
+		var results []orchestrator.OrchestrationResult
+		_ = json.Unmarshal(runs[0].Results, &results)
+		assert.Equal(t, []orchestrator.OrchestrationResult{{To: data.toAppDifferent, Error: "unsupported provider azure"}, {To: data.toApp}}, results)
+	})
+}
+
+func TestSyncWorker_withDeletedRule(t *testing.T) {
+	testsupport.WithSetUp(&syncRulesTestData{}, func(data *syncRulesTestData) {
+		worker := syncWorker(data, map[string]orchestrator.Provider{})
+		assert.NoError(t, worker.RunJob(workflowsupport.Job{Kind: orchestrator.SyncJob, Payload: []byte("50e00619-9f15-4e85-a7e9-f26d87ea12e7")}))
+	})
+}
+
+func TestSyncWorkFinder_findsDueRules(t *testing.T) {
+	testsupport.WithSetUp(&syncRulesTestData{}, func(data *syncRulesTestData) {
+		id, _ := data.gateway.Create(orchestrator.SyncRuleRecord{Name: "aRule", SourceId: data.fromApp, Targets: []string{data.toApp}, Interval: time.Minute, Enabled: true})
+		_, _ = data.gateway.Create(orchestrator.SyncRuleRecord{Name: "aDisabledRule", SourceId: data.fromApp, Targets: []string{data.toApp}, Interval: time.Minute})
+		ranId, _ := data.gateway.Create(orchestrator.SyncRuleRecord{Name: "aRuleThatRan", SourceId: data.fromApp, Targets: []string{data.toApp}, Interval: time.Hour, Enabled: true})
+		_ = data.gateway.RecordRun(ranId, time.Now(), time.Second, true, []byte("[]"), "")
+
+		finder := orchestrator.SyncWorkFinder{Gateway: data.gateway}
+		requested := finder.FindJobs()
+		assert.Equal(t, []workflowsupport.JobRequest{{Kind: orchestrator.SyncJob, Key: id, Payload: []byte(id)}}, requested)
+	})
+}
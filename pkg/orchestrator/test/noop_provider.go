@@ -10,6 +10,9 @@ import (
 type NoopProvider struct {
 	Discovered int
 	Err        error
+	Updated    []policysupport.PolicyInfo
+	CheckErr   error
+	Rejected   error
 }
 
 func (n *NoopProvider) Name() string {
@@ -27,15 +30,23 @@ func (n *NoopProvider) DiscoverApplications(info orchestrator.IntegrationInfo) (
 
 func (n *NoopProvider) GetPolicyInfo(_ orchestrator.IntegrationInfo, _ orchestrator.ApplicationInfo) ([]policysupport.PolicyInfo, error) {
 	return []policysupport.PolicyInfo{
-		{policysupport.MetaInfo{Version: "aVersion"}, []policysupport.ActionInfo{{"anAction"}}, policysupport.SubjectInfo{Members: []string{"aUser"}}, policysupport.ObjectInfo{
+		{ID: "aPolicyId", Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "anAction"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
 			ResourceID: "anId",
 		}},
-		{policysupport.MetaInfo{Version: "aVersion"}, []policysupport.ActionInfo{{"anotherAction"}}, policysupport.SubjectInfo{Members: []string{"anotherUser"}}, policysupport.ObjectInfo{
+		{Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "anotherAction"}}, Subject: policysupport.SubjectInfo{Members: []string{"anotherUser"}}, Object: policysupport.ObjectInfo{
 			ResourceID: "anId",
 		}},
 	}, n.Err
 }
 
-func (n *NoopProvider) SetPolicyInfo(_ orchestrator.IntegrationInfo, _ orchestrator.ApplicationInfo, _ []policysupport.PolicyInfo) (int, error) {
+func (n *NoopProvider) SetPolicyInfo(_ orchestrator.IntegrationInfo, _ orchestrator.ApplicationInfo, policyInfos []policysupport.PolicyInfo) (int, error) {
+	if n.Rejected != nil {
+		return http.StatusBadRequest, n.Rejected
+	}
+	n.Updated = policyInfos
 	return http.StatusCreated, n.Err
 }
+
+func (n *NoopProvider) CheckCredentials(_ orchestrator.IntegrationInfo) error {
+	return n.CheckErr
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -60,6 +61,23 @@ func (a *AmazonProvider) ListUserPools(info orchestrator.IntegrationInfo) (apps
 	return apps, err
 }
 
+// CheckCredentials lists a single user pool, which fails for unknown access keys or regions.
+func (a *AmazonProvider) CheckCredentials(info orchestrator.IntegrationInfo) error {
+	foundCredentials := a.Credentials(info.Key)
+	if foundCredentials.AccessKeyID == "" || foundCredentials.SecretAccessKey == "" || foundCredentials.Region == "" {
+		return errors.New("unable to read amazon key, an accessKeyID, secretAccessKey and region are required")
+	}
+	client, err := a.getHttpClient(info)
+	if err != nil {
+		return err
+	}
+	poolsInput := cognitoidentityprovider.ListUserPoolsInput{MaxResults: 1}
+	if _, listErr := client.ListUserPools(context.Background(), &poolsInput); listErr != nil {
+		return fmt.Errorf("unable to list amazon cognito user pools in region %s, %w", foundCredentials.Region, listErr)
+	}
+	return nil
+}
+
 func (a *AmazonProvider) GetPolicyInfo(integrationInfo orchestrator.IntegrationInfo, applicationInfo orchestrator.ApplicationInfo) ([]policysupport.PolicyInfo, error) {
 	client, err := a.getHttpClient(integrationInfo)
 	if err != nil {
@@ -76,6 +94,7 @@ func (a *AmazonProvider) GetPolicyInfo(integrationInfo orchestrator.IntegrationI
 
 	var policies []policysupport.PolicyInfo
 	policies = append(policies, policysupport.PolicyInfo{
+		ID:      applicationInfo.ObjectID,
 		Meta:    policysupport.MetaInfo{Version: "0.5"},
 		Actions: []policysupport.ActionInfo{{"aws:amazon.cognito/access"}}, // todo - not sure what this should be just yet.
 		Subject: policysupport.SubjectInfo{Members: members},
@@ -96,6 +115,12 @@ func (a *AmazonProvider) SetPolicyInfo(integrationInfo orchestrator.IntegrationI
 	if errPolicies != nil {
 		return http.StatusInternalServerError, errPolicies
 	}
+	if errCondition := policysupport.RejectConditions(a.Name(), policyInfos); errCondition != nil {
+		return http.StatusBadRequest, errCondition
+	}
+	if errDeny := policysupport.RejectDenyEffects(a.Name(), policyInfos); errDeny != nil {
+		return http.StatusBadRequest, errDeny
+	}
 
 	client, err := a.getHttpClient(integrationInfo)
 	if err != nil {
@@ -103,11 +128,6 @@ func (a *AmazonProvider) SetPolicyInfo(integrationInfo orchestrator.IntegrationI
 	}
 
 	for _, policyInfo := range policyInfos {
-		var newUsers []string
-		for _, user := range policyInfo.Subject.Members {
-			newUsers = append(newUsers, user)
-		}
-
 		filter := "status=\"Enabled\""
 		userInput := cognitoidentityprovider.ListUsersInput{UserPoolId: &applicationInfo.ObjectID, Filter: &filter}
 		users, listUsersErr := client.ListUsers(context.Background(), &userInput)
@@ -116,6 +136,7 @@ func (a *AmazonProvider) SetPolicyInfo(integrationInfo orchestrator.IntegrationI
 			return http.StatusInternalServerError, listUsersErr
 		}
 		existingUsers := a.membersFrom(users)
+		newUsers := a.ResolveEmails(existingUsers, policyInfo.Subject.Members)
 
 		enableErr := a.EnableUsers(client, applicationInfo.ObjectID, a.ShouldEnable(existingUsers, newUsers))
 		if enableErr != nil {
@@ -132,6 +153,101 @@ func (a *AmazonProvider) SetPolicyInfo(integrationInfo orchestrator.IntegrationI
 	return http.StatusCreated, nil
 }
 
+const accessAction = "aws:amazon.cognito/access"
+
+func (a *AmazonProvider) ToCanonical(policyInfos []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
+	members, err := policysupport.TranslateMembers(policyInfos, func(member string) (string, error) {
+		parts := strings.SplitN(member, ":", 2)
+		if len(parts) == 2 && strings.Contains(parts[1], "@") {
+			return policysupport.CanonicalUserPrefix + parts[1], nil
+		}
+		return policysupport.CanonicalIdentityPrefix + member, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policysupport.TranslateActions(members, func(actionUri string) (string, error) {
+		if actionUri == accessAction {
+			return policysupport.CanonicalAccessAction, nil
+		}
+		return actionUri, nil
+	})
+}
+
+// FromCanonical translates user members only; identities from other providers cannot be told apart from cognito
+// usernames without the user pool, see TranslatorFor.
+func (a *AmazonProvider) FromCanonical(policyInfos []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
+	return a.fromCanonical(policyInfos, map[string]string{})
+}
+
+// TranslatorFor returns a translator that also accepts identities naming a username or sub of the user pool.
+func (a *AmazonProvider) TranslatorFor(integrationInfo orchestrator.IntegrationInfo, applicationInfo orchestrator.ApplicationInfo) (orchestrator.PolicyTranslator, error) {
+	client, err := a.getHttpClient(integrationInfo)
+	if err != nil {
+		return nil, err
+	}
+	users, err := client.ListUsers(context.Background(), &cognitoidentityprovider.ListUsersInput{UserPoolId: &applicationInfo.ObjectID})
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]string)
+	for _, u := range users.Users {
+		username := aws.ToString(u.Username)
+		member := username
+		var sub string
+		for _, attr := range u.Attributes {
+			switch aws.ToString(attr.Name) {
+			case "email":
+				member = fmt.Sprintf("%s:%s", username, aws.ToString(attr.Value))
+			case "sub":
+				sub = aws.ToString(attr.Value)
+			}
+		}
+		known[username] = member
+		if sub != "" {
+			known[sub] = member
+		}
+	}
+	return &cognitoTranslator{provider: a, known: known}, nil
+}
+
+func (a *AmazonProvider) fromCanonical(policyInfos []policysupport.PolicyInfo, known map[string]string) ([]policysupport.PolicyInfo, error) {
+	members, err := policysupport.TranslateMembers(policyInfos, func(member string) (string, error) {
+		switch {
+		case strings.HasPrefix(member, policysupport.CanonicalUserPrefix):
+			return strings.TrimPrefix(member, policysupport.CanonicalUserPrefix), nil // cognito accepts email aliases as usernames
+		case strings.HasPrefix(member, policysupport.CanonicalIdentityPrefix):
+			if found, ok := known[strings.TrimPrefix(member, policysupport.CanonicalIdentityPrefix)]; ok {
+				return found, nil
+			}
+		}
+		return "", policysupport.UntranslatableMember(a.Name(), member)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policysupport.TranslateActions(members, func(actionUri string) (string, error) {
+		if actionUri == policysupport.CanonicalAccessAction || actionUri == accessAction {
+			return accessAction, nil
+		}
+		return "", policysupport.UntranslatableAction(a.Name(), actionUri)
+	})
+}
+
+type cognitoTranslator struct {
+	provider *AmazonProvider
+	known    map[string]string
+}
+
+func (t *cognitoTranslator) ToCanonical(policyInfos []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
+	return t.provider.ToCanonical(policyInfos)
+}
+
+func (t *cognitoTranslator) FromCanonical(policyInfos []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
+	return t.provider.fromCanonical(policyInfos, t.known)
+}
+
 func (a *AmazonProvider) membersFrom(users *cognitoidentityprovider.ListUsersOutput) []string {
 	var members []string
 	for _, u := range users.Users {
@@ -144,6 +260,21 @@ func (a *AmazonProvider) membersFrom(users *cognitoidentityprovider.ListUsersOut
 	return members
 }
 
+// ResolveEmails replaces members given only as an email, as translated from other providers,
+// with the matching username:email member.
+func (a *AmazonProvider) ResolveEmails(existingUsers []string, desiredUsers []string) []string {
+	var resolved []string
+	for _, desired := range desiredUsers {
+		for _, existing := range existingUsers {
+			if !strings.Contains(desired, ":") && strings.HasSuffix(existing, ":"+desired) {
+				desired = existing
+			}
+		}
+		resolved = append(resolved, desired)
+	}
+	return resolved
+}
+
 func (a *AmazonProvider) EnableUsers(client CognitoClient, userPoolId string, shouldEnable []string) error {
 	for _, enable := range shouldEnable {
 		enable := cognitoidentityprovider.AdminEnableUserInput{UserPoolId: &userPoolId, Username: &strings.Split(enable, ":")[0]}
@@ -9,6 +9,7 @@ import (
 	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
 	"github.com/hexa-org/policy-orchestrator/pkg/orchestratorproviders/amazonwebservices"
 	"github.com/hexa-org/policy-orchestrator/pkg/orchestratorproviders/amazonwebservices/test"
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestratorproviders/microsoftazure"
 	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
 	"github.com/stretchr/testify/assert"
 )
@@ -181,3 +182,116 @@ func TestAmazonProvider_SetPolicyInfo_withDisableErr(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, status)
 	assert.Error(t, err)
 }
+
+func TestAmazonProvider_SetPolicyInfo_withUnsupportedPolicy(t *testing.T) {
+	mockClient := &amazonwebservices_test.MockClient{Errs: map[string]error{}}
+	p := &amazonwebservices.AmazonProvider{CognitoClientOverride: mockClient}
+	status, err := p.SetPolicyInfo(orchestrator.IntegrationInfo{}, orchestrator.ApplicationInfo{ObjectID: "anObjectId"}, []policysupport.PolicyInfo{{
+		Meta:      policysupport.MetaInfo{Version: "0"},
+		Actions:   []policysupport.ActionInfo{{ActionUri: "aws:amazon.cognito/access"}},
+		Subject:   policysupport.SubjectInfo{Members: []string{"aUser:aUser@amazon.com"}},
+		Object:    policysupport.ObjectInfo{ResourceID: "aResourceId"},
+		Condition: policysupport.ConditionInfo{Rule: "req.ip sw 127"},
+	}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.EqualError(t, err, "amazon does not support policy conditions, unable to apply condition \"req.ip sw 127\"")
+
+	status, err = p.SetPolicyInfo(orchestrator.IntegrationInfo{}, orchestrator.ApplicationInfo{ObjectID: "anObjectId"}, []policysupport.PolicyInfo{{
+		Meta:    policysupport.MetaInfo{Version: "0"},
+		Actions: []policysupport.ActionInfo{{ActionUri: "aws:amazon.cognito/access"}},
+		Subject: policysupport.SubjectInfo{Members: []string{"aUser:aUser@amazon.com"}},
+		Object:  policysupport.ObjectInfo{ResourceID: "aResourceId"},
+		Effect:  policysupport.EffectDeny,
+	}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Error(t, err)
+}
+
+func TestAmazonProvider_Translation(t *testing.T) {
+	p := &amazonwebservices.AmazonProvider{}
+	infos := []policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "aws:amazon.cognito/access"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser:aUser@amazon.com", "anotherUser"}}, Object: policysupport.ObjectInfo{ResourceID: "anId"}}}
+
+	canonical, err := p.ToCanonical(infos)
+	assert.NoError(t, err)
+	assert.Equal(t, policysupport.CanonicalAccessAction, canonical[0].Actions[0].ActionUri)
+	assert.Equal(t, []string{"user:aUser@amazon.com", "id:anotherUser"}, canonical[0].Subject.Members)
+
+	_, err = p.FromCanonical(canonical)
+	assert.EqualError(t, err, "amazon is unable to represent member \"id:anotherUser\"")
+
+	canonical[0].Subject.Members = []string{"user:aUser@amazon.com"}
+	amazon, err := p.FromCanonical(canonical)
+	assert.NoError(t, err)
+	assert.Equal(t, "aws:amazon.cognito/access", amazon[0].Actions[0].ActionUri)
+	assert.Equal(t, []string{"aUser@amazon.com"}, amazon[0].Subject.Members)
+
+	_, err = p.FromCanonical([]policysupport.PolicyInfo{{Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}}})
+	assert.EqualError(t, err, "amazon is unable to represent member \"allusers\"")
+}
+
+func TestAmazonProvider_TranslatorFor(t *testing.T) {
+	p := &amazonwebservices.AmazonProvider{CognitoClientOverride: &amazonwebservices_test.MockClient{}}
+	translator, err := p.TranslatorFor(orchestrator.IntegrationInfo{Name: "amazon"}, orchestrator.ApplicationInfo{ObjectID: "anId"})
+	assert.NoError(t, err)
+
+	canonical := []policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: policysupport.CanonicalAccessAction}}, Subject: policysupport.SubjectInfo{Members: []string{"user:anotherUser@amazon.com", "id:aUser", "id:aSub"}}, Object: policysupport.ObjectInfo{ResourceID: "anId"}}}
+	amazon, err := translator.FromCanonical(canonical)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"anotherUser@amazon.com", "aUser:aUser@amazon.com", "aUser:aUser@amazon.com"}, amazon[0].Subject.Members)
+
+	canonical[0].Subject.Members = []string{"id:anUnknownUser"}
+	_, err = translator.FromCanonical(canonical)
+	assert.EqualError(t, err, "amazon is unable to represent member \"id:anUnknownUser\"")
+}
+
+func TestAmazonProvider_TranslatorFor_fromAzure(t *testing.T) {
+	azure := &microsoftazure.AzureProvider{}
+	fromAzure := []policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "azure:00000000-0000-0000-0000-000000000000"}}, Subject: policysupport.SubjectInfo{Members: []string{"aPrincipalId"}}, Object: policysupport.ObjectInfo{ResourceID: "anId"}}}
+	canonical, err := azure.ToCanonical(fromAzure)
+	assert.NoError(t, err)
+
+	p := &amazonwebservices.AmazonProvider{CognitoClientOverride: &amazonwebservices_test.MockClient{}}
+	translator, err := p.TranslatorFor(orchestrator.IntegrationInfo{Name: "amazon"}, orchestrator.ApplicationInfo{ObjectID: "anId"})
+	assert.NoError(t, err)
+	_, err = translator.FromCanonical(canonical)
+	assert.EqualError(t, err, "amazon is unable to represent member \"id:aPrincipalId\"")
+	_, err = p.FromCanonical(canonical)
+	assert.EqualError(t, err, "amazon is unable to represent member \"id:aPrincipalId\"")
+}
+
+func TestAmazonProvider_TranslatorFor_withListErr(t *testing.T) {
+	p := &amazonwebservices.AmazonProvider{CognitoClientOverride: &amazonwebservices_test.MockClient{Errs: map[string]error{"ListUsers": errors.New("oops")}}}
+	_, err := p.TranslatorFor(orchestrator.IntegrationInfo{Name: "amazon"}, orchestrator.ApplicationInfo{ObjectID: "anId"})
+	assert.Error(t, err)
+}
+
+func TestAmazonProvider_ResolveEmails(t *testing.T) {
+	p := &amazonwebservices.AmazonProvider{}
+	resolved := p.ResolveEmails([]string{"aUser:aUser@amazon.com"}, []string{"aUser@amazon.com", "anotherUser@amazon.com", "yetAnotherUser:yetAnotherUser@amazon.com"})
+	assert.Equal(t, []string{"aUser:aUser@amazon.com", "anotherUser@amazon.com", "yetAnotherUser:yetAnotherUser@amazon.com"}, resolved)
+}
+
+func TestAmazonProvider_CheckCredentials(t *testing.T) {
+	key := []byte(`
+{
+  "accessKeyID": "anAccessKeyID",
+  "secretAccessKey": "aSecretAccessKey",
+  "region": "aRegion"
+}
+`)
+	info := orchestrator.IntegrationInfo{Name: "amazon", Key: key}
+	mockClient := &amazonwebservices_test.MockClient{}
+	p := &amazonwebservices.AmazonProvider{CognitoClientOverride: mockClient}
+	assert.NoError(t, p.CheckCredentials(info))
+
+	mockClient.Errs = map[string]error{"ListUserPools": errors.New("oops")}
+	err := p.CheckCredentials(info)
+	assert.Equal(t, "unable to list amazon cognito user pools in region aRegion, oops", err.Error())
+}
+
+func TestAmazonProvider_CheckCredentials_withMissingRegion(t *testing.T) {
+	key := []byte(`{"accessKeyID": "anAccessKeyID", "secretAccessKey": "aSecretAccessKey"}`)
+	p := &amazonwebservices.AmazonProvider{CognitoClientOverride: &amazonwebservices_test.MockClient{}}
+	err := p.CheckCredentials(orchestrator.IntegrationInfo{Name: "amazon", Key: key})
+	assert.Equal(t, "unable to read amazon key, an accessKeyID, secretAccessKey and region are required", err.Error())
+}
@@ -17,7 +17,9 @@ func (m *MockClient) ListUsers(_ context.Context, _ *cognitoidentityprovider.Lis
 	username := "aUser"
 	name := "email"
 	value := "aUser@amazon.com"
-	attributes := []types.AttributeType{{Name: &name, Value: &value}}
+	subName := "sub"
+	sub := "aSub"
+	attributes := []types.AttributeType{{Name: &name, Value: &value}, {Name: &subName, Value: &sub}}
 	return &cognitoidentityprovider.ListUsersOutput{
 		Users: []types.UserType{{Username: &username, Attributes: attributes}},
 	}, m.Errs["ListUsers"]
@@ -40,6 +40,20 @@ type engines struct {
 	DefaultHostname string `json:"defaultHostname"`
 }
 
+func (c *GoogleClient) GetProject() error {
+	url := fmt.Sprintf("https://compute.googleapis.com/compute/v1/projects/%s", c.ProjectId)
+
+	get, err := c.HttpClient.Get(url)
+	if err != nil {
+		log.Println("Unable to find google cloud project.")
+		return err
+	}
+	if get.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to access google cloud project %s, %s", c.ProjectId, get.Status)
+	}
+	return nil
+}
+
 func (c *GoogleClient) GetAppEngineApplications() ([]orchestrator.ApplicationInfo, error) {
 	url := fmt.Sprintf("https://appengine.googleapis.com/v1/apps/%s", c.ProjectId)
 	var appEngines engines
@@ -93,65 +107,116 @@ type policy struct {
 }
 
 type bindings struct {
+	Version  int           `json:"version,omitempty"`
 	Bindings []bindingInfo `json:"bindings"`
+	Etag     string        `json:"etag,omitempty"`
 }
 
 type bindingInfo struct {
-	Role    string   `json:"role"`
-	Members []string `json:"members"`
+	Role      string            `json:"role"`
+	Members   []string          `json:"members"`
+	Condition *bindingCondition `json:"condition,omitempty"`
 }
 
-func (c *GoogleClient) GetBackendPolicy(name, objectId string) ([]policysupport.PolicyInfo, error) {
-	var url string
-	if strings.HasPrefix(name, "k8s") { // todo - revisit and improve the decision here
-		url = fmt.Sprintf("https://iap.googleapis.com/v1/projects/%s/iap_web/compute/services/%s:getIamPolicy", c.ProjectId, objectId)
-	} else {
-		url = fmt.Sprintf("https://iap.googleapis.com/v1/projects/%s/iap_web/appengine-%s/services/default:getIamPolicy", c.ProjectId, objectId)
-	}
+type bindingCondition struct {
+	Title      string `json:"title"`
+	Expression string `json:"expression"`
+}
 
-	post, err := c.HttpClient.Post(url, "application/json", bytes.NewReader([]byte{}))
-	if err != nil {
-		log.Println("Unable to find google cloud policy.")
-		return []policysupport.PolicyInfo{}, err
-	}
-	log.Printf("Google cloud response %s.\n", post.Status)
+type getPolicyRequest struct {
+	Options getPolicyOptions `json:"options"`
+}
 
-	var binds bindings
-	if err = json.NewDecoder(post.Body).Decode(&binds); err != nil {
-		log.Println("Unable to decode google cloud policy.")
+type getPolicyOptions struct {
+	RequestedPolicyVersion int `json:"requestedPolicyVersion"`
+}
+
+// conditional bindings are only supported by version 3 iam policies
+const conditionalPolicyVersion = 3
+
+func (c *GoogleClient) GetBackendPolicy(name, objectId string) ([]policysupport.PolicyInfo, error) {
+	binds, err := c.getIamPolicy(name, objectId)
+	if err != nil {
 		return []policysupport.PolicyInfo{}, err
 	}
 
 	var policies []policysupport.PolicyInfo
 	for _, found := range binds.Bindings {
 		log.Printf("Found google cloud policy for role %s.\n", found.Role)
-		policies = append(policies, policysupport.PolicyInfo{
+		info := policysupport.PolicyInfo{
+			ID:      found.Role,
 			Meta:    policysupport.MetaInfo{Version: "0.5"},
-			Actions: []policysupport.ActionInfo{{"gcp:" + found.Role}},
+			Actions: []policysupport.ActionInfo{{ActionUri: "gcp:" + found.Role}},
 			Subject: policysupport.SubjectInfo{Members: found.Members},
 			Object: policysupport.ObjectInfo{
 				ResourceID: objectId,
 			},
-		})
+		}
+		if found.Condition != nil {
+			info.Condition = policysupport.ConditionInfo{Rule: found.Condition.Expression}
+		}
+		policies = append(policies, info)
 	}
 	return policies, err
 }
 
-func (c *GoogleClient) SetBackendPolicy(name, objectId string, p policysupport.PolicyInfo) error { // todo - objectId may no longer be needed, at least for google
-	var url string
-	if strings.HasPrefix(name, "k8s") { // todo - revisit and improve the decision here
-		url = fmt.Sprintf("https://iap.googleapis.com/v1/projects/%s/iap_web/compute/services/%s:setIamPolicy", c.ProjectId, objectId)
-	} else {
-		url = fmt.Sprintf("https://iap.googleapis.com/v1/projects/%s/iap_web/appengine-%s/services/default:setIamPolicy", c.ProjectId, objectId)
+// SetBackendPolicy replaces the application's iam policy with a binding per policy action, an empty list of policies
+// removing every binding. The current policy's etag is sent back so that concurrent changes are not overwritten.
+func (c *GoogleClient) SetBackendPolicy(name, objectId string, policies []policysupport.PolicyInfo) error { // todo - objectId may no longer be needed, at least for google
+	current, err := c.getIamPolicy(name, objectId)
+	if err != nil {
+		return err
 	}
 
-	// todo - handle many actions
-	uri := strings.TrimPrefix(p.Actions[0].ActionUri, "gcp:")
+	binds := bindings{Bindings: make([]bindingInfo, 0), Etag: current.Etag}
+	for _, p := range policies {
+		for _, action := range p.Actions {
+			binding := bindingInfo{Role: strings.TrimPrefix(action.ActionUri, "gcp:"), Members: p.Subject.Members}
+			if p.HasCondition() {
+				binds.Version = conditionalPolicyVersion
+				binding.Condition = &bindingCondition{Title: "hexa condition", Expression: p.Condition.Rule}
+			}
+			binds.Bindings = append(binds.Bindings, binding)
+		}
+	}
+	b := new(bytes.Buffer)
+	_ = json.NewEncoder(b).Encode(policy{binds})
+
+	post, err := c.HttpClient.Post(c.iamPolicyUrl(name, objectId, "setIamPolicy"), "application/json", b)
+	if err != nil {
+		return err
+	}
+	if post.StatusCode < 200 || post.StatusCode > 299 {
+		return fmt.Errorf("unable to set google cloud policy, %s", post.Status)
+	}
+	return nil
+}
 
-	body := policy{bindings{[]bindingInfo{{uri, p.Subject.Members}}}}
+func (c *GoogleClient) getIamPolicy(name, objectId string) (bindings, error) {
 	b := new(bytes.Buffer)
-	_ = json.NewEncoder(b).Encode(body)
+	_ = json.NewEncoder(b).Encode(getPolicyRequest{getPolicyOptions{conditionalPolicyVersion}})
+
+	post, err := c.HttpClient.Post(c.iamPolicyUrl(name, objectId, "getIamPolicy"), "application/json", b)
+	if err != nil {
+		log.Println("Unable to find google cloud policy.")
+		return bindings{}, err
+	}
+	log.Printf("Google cloud response %s.\n", post.Status)
+	if post.StatusCode < 200 || post.StatusCode > 299 {
+		return bindings{}, fmt.Errorf("unable to get google cloud policy, %s", post.Status)
+	}
+
+	var binds bindings
+	if err = json.NewDecoder(post.Body).Decode(&binds); err != nil {
+		log.Println("Unable to decode google cloud policy.")
+		return bindings{}, err
+	}
+	return binds, nil
+}
 
-	_, err := c.HttpClient.Post(url, "application/json", b)
-	return err
+func (c *GoogleClient) iamPolicyUrl(name, objectId, method string) string {
+	if strings.HasPrefix(name, "k8s") { // todo - revisit and improve the decision here
+		return fmt.Sprintf("https://iap.googleapis.com/v1/projects/%s/iap_web/compute/services/%s:%s", c.ProjectId, objectId, method)
+	}
+	return fmt.Sprintf("https://iap.googleapis.com/v1/projects/%s/iap_web/appengine-%s/services/default:%s", c.ProjectId, objectId, method)
 }
@@ -98,6 +98,9 @@ func TestGoogleClient_GetBackendPolicies(t *testing.T) {
 	}
 	assert.Equal(t, 2, len(infos))
 	assert.Equal(t, expectedUsers, infos[0].Subject.Members)
+	assert.False(t, infos[0].HasCondition())
+	assert.Equal(t, "request.time < timestamp('2020-10-01T00:00:00.000Z')", infos[1].Condition.Rule)
+	assert.Equal(t, "{\"options\":{\"requestedPolicyVersion\":3}}\n", string(m.RequestBody))
 	assert.Equal(t, "https://iap.googleapis.com/v1/projects/k8sproject/iap_web/compute/services/k8sObjectId:getIamPolicy", m.Url)
 }
 
@@ -120,41 +123,95 @@ func TestGoogleClient_GetBackendPolicies_withBadJson(t *testing.T) {
 
 func TestGoogleClient_SetAppEnginePolicies(t *testing.T) {
 	policy := policysupport.PolicyInfo{
-		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{"roles/iap.httpsResourceAccessor"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
+		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "roles/iap.httpsResourceAccessor"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
 			ResourceID: "anObjectId",
 		},
 	}
 	m := google_cloud_test.NewMockClient()
+	m.ResponseBody["appengine"] = google_cloud_test.Resource("policy.json")
 	client := googlecloud.GoogleClient{HttpClient: m, ProjectId: "appengineproject"}
-	err := client.SetBackendPolicy("appEngineName", "anObjectId", policy)
+	err := client.SetBackendPolicy("appEngineName", "anObjectId", []policysupport.PolicyInfo{policy})
 	assert.NoError(t, err)
-	assert.Equal(t, "{\"policy\":{\"bindings\":[{\"role\":\"roles/iap.httpsResourceAccessor\",\"members\":[\"aUser\"]}]}}\n", string(m.RequestBody))
+	assert.Equal(t, "{\"policy\":{\"bindings\":[{\"role\":\"roles/iap.httpsResourceAccessor\",\"members\":[\"aUser\"]}],\"etag\":\"BwWWja0YfJA=\"}}\n", string(m.RequestBody))
 	assert.Equal(t, "https://iap.googleapis.com/v1/projects/appengineproject/iap_web/appengine-anObjectId/services/default:setIamPolicy", m.Url)
 }
 
 func TestGoogleClient_SetBackendPolicies(t *testing.T) {
 	policy := policysupport.PolicyInfo{
-		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{"gcp:roles/iap.httpsResourceAccessor"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
+		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "gcp:roles/iap.httpsResourceAccessor"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
 			ResourceID: "anObjectId",
 		},
 	}
 	m := google_cloud_test.NewMockClient()
+	m.ResponseBody["compute"] = google_cloud_test.Resource("policy.json")
 	client := googlecloud.GoogleClient{HttpClient: m, ProjectId: "k8sproject"}
-	err := client.SetBackendPolicy("k8sName", "anObjectId", policy)
+	err := client.SetBackendPolicy("k8sName", "anObjectId", []policysupport.PolicyInfo{policy})
 	assert.NoError(t, err)
-	assert.Equal(t, "{\"policy\":{\"bindings\":[{\"role\":\"roles/iap.httpsResourceAccessor\",\"members\":[\"aUser\"]}]}}\n", string(m.RequestBody))
+	assert.Equal(t, "{\"policy\":{\"bindings\":[{\"role\":\"roles/iap.httpsResourceAccessor\",\"members\":[\"aUser\"]}],\"etag\":\"BwWWja0YfJA=\"}}\n", string(m.RequestBody))
 	assert.Equal(t, "https://iap.googleapis.com/v1/projects/k8sproject/iap_web/compute/services/anObjectId:setIamPolicy", m.Url)
 }
 
+func TestGoogleClient_SetBackendPolicies_withMultiplePolicies(t *testing.T) {
+	policies := []policysupport.PolicyInfo{
+		{Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "gcp:roles/iap.httpsResourceAccessor"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{ResourceID: "anObjectId"}},
+		{Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "gcp:roles/iap.settingsAdmin"}}, Subject: policysupport.SubjectInfo{Members: []string{"anAdmin"}}, Object: policysupport.ObjectInfo{ResourceID: "anObjectId"}},
+	}
+	m := google_cloud_test.NewMockClient()
+	m.ResponseBody["compute"] = google_cloud_test.Resource("roles.json")
+	client := googlecloud.GoogleClient{HttpClient: m, ProjectId: "k8sproject"}
+	err := client.SetBackendPolicy("k8sName", "anObjectId", policies)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"policy\":{\"bindings\":[{\"role\":\"roles/iap.httpsResourceAccessor\",\"members\":[\"aUser\"]},{\"role\":\"roles/iap.settingsAdmin\",\"members\":[\"anAdmin\"]}],\"etag\":\"BwXhqDppIeQ=\"}}\n", string(m.RequestBody))
+}
+
+func TestGoogleClient_SetBackendPolicies_withNoPolicies(t *testing.T) {
+	m := google_cloud_test.NewMockClient()
+	m.ResponseBody["compute"] = google_cloud_test.Resource("roles.json")
+	client := googlecloud.GoogleClient{HttpClient: m, ProjectId: "k8sproject"}
+	err := client.SetBackendPolicy("k8sName", "anObjectId", []policysupport.PolicyInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"policy\":{\"bindings\":[],\"etag\":\"BwXhqDppIeQ=\"}}\n", string(m.RequestBody))
+	assert.Equal(t, "https://iap.googleapis.com/v1/projects/k8sproject/iap_web/compute/services/anObjectId:setIamPolicy", m.Url)
+}
+
+func TestGoogleClient_SetBackendPolicies_withCondition(t *testing.T) {
+	policy := policysupport.PolicyInfo{
+		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "gcp:roles/iap.httpsResourceAccessor"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
+			ResourceID: "anObjectId",
+		},
+		Condition: policysupport.ConditionInfo{Rule: "request.path.startsWith('/sales')"},
+	}
+	m := google_cloud_test.NewMockClient()
+	m.ResponseBody["compute"] = google_cloud_test.Resource("policy.json")
+	client := googlecloud.GoogleClient{HttpClient: m, ProjectId: "k8sproject"}
+	err := client.SetBackendPolicy("k8sName", "anObjectId", []policysupport.PolicyInfo{policy})
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"policy\":{\"version\":3,\"bindings\":[{\"role\":\"roles/iap.httpsResourceAccessor\",\"members\":[\"aUser\"],\"condition\":{\"title\":\"hexa condition\",\"expression\":\"request.path.startsWith('/sales')\"}}],\"etag\":\"BwWWja0YfJA=\"}}\n", string(m.RequestBody))
+}
+
 func TestGoogleClient_SetBackendPolicies_withRequestError(t *testing.T) {
 	policy := policysupport.PolicyInfo{
-		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{"gcp:roles/iap.httpsResourceAccessor"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
+		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "gcp:roles/iap.httpsResourceAccessor"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
 			ResourceID: "anObjectId",
 		},
 	}
 	m := google_cloud_test.NewMockClient()
 	m.Err = errors.New("oops")
 	client := googlecloud.GoogleClient{HttpClient: m}
-	err := client.SetBackendPolicy("k8sName", "anObjectId", policy)
+	err := client.SetBackendPolicy("k8sName", "anObjectId", []policysupport.PolicyInfo{policy})
+	assert.Error(t, err)
+}
+
+func TestGoogleClient_SetBackendPolicies_withErrorStatus(t *testing.T) {
+	policy := policysupport.PolicyInfo{
+		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "gcp:roles/iap.httpsResourceAccessor"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
+			ResourceID: "anObjectId",
+		},
+	}
+	m := google_cloud_test.NewMockClient()
+	m.ResponseBody["compute"] = google_cloud_test.Resource("policy.json")
+	m.Status = 403
+	client := googlecloud.GoogleClient{HttpClient: m}
+	err := client.SetBackendPolicy("k8sName", "anObjectId", []policysupport.PolicyInfo{policy})
 	assert.Error(t, err)
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -49,6 +50,20 @@ func (g *GoogleProvider) DiscoverApplications(info orchestrator.IntegrationInfo)
 	return apps, err
 }
 
+// CheckCredentials reads the key's project, which fails for unknown projects or service accounts without access.
+func (g *GoogleProvider) CheckCredentials(info orchestrator.IntegrationInfo) error {
+	foundCredentials := g.credentials(info.Key)
+	if foundCredentials.ProjectId == "" {
+		return errors.New("unable to read google cloud key, missing project_id")
+	}
+	client, createClientErr := g.getHttpClient(info.Key)
+	if createClientErr != nil {
+		return fmt.Errorf("unable to create google http client, %w", createClientErr)
+	}
+	googleClient := GoogleClient{client, foundCredentials.ProjectId}
+	return googleClient.GetProject()
+}
+
 func (g *GoogleProvider) GetPolicyInfo(integration orchestrator.IntegrationInfo, app orchestrator.ApplicationInfo) (infos []policysupport.PolicyInfo, err error) {
 	key := integration.Key
 	foundCredentials := g.credentials(key)
@@ -71,6 +86,9 @@ func (g *GoogleProvider) SetPolicyInfo(integration orchestrator.IntegrationInfo,
 	if errPolicies != nil {
 		return 500, errPolicies
 	}
+	if errDeny := policysupport.RejectDenyEffects(g.Name(), policyInfos); errDeny != nil {
+		return 400, errDeny
+	}
 
 	key := integration.Key
 	foundCredentials := g.credentials(key)
@@ -80,15 +98,63 @@ func (g *GoogleProvider) SetPolicyInfo(integration orchestrator.IntegrationInfo,
 		return 500, createClientErr
 	}
 	googleClient := GoogleClient{client, foundCredentials.ProjectId}
-	for _, policyInfo := range policyInfos {
-		err := googleClient.SetBackendPolicy(app.Name, app.ObjectID, policyInfo)
-		if err != nil {
-			return 500, err
-		}
+	if err := googleClient.SetBackendPolicy(app.Name, app.ObjectID, policyInfos); err != nil {
+		return 500, err
 	}
 	return 201, nil
 }
 
+const accessorRole = "gcp:roles/iap.httpsResourceAccessor"
+
+func (g *GoogleProvider) ToCanonical(policyInfos []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
+	members, err := policysupport.TranslateMembers(policyInfos, func(member string) (string, error) {
+		switch member {
+		case "allUsers":
+			return policysupport.CanonicalAllUsers, nil
+		case "allAuthenticatedUsers":
+			return policysupport.CanonicalAllAuthenticated, nil
+		}
+		return member, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policysupport.TranslateActions(members, func(actionUri string) (string, error) {
+		if actionUri == accessorRole {
+			return policysupport.CanonicalAccessAction, nil
+		}
+		return actionUri, nil
+	})
+}
+
+func (g *GoogleProvider) FromCanonical(policyInfos []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
+	members, err := policysupport.TranslateMembers(policyInfos, func(member string) (string, error) {
+		switch {
+		case member == policysupport.CanonicalAllUsers:
+			return "allUsers", nil
+		case member == policysupport.CanonicalAllAuthenticated:
+			return "allAuthenticatedUsers", nil
+		case strings.HasPrefix(member, policysupport.CanonicalIdentityPrefix):
+			return "", policysupport.UntranslatableMember(g.Name(), member)
+		case strings.Contains(member, ":"):
+			return member, nil // user:, group:, serviceAccount: and domain: members are shared with google
+		}
+		return "", policysupport.UntranslatableMember(g.Name(), member)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policysupport.TranslateActions(members, func(actionUri string) (string, error) {
+		switch {
+		case actionUri == policysupport.CanonicalAccessAction:
+			return accessorRole, nil
+		case strings.HasPrefix(actionUri, "gcp:"):
+			return actionUri, nil
+		}
+		return "", policysupport.UntranslatableAction(g.Name(), actionUri)
+	})
+}
+
 func (g *GoogleProvider) NewHttpClient(key []byte) (HTTPClient, error) {
 	var opts []option.ClientOption
 	opt := option.WithCredentialsJSON(key)
@@ -1,6 +1,7 @@
 package googlecloud_test
 
 import (
+	"errors"
 	"io/ioutil"
 	"path/filepath"
 	"runtime"
@@ -98,11 +99,12 @@ func TestGoogleProvider_GetPolicy(t *testing.T) {
 
 func TestGoogleProvider_SetPolicy(t *testing.T) {
 	policy := policysupport.PolicyInfo{
-		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{"anAction"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
+		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "anAction"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
 			ResourceID: "anObjectId",
 		},
 	}
 	m := google_cloud_test.NewMockClient()
+	m.ResponseBody["appengine"] = google_cloud_test.Resource("policy.json")
 
 	p := googlecloud.GoogleProvider{HttpClientOverride: m}
 	info := orchestrator.IntegrationInfo{Name: "not google_cloud", Key: []byte("aKey")}
@@ -111,9 +113,49 @@ func TestGoogleProvider_SetPolicy(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGoogleProvider_SetPolicy_keepsOtherRoles(t *testing.T) {
+	m := google_cloud_test.NewMockClient()
+	m.ResponseBody["compute"] = google_cloud_test.Resource("roles.json")
+
+	p := googlecloud.GoogleProvider{HttpClientOverride: m}
+	info := orchestrator.IntegrationInfo{Name: "not google_cloud", Key: []byte("aKey")}
+	app := orchestrator.ApplicationInfo{ObjectID: "k8sObjectId", Name: "k8sName"}
+	infos, err := p.GetPolicyInfo(info, app)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(infos))
+
+	infos[1].Subject.Members = []string{"user:mallory@example.com"}
+	status, err := p.SetPolicyInfo(info, app, infos)
+	assert.Equal(t, 201, status)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://iap.googleapis.com/v1/projects//iap_web/compute/services/k8sObjectId:setIamPolicy", m.Url)
+	assert.Equal(t, "{\"policy\":{\"bindings\":["+
+		"{\"role\":\"roles/iap.httpsResourceAccessor\",\"members\":[\"user:phil@example.com\"]},"+
+		"{\"role\":\"roles/iap.tunnelResourceAccessor\",\"members\":[\"user:mallory@example.com\"]},"+
+		"{\"role\":\"roles/iap.settingsAdmin\",\"members\":[\"group:admins@example.com\"]}"+
+		"],\"etag\":\"BwXhqDppIeQ=\"}}\n", string(m.RequestBody))
+}
+
+func TestGoogleProvider_SetPolicy_withErrorStatus(t *testing.T) {
+	policy := policysupport.PolicyInfo{
+		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "anAction"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
+			ResourceID: "anObjectId",
+		},
+	}
+	m := google_cloud_test.NewMockClient()
+	m.ResponseBody["appengine"] = google_cloud_test.Resource("policy.json")
+	m.Status = 403
+
+	p := googlecloud.GoogleProvider{HttpClientOverride: m}
+	info := orchestrator.IntegrationInfo{Name: "not google_cloud", Key: []byte("aKey")}
+	status, err := p.SetPolicyInfo(info, orchestrator.ApplicationInfo{ObjectID: "anObjectId"}, []policysupport.PolicyInfo{policy})
+	assert.Equal(t, 500, status)
+	assert.Error(t, err)
+}
+
 func TestGoogleProvider_SetPolicy_withInvalidArguments(t *testing.T) {
 	missingMeta := policysupport.PolicyInfo{
-		Actions: []policysupport.ActionInfo{{"anAction"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
+		Actions: []policysupport.ActionInfo{{ActionUri: "anAction"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
 			ResourceID: "anObjectId",
 		},
 	}
@@ -130,3 +172,59 @@ func TestGoogleProvider_SetPolicy_withInvalidArguments(t *testing.T) {
 	assert.Equal(t, 500, status)
 	assert.Error(t, err)
 }
+
+func TestGoogleProvider_SetPolicy_withDenyEffect(t *testing.T) {
+	policy := policysupport.PolicyInfo{
+		Meta: policysupport.MetaInfo{Version: "aVersion"}, Actions: []policysupport.ActionInfo{{ActionUri: "anAction"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{
+			ResourceID: "anObjectId",
+		},
+		Effect: policysupport.EffectDeny,
+	}
+	m := google_cloud_test.NewMockClient()
+
+	p := googlecloud.GoogleProvider{HttpClientOverride: m}
+	info := orchestrator.IntegrationInfo{Name: "not google_cloud", Key: []byte("aKey")}
+	status, err := p.SetPolicyInfo(info, orchestrator.ApplicationInfo{ObjectID: "anObjectId"}, []policysupport.PolicyInfo{policy})
+	assert.Equal(t, 400, status)
+	assert.EqualError(t, err, "google_cloud does not support deny effects, unable to apply deny policy")
+	assert.Nil(t, m.RequestBody)
+}
+
+func TestGoogleProvider_Translation(t *testing.T) {
+	p := googlecloud.GoogleProvider{}
+	infos := []policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "gcp:roles/iap.httpsResourceAccessor"}}, Subject: policysupport.SubjectInfo{Members: []string{"user:aUser@example.com", "group:aGroup@example.com", "allAuthenticatedUsers"}}, Object: policysupport.ObjectInfo{ResourceID: "anId"}}}
+
+	canonical, err := p.ToCanonical(infos)
+	assert.NoError(t, err)
+	assert.Equal(t, policysupport.CanonicalAccessAction, canonical[0].Actions[0].ActionUri)
+	assert.Equal(t, []string{"user:aUser@example.com", "group:aGroup@example.com", "allauthenticated"}, canonical[0].Subject.Members)
+
+	google, err := p.FromCanonical(canonical)
+	assert.NoError(t, err)
+	assert.Equal(t, infos, google)
+
+	_, err = p.FromCanonical([]policysupport.PolicyInfo{{Actions: []policysupport.ActionInfo{{ActionUri: "http:GET:/"}}}})
+	assert.EqualError(t, err, "google_cloud is unable to represent action \"http:GET:/\"")
+
+	_, err = p.FromCanonical([]policysupport.PolicyInfo{{Subject: policysupport.SubjectInfo{Members: []string{"id:anId:aName"}}}})
+	assert.EqualError(t, err, "google_cloud is unable to represent member \"id:anId:aName\"")
+}
+
+func TestGoogleProvider_CheckCredentials(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	key, _ := ioutil.ReadFile(filepath.Join(file, "./../test/project.json"))
+
+	m := google_cloud_test.NewMockClient()
+	p := &googlecloud.GoogleProvider{HttpClientOverride: m}
+	assert.NoError(t, p.CheckCredentials(orchestrator.IntegrationInfo{Name: "google_cloud", Key: key}))
+	assert.Equal(t, "https://compute.googleapis.com/compute/v1/projects/google-cloud-project-id", m.Url)
+
+	m.Err = errors.New("oops")
+	assert.Error(t, p.CheckCredentials(orchestrator.IntegrationInfo{Name: "google_cloud", Key: key}))
+}
+
+func TestGoogleProvider_CheckCredentials_withMissingProject(t *testing.T) {
+	p := &googlecloud.GoogleProvider{HttpClientOverride: google_cloud_test.NewMockClient()}
+	err := p.CheckCredentials(orchestrator.IntegrationInfo{Name: "google_cloud", Key: []byte("{}")})
+	assert.Equal(t, "unable to read google cloud key, missing project_id", err.Error())
+}
@@ -16,6 +16,7 @@ type MockClient struct {
 	ResponseBody map[string][]byte
 	RequestBody  []byte
 	Url          string
+	Status       int
 }
 
 func NewMockClient() *MockClient {
@@ -48,5 +49,9 @@ func (m *MockClient) Post(url, _ string, body io.Reader) (resp *http.Response, e
 	} else {
 		responseBody = m.ResponseBody["appengine"]
 	}
-	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(responseBody))}, m.Err
+	status := http.StatusOK
+	if m.Status != 0 {
+		status = m.Status
+	}
+	return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: ioutil.NopCloser(bytes.NewReader(responseBody))}, m.Err
 }
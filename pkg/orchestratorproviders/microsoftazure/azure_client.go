@@ -29,7 +29,8 @@ type AzureKey struct {
 }
 
 type AzureAccessToken struct {
-	Token string `json:"access_token"`
+	Token            string `json:"access_token"`
+	ErrorDescription string `json:"error_description"`
 }
 
 type azureWebApps struct {
@@ -1,6 +1,7 @@
 package microsoftazure
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -31,6 +32,27 @@ func (a *AzureProvider) DiscoverApplications(info orchestrator.IntegrationInfo)
 	return apps, err
 }
 
+// CheckCredentials requests an access token for the key's app registration, which fails for unknown tenants or secrets.
+func (a *AzureProvider) CheckCredentials(info orchestrator.IntegrationInfo) error {
+	azureClient := AzureClient{a.getHttpClient()}
+	decoded, err := azureClient.DecodeKey(info.Key)
+	if err != nil {
+		return fmt.Errorf("unable to read azure key, %w", err)
+	}
+	if decoded.AppId == "" || decoded.Secret == "" || decoded.Tenant == "" {
+		return errors.New("unable to read azure key, an appId, secret and tenant are required")
+	}
+	accessToken, err := azureClient.AccessTokenRequest(decoded)
+	if err != nil {
+		return fmt.Errorf("unable to authenticate with azure tenant %s, %w", decoded.Tenant, err)
+	}
+	if accessToken.Token == "" {
+		return fmt.Errorf("unable to authenticate with azure tenant %s, %s", decoded.Tenant, accessToken.ErrorDescription)
+	}
+	return nil
+}
+
+// GetPolicyInfo returns a policy per app role assignment, identified by the assignment's id.
 func (a *AzureProvider) GetPolicyInfo(integrationInfo orchestrator.IntegrationInfo, applicationInfo orchestrator.ApplicationInfo) ([]policysupport.PolicyInfo, error) {
 	key := integrationInfo.Key
 	var policies []policysupport.PolicyInfo
@@ -39,22 +61,18 @@ func (a *AzureProvider) GetPolicyInfo(integrationInfo orchestrator.IntegrationIn
 	principal, _ := azureClient.GetServicePrincipals(key, applicationInfo.Description) // todo - description is named poorly
 	assignments, _ := azureClient.GetAppRoleAssignedTo(key, principal.List[0].ID)
 
-	var appRoleId string
-	var principalIdsAndDisplayNames []string
 	for _, assignment := range assignments.List {
-		appRoleId = fmt.Sprintf("azure:%s", assignment.AppRoleId)
-		principalIdsAndDisplayNames = append(principalIdsAndDisplayNames, fmt.Sprintf("%s:%s", assignment.PrincipalId, assignment.PrincipalDisplayName))
+		policies = append(policies, policysupport.PolicyInfo{
+			ID:      assignment.ID,
+			Meta:    policysupport.MetaInfo{Version: "0.5"},
+			Actions: []policysupport.ActionInfo{{fmt.Sprintf("azure:%s", assignment.AppRoleId)}},
+			Subject: policysupport.SubjectInfo{Members: []string{fmt.Sprintf("%s:%s", assignment.PrincipalId, assignment.PrincipalDisplayName)}},
+			Object: policysupport.ObjectInfo{
+				ResourceID: applicationInfo.ObjectID,
+			},
+		})
 	}
 
-	policies = append(policies, policysupport.PolicyInfo{
-		Meta:    policysupport.MetaInfo{Version: "0.5"},
-		Actions: []policysupport.ActionInfo{{appRoleId}},
-		Subject: policysupport.SubjectInfo{Members: principalIdsAndDisplayNames},
-		Object: policysupport.ObjectInfo{
-			ResourceID: applicationInfo.ObjectID,
-		},
-	})
-
 	return policies, nil
 }
 
@@ -68,13 +86,20 @@ func (a *AzureProvider) SetPolicyInfo(integrationInfo orchestrator.IntegrationIn
 	if errPolicies != nil {
 		return http.StatusInternalServerError, errPolicies
 	}
+	if errCondition := policysupport.RejectConditions(a.Name(), policyInfos); errCondition != nil {
+		return http.StatusBadRequest, errCondition
+	}
+	if errDeny := policysupport.RejectDenyEffects(a.Name(), policyInfos); errDeny != nil {
+		return http.StatusBadRequest, errDeny
+	}
 
 	key := integrationInfo.Key
 	client := a.getHttpClient()
 	azureClient := AzureClient{client}
 	principal, _ := azureClient.GetServicePrincipals(key, applicationInfo.Description) // todo - description is named poorly
+	// the assignments of all policies are applied together, as existing assignments missing from them are removed
+	var assignments []AzureAppRoleAssignment
 	for _, policyInfo := range policyInfos {
-		var assignments []AzureAppRoleAssignment
 		for _, user := range policyInfo.Subject.Members {
 			assignments = append(assignments, AzureAppRoleAssignment{
 				AppRoleId:   strings.TrimPrefix(policyInfo.Actions[0].ActionUri, "azure:"),
@@ -82,14 +107,53 @@ func (a *AzureProvider) SetPolicyInfo(integrationInfo orchestrator.IntegrationIn
 				ResourceId:  strings.Split(policyInfo.Object.ResourceID, ":")[0],
 			})
 		}
-		err := azureClient.SetAppRoleAssignedTo(key, principal.List[0].ID, assignments)
-		if err != nil {
-			return http.StatusInternalServerError, err
-		}
+	}
+	err := azureClient.SetAppRoleAssignedTo(key, principal.List[0].ID, assignments)
+	if err != nil {
+		return http.StatusInternalServerError, err
 	}
 	return http.StatusCreated, nil
 }
 
+// the default access app role is assigned when an application defines no roles of its own
+const defaultAccessRole = "azure:00000000-0000-0000-0000-000000000000"
+
+func (a *AzureProvider) ToCanonical(policyInfos []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
+	members, err := policysupport.TranslateMembers(policyInfos, func(member string) (string, error) {
+		return policysupport.CanonicalIdentityPrefix + member, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policysupport.TranslateActions(members, func(actionUri string) (string, error) {
+		if actionUri == defaultAccessRole {
+			return policysupport.CanonicalAccessAction, nil
+		}
+		return actionUri, nil
+	})
+}
+
+func (a *AzureProvider) FromCanonical(policyInfos []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
+	members, err := policysupport.TranslateMembers(policyInfos, func(member string) (string, error) {
+		if strings.HasPrefix(member, policysupport.CanonicalIdentityPrefix) {
+			return strings.TrimPrefix(member, policysupport.CanonicalIdentityPrefix), nil
+		}
+		return "", policysupport.UntranslatableMember(a.Name(), member) // todo - resolve emails to principal ids
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policysupport.TranslateActions(members, func(actionUri string) (string, error) {
+		switch {
+		case actionUri == policysupport.CanonicalAccessAction:
+			return defaultAccessRole, nil
+		case strings.HasPrefix(actionUri, "azure:"):
+			return actionUri, nil
+		}
+		return "", policysupport.UntranslatableAction(a.Name(), actionUri)
+	})
+}
+
 func (a *AzureProvider) getHttpClient() HTTPClient {
 	if a.HttpClientOverride != nil {
 		return a.HttpClientOverride
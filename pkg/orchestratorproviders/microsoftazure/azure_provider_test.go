@@ -76,11 +76,24 @@ func TestGetPolicy(t *testing.T) {
 	appInfo := orchestrator.ApplicationInfo{ObjectID: "anObjectId", Name: "anAppName", Description: "aDescription"}
 	policies, _ := p.GetPolicyInfo(info, appInfo)
 	assert.Equal(t, 1, len(policies))
+	assert.Equal(t, "anId", policies[0].ID)
 	assert.Equal(t, "azure:anAppRoleId", policies[0].Actions[0].ActionUri)
 	assert.Equal(t, "aPrincipalId:aPrincipalDisplayName", policies[0].Subject.Members[0])
 	assert.Equal(t, "anObjectId", policies[0].Object.ResourceID)
 }
 
+func TestGetPolicy_withAssignments(t *testing.T) {
+	m := new(microsoftazure_test.MockClient)
+	mockExchanges(m)
+	p := &microsoftazure.AzureProvider{HttpClientOverride: m}
+	key := []byte(`{"appId":"anAppId", "secret":"aSecret", "tenant":"aTenant", "subscription":"aSubscription"}`)
+	policies, _ := p.GetPolicyInfo(orchestrator.IntegrationInfo{Name: "azure", Key: key}, orchestrator.ApplicationInfo{ObjectID: "anObjectId", Description: "aDescription"})
+	assert.Equal(t, 3, len(policies))
+	assert.Equal(t, "anotherId", policies[1].ID)
+	assert.Equal(t, "azure:anotherAppRoleId", policies[1].Actions[0].ActionUri)
+	assert.Equal(t, []string{"anotherPrincipalId:anotherPrincipalDisplayName"}, policies[1].Subject.Members)
+}
+
 func TestSetPolicy(t *testing.T) {
 	m := new(microsoftazure_test.MockClient)
 	mockExchanges(m)
@@ -147,6 +160,45 @@ func TestSetPolicy_withInvalidArguments(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestSetPolicy_withUnsupportedPolicy(t *testing.T) {
+	m := new(microsoftazure_test.MockClient)
+	mockExchanges(m)
+	azureProvider := microsoftazure.AzureProvider{HttpClientOverride: m}
+	key := []byte(`
+{
+  "appId":"anAppId",
+  "secret":"aSecret",
+  "tenant":"aTenant",
+  "subscription":"aSubscription"
+}
+`)
+	status, err := azureProvider.SetPolicyInfo(
+		orchestrator.IntegrationInfo{Name: "azure", Key: key},
+		orchestrator.ApplicationInfo{ObjectID: "anObjectId", Name: "anAppName", Description: "aDescription"},
+		[]policysupport.PolicyInfo{{
+			Meta:      policysupport.MetaInfo{Version: "0"},
+			Actions:   []policysupport.ActionInfo{{ActionUri: "azure:anAppRoleId"}},
+			Subject:   policysupport.SubjectInfo{Members: []string{"aPrincipalId:aPrincipalDisplayName"}},
+			Object:    policysupport.ObjectInfo{ResourceID: "anObjectId"},
+			Condition: policysupport.ConditionInfo{Rule: "req.ip sw 127"},
+		}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.EqualError(t, err, "azure does not support policy conditions, unable to apply condition \"req.ip sw 127\"")
+
+	status, err = azureProvider.SetPolicyInfo(
+		orchestrator.IntegrationInfo{Name: "azure", Key: key},
+		orchestrator.ApplicationInfo{ObjectID: "anObjectId", Name: "anAppName", Description: "aDescription"},
+		[]policysupport.PolicyInfo{{
+			Meta:    policysupport.MetaInfo{Version: "0"},
+			Actions: []policysupport.ActionInfo{{ActionUri: "azure:anAppRoleId"}},
+			Subject: policysupport.SubjectInfo{Members: []string{"aPrincipalId:aPrincipalDisplayName"}},
+			Object:  policysupport.ObjectInfo{ResourceID: "anObjectId"},
+			Effect:  policysupport.EffectDeny,
+		}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.EqualError(t, err, "azure does not support deny effects, unable to apply deny policy")
+}
+
 func mockExchanges(m *microsoftazure_test.MockClient) {
 	m.Exchanges = []microsoftazure_test.MockExchange{
 		{Path: "https://login.microsoftonline.com/aTenant/oauth2/v2.0/token", ResponseBody: []byte("{\"access_token\":\"aToken\"}")},
@@ -182,3 +234,56 @@ func mockExchanges(m *microsoftazure_test.MockClient) {
 		{Path: "https://graph.microsoft.com/v1.0/servicePrincipals/aToken/appRoleAssignedTo/anotherId"},
 	}
 }
+
+func TestAzureProvider_Translation(t *testing.T) {
+	p := microsoftazure.AzureProvider{}
+	infos := []policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "azure:00000000-0000-0000-0000-000000000000"}}, Subject: policysupport.SubjectInfo{Members: []string{"aPrincipalId:aName"}}, Object: policysupport.ObjectInfo{ResourceID: "anId"}}}
+
+	canonical, err := p.ToCanonical(infos)
+	assert.NoError(t, err)
+	assert.Equal(t, policysupport.CanonicalAccessAction, canonical[0].Actions[0].ActionUri)
+	assert.Equal(t, []string{"id:aPrincipalId:aName"}, canonical[0].Subject.Members)
+
+	azure, err := p.FromCanonical(canonical)
+	assert.NoError(t, err)
+	assert.Equal(t, infos, azure)
+
+	_, err = p.FromCanonical([]policysupport.PolicyInfo{{Subject: policysupport.SubjectInfo{Members: []string{"user:aUser@example.com"}}}})
+	assert.EqualError(t, err, "azure is unable to represent member \"user:aUser@example.com\"")
+}
+
+func TestCheckCredentials(t *testing.T) {
+	m := new(microsoftazure_test.MockClient)
+	m.Exchanges = []microsoftazure_test.MockExchange{
+		{Path: "https://login.microsoftonline.com/aTenant/oauth2/v2.0/token", ResponseBody: []byte("{\"access_token\":\"aToken\"}")},
+	}
+	p := &microsoftazure.AzureProvider{HttpClientOverride: m}
+	key := []byte(`{"appId":"anAppId", "secret":"aSecret", "tenant":"aTenant"}`)
+	assert.NoError(t, p.CheckCredentials(orchestrator.IntegrationInfo{Name: "azure", Key: key}))
+}
+
+func TestCheckCredentials_withRejectedSecret(t *testing.T) {
+	m := new(microsoftazure_test.MockClient)
+	m.Exchanges = []microsoftazure_test.MockExchange{
+		{Path: "https://login.microsoftonline.com/aTenant/oauth2/v2.0/token", ResponseBody: []byte("{\"error_description\":\"Invalid client secret provided.\"}")},
+	}
+	p := &microsoftazure.AzureProvider{HttpClientOverride: m}
+	key := []byte(`{"appId":"anAppId", "secret":"aSecret", "tenant":"aTenant"}`)
+	err := p.CheckCredentials(orchestrator.IntegrationInfo{Name: "azure", Key: key})
+	assert.Equal(t, "unable to authenticate with azure tenant aTenant, Invalid client secret provided.", err.Error())
+}
+
+func TestCheckCredentials_withUnknownTenant(t *testing.T) {
+	p := &microsoftazure.AzureProvider{HttpClientOverride: new(microsoftazure_test.MockClient)}
+	key := []byte(`{"appId":"anAppId", "secret":"aSecret", "tenant":"anotherTenant"}`)
+	err := p.CheckCredentials(orchestrator.IntegrationInfo{Name: "azure", Key: key})
+	assert.Contains(t, err.Error(), "unable to authenticate with azure tenant anotherTenant")
+}
+
+func TestCheckCredentials_withBadKey(t *testing.T) {
+	p := &microsoftazure.AzureProvider{HttpClientOverride: new(microsoftazure_test.MockClient)}
+	assert.Error(t, p.CheckCredentials(orchestrator.IntegrationInfo{Name: "azure", Key: []byte("aKey")}))
+
+	err := p.CheckCredentials(orchestrator.IntegrationInfo{Name: "azure", Key: []byte(`{"appId":"anAppId"}`)})
+	assert.Equal(t, "unable to read azure key, an appId, secret and tenant are required", err.Error())
+}
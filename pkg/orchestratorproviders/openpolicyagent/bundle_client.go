@@ -2,13 +2,14 @@ package openpolicyagent
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/compressionsupport"
 )
@@ -21,33 +22,62 @@ type HTTPClient interface {
 type BundleClient struct {
 	BundleServerURL string
 	HttpClient      HTTPClient
+	Signer          *BundleSigner // when present, bundles without a valid signature are rejected
 }
 
-func (b *BundleClient) GetDataFromBundle(bundleUrl string, path string) ([]byte, error) {
+// GetBundle fetches the bundle's files, keyed by their path within the bundle, rejecting bundles whose signature does
+// not verify when there is a signer.
+func (b *BundleClient) GetBundle(bundleUrl string) (map[string][]byte, error) {
 	get, getErr := b.HttpClient.Get(bundleUrl)
 	if getErr != nil {
 		return nil, getErr
 	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(get.Body)
 
-	all, readErr := io.ReadAll(get.Body)
-	if readErr != nil {
-		return nil, readErr
+	files, tarErr := compressionsupport.UnTarGzip(get.Body)
+	if tarErr != nil {
+		return nil, tarErr
+	}
+	if b.Signer != nil {
+		if verifyErr := b.verify(files); verifyErr != nil {
+			return nil, verifyErr
+		}
 	}
+	return files, nil
+}
 
-	gz, gzipErr := compressionsupport.UnGzip(bytes.NewReader(all))
-	if gzipErr != nil {
-		return nil, gzipErr
+func (b *BundleClient) GetDataFromBundle(bundleUrl string) ([]byte, error) {
+	files, err := b.GetBundle(bundleUrl)
+	if err != nil {
+		return nil, err
+	}
+	data, found := files["bundle/data.json"]
+	if !found {
+		return nil, errors.New("unable to read bundle, missing bundle/data.json")
 	}
+	return data, nil
+}
 
-	tarErr := compressionsupport.UnTarToPath(bytes.NewReader(gz), path)
-	if tarErr != nil {
-		return nil, tarErr
+func (b *BundleClient) verify(files map[string][]byte) error {
+	content, found := files[SignaturesFile]
+	if !found {
+		return errors.New("unable to verify bundle, missing " + SignaturesFile)
+	}
+	signed := make(map[string][]byte)
+	for name, data := range files {
+		if name != SignaturesFile {
+			signed[name] = data
+		}
 	}
-	return os.ReadFile(filepath.Join(path, "/bundle/data.json"))
+	return b.Signer.Verify(signed, content)
 }
 
 // todo - ignoring errors for the moment while spiking
 
+// PostBundle uploads the bundle to its path without the .tar.gz extension, for example /bundles/bundle for
+// /bundles/bundle.tar.gz, or to /bundles for bundle urls of other forms.
 func (b *BundleClient) PostBundle(bundleUrl string, bundle []byte) (int, error) {
 	buf := new(bytes.Buffer)
 	writer := multipart.NewWriter(buf)
@@ -55,7 +85,46 @@ func (b *BundleClient) PostBundle(bundleUrl string, bundle []byte) (int, error)
 	_, _ = formFile.Write(bundle)
 	_ = writer.Close()
 	parse, _ := url.Parse(bundleUrl)
+	path := "/bundles"
+	if strings.HasSuffix(parse.Path, ".tar.gz") {
+		path = strings.TrimSuffix(parse.Path, ".tar.gz")
+	}
 	contentType := writer.FormDataContentType()
-	resp, err := b.HttpClient.Post(fmt.Sprintf("%s://%s/bundles", parse.Scheme, parse.Host), contentType, buf)
+	resp, err := b.HttpClient.Post(fmt.Sprintf("%s://%s%s", parse.Scheme, parse.Host, path), contentType, buf)
 	return resp.StatusCode, err
 }
+
+type Bundles struct {
+	Bundles []BundleInfo `json:"bundles"`
+}
+
+// BundleInfo is a bundle listed by the bundle server, its path being relative to the server.
+type BundleInfo struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Package string `json:"package"`
+}
+
+// GetBundles lists the bundles of the server hosting the bundle url.
+func (b *BundleClient) GetBundles(bundleUrl string) ([]BundleInfo, error) {
+	parse, parseErr := url.Parse(bundleUrl)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	get, getErr := b.HttpClient.Get(fmt.Sprintf("%s://%s/bundles", parse.Scheme, parse.Host))
+	if getErr != nil {
+		return nil, getErr
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(get.Body)
+	if get.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to list bundles, %s", get.Status)
+	}
+
+	var list Bundles
+	if decodeErr := json.NewDecoder(get.Body).Decode(&list); decodeErr != nil {
+		return nil, decodeErr
+	}
+	return list.Bundles, nil
+}
@@ -3,17 +3,17 @@ package openpolicyagent_test
 import (
 	"bytes"
 	"errors"
-	"fmt"
-	"math/rand"
-	"os"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"runtime"
 	"testing"
-	"time"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/compressionsupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
 	"github.com/hexa-org/policy-orchestrator/pkg/orchestratorproviders/openpolicyagent"
 	"github.com/hexa-org/policy-orchestrator/pkg/orchestratorproviders/openpolicyagent/test"
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,10 +28,7 @@ func TestBundleClient_GetExpressionFromBundle(t *testing.T) {
 
 	client := openpolicyagent.BundleClient{HttpClient: &mockClient}
 
-	dir := os.TempDir()
-	rand.Seed(time.Now().UnixNano())
-	path := filepath.Join(dir, fmt.Sprintf("test-bundles/.bundle-%d", rand.Uint64()))
-	data, err := client.GetDataFromBundle("someUrl", path)
+	data, err := client.GetDataFromBundle("someUrl")
 	assert.NoError(t, err)
 	assert.Equal(t, `{
   "policies": [
@@ -87,14 +84,13 @@ func TestBundleClient_GetExpressionFromBundle(t *testing.T) {
     }
   ]
 }`, string(data))
-	_ = os.RemoveAll(path)
 }
 
 func TestBundleClient_GetExpressionFromBundle_withBadRequest(t *testing.T) {
 	mockClient := openpolicyagent_test.MockClient{}
 	mockClient.Err = errors.New("oops")
 	client := openpolicyagent.BundleClient{HttpClient: &mockClient}
-	_, err := client.GetDataFromBundle("someUrl", os.TempDir())
+	_, err := client.GetDataFromBundle("someUrl")
 	assert.Error(t, err)
 }
 
@@ -102,19 +98,69 @@ func TestBundleClient_GetExpressionFromBundle_withBadGzip(t *testing.T) {
 	var buffer bytes.Buffer
 	mockClient := openpolicyagent_test.MockClient{Response: buffer.Bytes()}
 	client := openpolicyagent.BundleClient{HttpClient: &mockClient}
-	_, err := client.GetDataFromBundle("someUrl", "")
+	_, err := client.GetDataFromBundle("someUrl")
 	assert.Error(t, err)
 }
 
 func TestBundleClient_GetExpressionFromBundle_withBadTar(t *testing.T) {
-	_, file, _, _ := runtime.Caller(0)
-	join := filepath.Join(file, "../resources/bundles")
-	tar, _ := compressionsupport.TarFromPath(join)
 	var buffer bytes.Buffer
-	_ = compressionsupport.Gzip(&buffer, tar)
+	_ = compressionsupport.Gzip(&buffer, []byte("not a tar"))
 
 	mockClient := openpolicyagent_test.MockClient{Response: buffer.Bytes()}
 	client := openpolicyagent.BundleClient{HttpClient: &mockClient}
-	_, err := client.GetDataFromBundle("someUrl", "/badPath")
+	_, err := client.GetDataFromBundle("someUrl")
 	assert.Error(t, err)
 }
+
+func TestBundleClient_GetExpressionFromBundle_withMissingData(t *testing.T) {
+	var buffer bytes.Buffer
+	_ = compressionsupport.TarGzip(&buffer, map[string][]byte{".manifest": []byte("{}")})
+
+	mockClient := openpolicyagent_test.MockClient{Response: buffer.Bytes()}
+	client := openpolicyagent.BundleClient{HttpClient: &mockClient}
+	_, err := client.GetDataFromBundle("someUrl")
+	assert.EqualError(t, err, "unable to read bundle, missing bundle/data.json")
+}
+
+func TestBundleClient_GetExpressionFromBundle_withSigner(t *testing.T) {
+	postClient := openpolicyagent_test.MockClient{Status: http.StatusCreated}
+	p := openpolicyagent.OpaProvider{BundleClientOverride: openpolicyagent.BundleClient{HttpClient: &postClient}}
+	_, _ = p.SetPolicyInfo(
+		orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: []byte(`{"bundle_url": "aBigUrl", "signing_key": "aSecret"}`)},
+		orchestrator.ApplicationInfo{ObjectID: "aResourceId"},
+		[]policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}},
+			Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}, Object: policysupport.ObjectInfo{ResourceID: "aResourceId"}}},
+	)
+
+	mockClient := openpolicyagent_test.MockClient{Response: postClient.Request}
+
+	client := openpolicyagent.BundleClient{HttpClient: &mockClient, Signer: &openpolicyagent.BundleSigner{Key: []byte("aSecret")}}
+	data, err := client.GetDataFromBundle("someUrl")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"resource_id":"aResourceId"`)
+
+	client = openpolicyagent.BundleClient{HttpClient: &mockClient, Signer: &openpolicyagent.BundleSigner{Key: []byte("anotherSecret")}}
+	_, err = client.GetDataFromBundle("someUrl")
+	assert.EqualError(t, err, "unable to verify bundle, signature is invalid")
+}
+
+func TestBundleClient_GetExpressionFromBundle_withSignerAndUnsignedBundle(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	tar, _ := compressionsupport.TarFromPath(filepath.Join(file, "../resources/bundles"))
+	var buffer bytes.Buffer
+	_ = compressionsupport.Gzip(&buffer, tar)
+
+	mockClient := openpolicyagent_test.MockClient{Response: buffer.Bytes()}
+	client := openpolicyagent.BundleClient{HttpClient: &mockClient, Signer: &openpolicyagent.BundleSigner{Key: []byte("aSecret")}}
+	_, err := client.GetDataFromBundle("someUrl")
+	assert.EqualError(t, err, "unable to verify bundle, missing .signatures.json")
+}
+
+func TestBundleClient_GetBundles_withoutListing(t *testing.T) {
+	bundleServer := httptest.NewServer(http.NotFoundHandler())
+	defer bundleServer.Close()
+
+	client := openpolicyagent.BundleClient{HttpClient: &http.Client{}}
+	_, err := client.GetBundles(bundleServer.URL + "/bundles/bundle.tar.gz")
+	assert.EqualError(t, err, "unable to list bundles, 404 Not Found")
+}
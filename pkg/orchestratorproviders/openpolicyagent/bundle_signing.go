@@ -0,0 +1,152 @@
+package openpolicyagent
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// OPA only reads the manifest and signatures at the root of a bundle, the policies' data and rego live under bundle/.
+const (
+	ManifestFile   = ".manifest"
+	SignaturesFile = ".signatures.json"
+)
+
+// BundleSigner signs and verifies bundles in OPA's .signatures.json format, a JWT listing the sha-256 hash of each
+// bundle file. A PEM encoded rsa private key signs with RS256, a PEM encoded rsa public key only verifies, and any
+// other key is used as an HS256 secret.
+type BundleSigner struct {
+	Key   []byte
+	KeyID string
+}
+
+type signatures struct {
+	Signatures []string `json:"signatures"`
+}
+
+type signedFile struct {
+	Name      string `json:"name"`
+	Hash      string `json:"hash"`
+	Algorithm string `json:"algorithm"`
+}
+
+type signedFiles struct {
+	Files []signedFile `json:"files"`
+	KeyID string       `json:"keyid,omitempty"`
+}
+
+func (c signedFiles) Valid() error {
+	return nil
+}
+
+// Sign returns the .signatures.json content for the bundle files, keyed by their path within the bundle.
+func (s BundleSigner) Sign(files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0)
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	claims := signedFiles{Files: make([]signedFile, 0), KeyID: s.KeyID}
+	for _, name := range names {
+		hash, err := fileHash(name, files[name])
+		if err != nil {
+			return nil, err
+		}
+		claims.Files = append(claims.Files, signedFile{Name: name, Hash: hash, Algorithm: "SHA-256"})
+	}
+
+	method, key, err := s.signingKey()
+	if err != nil {
+		return nil, err
+	}
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign bundle, %w", err)
+	}
+	return json.Marshal(signatures{[]string{token}})
+}
+
+// Verify rejects the bundle files unless the signature is valid and lists each of them, and only them, by hash.
+func (s BundleSigner) Verify(files map[string][]byte, content []byte) error {
+	var found signatures
+	if err := json.Unmarshal(content, &found); err != nil || len(found.Signatures) != 1 {
+		return errors.New("unable to verify bundle, expected a single signature")
+	}
+
+	var claims signedFiles
+	if _, err := jwt.ParseWithClaims(found.Signatures[0], &claims, s.verificationKey); err != nil {
+		return fmt.Errorf("unable to verify bundle, %w", err)
+	}
+
+	signed := make(map[string]string)
+	for _, file := range claims.Files {
+		signed[file.Name] = file.Hash
+	}
+	if len(signed) != len(files) {
+		return errors.New("unable to verify bundle, signed files do not match the bundle")
+	}
+	for name, data := range files {
+		hash, err := fileHash(name, data)
+		if err != nil {
+			return err
+		}
+		if signed[name] != hash {
+			return fmt.Errorf("unable to verify bundle, %s does not match its signature", name)
+		}
+	}
+	return nil
+}
+
+func (s BundleSigner) signingKey() (jwt.SigningMethod, interface{}, error) {
+	if !bytes.Contains(s.Key, []byte("-----BEGIN")) {
+		return jwt.SigningMethodHS256, s.Key, nil
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(s.Key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to sign bundle, %w", err)
+	}
+	return jwt.SigningMethodRS256, key, nil
+}
+
+func (s BundleSigner) verificationKey(token *jwt.Token) (interface{}, error) {
+	if !bytes.Contains(s.Key, []byte("-----BEGIN")) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return s.Key, nil
+	}
+	if token.Method != jwt.SigningMethodRS256 {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	if private, err := jwt.ParseRSAPrivateKeyFromPEM(s.Key); err == nil {
+		return &private.PublicKey, nil
+	}
+	return jwt.ParseRSAPublicKeyFromPEM(s.Key)
+}
+
+// fileHash hashes json files and the manifest in their canonical form, with sorted keys and no whitespace, as OPA does.
+func fileHash(name string, data []byte) (string, error) {
+	if strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".manifest") {
+		var value interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return "", fmt.Errorf("unable to hash %s, %w", name, err)
+		}
+		var canonical bytes.Buffer
+		encoder := json.NewEncoder(&canonical)
+		encoder.SetEscapeHTML(false)
+		_ = encoder.Encode(value)
+		data = bytes.TrimSuffix(canonical.Bytes(), []byte("\n"))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
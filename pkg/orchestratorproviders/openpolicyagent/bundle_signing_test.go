@@ -0,0 +1,77 @@
+package openpolicyagent_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestratorproviders/openpolicyagent"
+	"github.com/stretchr/testify/assert"
+)
+
+func bundleFiles() map[string][]byte {
+	return map[string][]byte{
+		".manifest":          []byte("{\"revision\":\"\",\"roots\":[\"\"]}\n"),
+		"bundle/data.json":   []byte(`{"policies": [{"id": "aPolicy"}]}`),
+		"bundle/policy.rego": []byte("package authz\n"),
+	}
+}
+
+func TestBundleSigner_withSecret(t *testing.T) {
+	signer := openpolicyagent.BundleSigner{Key: []byte("aSecret"), KeyID: "aKeyId"}
+	signed, err := signer.Sign(bundleFiles())
+	assert.NoError(t, err)
+
+	var signatures struct {
+		Signatures []string `json:"signatures"`
+	}
+	_ = json.Unmarshal(signed, &signatures)
+	assert.Equal(t, 1, len(signatures.Signatures))
+	assert.Equal(t, 3, strings.Count(signatures.Signatures[0], ".")+1)
+
+	assert.NoError(t, signer.Verify(bundleFiles(), signed))
+
+	reformatted := bundleFiles()
+	reformatted["bundle/data.json"] = []byte(`{"policies":[{"id":"aPolicy"}]}`)
+	assert.NoError(t, signer.Verify(reformatted, signed))
+
+	assert.EqualError(t, openpolicyagent.BundleSigner{Key: []byte("anotherSecret")}.Verify(bundleFiles(), signed),
+		"unable to verify bundle, signature is invalid")
+}
+
+func TestBundleSigner_withRSAKey(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	private := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	publicBytes, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	public := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes})
+
+	signed, err := openpolicyagent.BundleSigner{Key: private}.Sign(bundleFiles())
+	assert.NoError(t, err)
+	assert.NoError(t, openpolicyagent.BundleSigner{Key: private}.Verify(bundleFiles(), signed))
+	assert.NoError(t, openpolicyagent.BundleSigner{Key: public}.Verify(bundleFiles(), signed))
+
+	_, err = openpolicyagent.BundleSigner{Key: public}.Sign(bundleFiles())
+	assert.Error(t, err)
+
+	assert.EqualError(t, openpolicyagent.BundleSigner{Key: []byte("aSecret")}.Verify(bundleFiles(), signed),
+		"unable to verify bundle, unexpected signing method RS256")
+}
+
+func TestBundleSigner_withTamperedFiles(t *testing.T) {
+	signer := openpolicyagent.BundleSigner{Key: []byte("aSecret")}
+	signed, _ := signer.Sign(bundleFiles())
+
+	tampered := bundleFiles()
+	tampered["bundle/policy.rego"] = []byte("package authz\n\nallow { true }\n")
+	assert.EqualError(t, signer.Verify(tampered, signed), "unable to verify bundle, bundle/policy.rego does not match its signature")
+
+	added := bundleFiles()
+	added["bundle/other.rego"] = []byte("package other\n")
+	assert.EqualError(t, signer.Verify(added, signed), "unable to verify bundle, signed files do not match the bundle")
+
+	assert.EqualError(t, signer.Verify(bundleFiles(), []byte(`{"signatures":[]}`)), "unable to verify bundle, expected a single signature")
+}
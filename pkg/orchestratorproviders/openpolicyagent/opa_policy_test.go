@@ -66,6 +66,14 @@ func TestPolicy(t *testing.T) {
 	shouldNotAllow(t, provider, "http:GET:/humanresources", "any@google.com")
 	shouldNotAllow(t, provider, "http:GET:/humanresources", "sales@hexaindustries.io")
 	shouldAllow(t, provider, "http:GET:/humanresources", "humanresources@hexaindustries.io")
+
+	anyMethod := []byte(`{"policies":[{"actions":[{"action_uri":"http:*"}],"subject":{"members":["allusers"]},"object":{"resource_id":"anId"}}]}`)
+	anyMethodReq, _ := http.NewRequest(http.MethodPut, "http://localhost:8887/v1/data/bundle", bytes.NewBuffer(anyMethod))
+	anyMethodDo, _ := (&http.Client{}).Do(anyMethodReq)
+	assert.Equal(t, http.StatusNoContent, anyMethodDo.StatusCode)
+
+	shouldAllow(t, provider, "http:GET:/accounting", "")
+	shouldAllow(t, provider, "http:POST:/humanresources", "any@google.com")
 }
 
 func shouldAllow(t *testing.T, provider decisionsupportproviders.OpaDecisionProvider, action string, principal string) {
@@ -2,16 +2,18 @@ package openpolicyagent
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
-	"math/rand"
 	"net/http"
-	"os"
+	"net/url"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -32,27 +34,69 @@ func (o *OpaProvider) Name() string {
 	return "open_policy_agent"
 }
 
+// DiscoverApplications returns an application per bundle listed by the bundle server, or the key's bundle alone for
+// servers without a listing. Applications are identified by their bundle url, with the bundle's rego package as its
+// fragment unless it is the default authz package.
 func (o *OpaProvider) DiscoverApplications(info orchestrator.IntegrationInfo) (apps []orchestrator.ApplicationInfo, err error) {
 	c := o.credentials(info.Key)
-	if strings.EqualFold(info.Name, o.Name()) {
+	if !strings.EqualFold(info.Name, o.Name()) {
+		return apps, err
+	}
+	client := o.ensureClientIsAvailable(info.Key)
+	bundles, listErr := client.GetBundles(c.BundleUrl)
+	server, parseErr := url.Parse(c.BundleUrl)
+	if listErr != nil || parseErr != nil || len(bundles) == 0 {
 		apps = append(apps, orchestrator.ApplicationInfo{
 			ObjectID:    base64.StdEncoding.EncodeToString([]byte(c.BundleUrl)), // todo - intended to represent a resource identifier
 			Name:        "package authz",
 			Description: "Open policy agent bundle",
 		})
+		return apps, err
+	}
+
+	for _, bundle := range bundles {
+		bundleUrl := fmt.Sprintf("%s://%s%s", server.Scheme, server.Host, bundle.Path)
+		if bundle.Package != "" && bundle.Package != defaultPackage {
+			bundleUrl += "#" + bundle.Package
+		}
+		apps = append(apps, orchestrator.ApplicationInfo{
+			ObjectID:    base64.StdEncoding.EncodeToString([]byte(bundleUrl)),
+			Name:        fmt.Sprintf("%s package %s", bundle.Name, bundle.Package),
+			Description: "Open policy agent bundle " + bundle.Path,
+		})
 	}
 	return apps, err
 }
 
+// CheckCredentials fetches the key's bundle, which fails for unreachable bundle servers or unknown bundles.
+func (o *OpaProvider) CheckCredentials(info orchestrator.IntegrationInfo) error {
+	c := o.credentials(info.Key)
+	if c.BundleUrl == "" {
+		return errors.New("unable to read open policy agent key, missing bundle_url")
+	}
+	client := o.ensureClientIsAvailable(info.Key)
+	get, err := client.HttpClient.Get(c.BundleUrl)
+	if err != nil {
+		return fmt.Errorf("unable to reach bundle server, %w", err)
+	}
+	if get.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to fetch bundle %s, %s", c.BundleUrl, get.Status)
+	}
+	return nil
+}
+
 type Policies struct {
 	Policies []Policy `json:"policies"`
 }
 
 type Policy struct {
-	Meta    Meta     `json:"meta"`
-	Actions []Action `json:"actions"`
-	Subject Subject  `json:"subject"`
-	Object  Object   `json:"object"`
+	ID        string     `json:"id,omitempty"`
+	Meta      Meta       `json:"meta"`
+	Actions   []Action   `json:"actions"`
+	Subject   Subject    `json:"subject"`
+	Object    Object     `json:"object"`
+	Effect    string     `json:"effect,omitempty"`
+	Condition *Condition `json:"condition,omitempty"`
 }
 
 type Meta struct {
@@ -71,16 +115,29 @@ type Object struct {
 	ResourceID string `json:"resource_id"`
 }
 
+type Condition struct {
+	Rule string `json:"rule"`
+}
+
+// Manifest identifies the bundle's revision, the roots cover the rego package and the data under bundle. As every
+// bundle claims the bundle root, an open policy agent loads a single bundle and each bundle needs its own agent.
+type Manifest struct {
+	Revision string   `json:"revision"`
+	Roots    []string `json:"roots"`
+}
+
 func (o *OpaProvider) GetPolicyInfo(integration orchestrator.IntegrationInfo, appInfo orchestrator.ApplicationInfo) ([]policysupport.PolicyInfo, error) {
 	key := integration.Key
 	client := o.ensureClientIsAvailable(key)
-	rand.Seed(time.Now().UnixNano())
-	path := filepath.Join(os.TempDir(), fmt.Sprintf("/test-bundle-%d", rand.Uint64()))
-	data, err := client.GetDataFromBundle(client.BundleServerURL, path)
+	files, err := client.GetBundle(o.bundleUrl(client, appInfo))
 	if err != nil {
 		log.Printf("open-policy-agent, unable to read expression file. %s\n", err)
 		return nil, err
 	}
+	data, found := files["bundle/data.json"]
+	if !found {
+		return nil, errors.New("unable to read bundle, missing bundle/data.json")
+	}
 
 	var policies Policies
 	unmarshalErr := json.Unmarshal(data, &policies)
@@ -88,14 +145,18 @@ func (o *OpaProvider) GetPolicyInfo(integration orchestrator.IntegrationInfo, ap
 		return nil, unmarshalErr
 	}
 
+	var manifest Manifest
+	_ = json.Unmarshal(files[ManifestFile], &manifest)
+
 	var hexaPolicies []policysupport.PolicyInfo
 	for _, p := range policies.Policies {
 		var actions []policysupport.ActionInfo
 		for _, a := range p.Actions {
 			actions = append(actions, policysupport.ActionInfo{ActionUri: a.ActionUri})
 		}
-		hexaPolicies = append(hexaPolicies, policysupport.PolicyInfo{
-			Meta:    policysupport.MetaInfo{Version: p.Meta.Version},
+		info := policysupport.PolicyInfo{
+			ID:      p.ID,
+			Meta:    policysupport.MetaInfo{Version: p.Meta.Version, Revision: manifest.Revision},
 			Actions: actions,
 			Subject: policysupport.SubjectInfo{
 				Members: p.Subject.Members,
@@ -103,7 +164,12 @@ func (o *OpaProvider) GetPolicyInfo(integration orchestrator.IntegrationInfo, ap
 			Object: policysupport.ObjectInfo{
 				ResourceID: appInfo.ObjectID, // todo - for now, ensures the correct resource identifier
 			},
-		})
+			Effect: p.Effect,
+		}
+		if p.Condition != nil {
+			info.Condition = policysupport.ConditionInfo{Rule: p.Condition.Rule}
+		}
+		hexaPolicies = append(hexaPolicies, info)
 	}
 	return hexaPolicies, nil
 }
@@ -118,26 +184,42 @@ func (o *OpaProvider) SetPolicyInfo(integration orchestrator.IntegrationInfo, ap
 	if errPolicies != nil {
 		return http.StatusInternalServerError, errPolicies
 	}
+	generated := o.credentials(integration.Key).Rego == RegoGenerated
+	if !generated {
+		if errCondition := policysupport.RejectConditions(o.Name(), policyInfos); errCondition != nil { // todo - the default policy.rego cannot evaluate conditions
+			return http.StatusBadRequest, errCondition
+		}
+	}
 
 	key := integration.Key
 	client := o.ensureClientIsAvailable(key)
 
+	written := make([]policysupport.PolicyInfo, 0)
 	var policies []Policy
-	for _, p := range policyInfos {
+	for _, p := range policysupport.EnsureIdentifiers(policyInfos) {
+		p.Object.ResourceID = appInfo.ObjectID // todo - for now, ensures the correct resource identifier
+		written = append(written, p)
+
 		var actions []Action
 		for _, a := range p.Actions {
 			actions = append(actions, Action{a.ActionUri})
 		}
-		policies = append(policies, Policy{
+		policy := Policy{
+			ID:      p.ID,
 			Meta:    Meta{Version: p.Meta.Version},
 			Actions: actions,
 			Subject: Subject{
 				p.Subject.Members,
 			},
 			Object: Object{
-				ResourceID: appInfo.ObjectID, // todo - for now, ensures the correct resource identifier
+				ResourceID: p.Object.ResourceID,
 			},
-		})
+			Effect: p.Effect,
+		}
+		if p.HasCondition() {
+			policy.Condition = &Condition{Rule: p.Condition.Rule}
+		}
+		policies = append(policies, policy)
 	}
 	data, marshalErr := json.Marshal(Policies{policies})
 	if marshalErr != nil {
@@ -145,46 +227,152 @@ func (o *OpaProvider) SetPolicyInfo(integration orchestrator.IntegrationInfo, ap
 		return http.StatusInternalServerError, marshalErr
 	}
 
-	bundle, copyErr := o.MakeDefaultBundle(data)
-	if copyErr != nil {
-		log.Printf("open-policy-agent, unable to create default bundle. %s\n", copyErr)
-		return http.StatusInternalServerError, copyErr
+	regoPackage := o.regoPackage(appInfo)
+	rego := bytes.Replace(o.defaultRego(), []byte("package "+defaultPackage), []byte("package "+regoPackage), 1)
+	if generated {
+		generatedRego, regoErr := GenerateRego(regoPackage, written)
+		if regoErr != nil {
+			return http.StatusBadRequest, regoErr
+		}
+		rego = []byte(generatedRego)
+	}
+	bundle, bundleErr := o.makeBundle(data, rego, regoPackage, client.Signer)
+	if bundleErr != nil {
+		log.Printf("open-policy-agent, unable to create bundle. %s\n", bundleErr)
+		return http.StatusInternalServerError, bundleErr
 	}
 	defer func() {
 		if err := recover(); err != nil {
 			log.Println("unable to set policy.")
 		}
 	}()
-	return client.PostBundle(client.BundleServerURL, bundle.Bytes())
+	return client.PostBundle(o.bundleUrl(client, appInfo), bundle.Bytes())
+}
+
+func (o *OpaProvider) ToCanonical(policyInfos []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
+	members, err := policysupport.TranslateMembers(policyInfos, func(member string) (string, error) {
+		if strings.Contains(member, "@") && !strings.Contains(member, ":") {
+			return policysupport.CanonicalUserPrefix + member, nil
+		}
+		return member, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policysupport.TranslateActions(members, func(actionUri string) (string, error) {
+		if actionUri == anyMethodAction {
+			return policysupport.CanonicalAccessAction, nil
+		}
+		return actionUri, nil
+	})
 }
 
+func (o *OpaProvider) FromCanonical(policyInfos []policysupport.PolicyInfo) ([]policysupport.PolicyInfo, error) {
+	members, err := policysupport.TranslateMembers(policyInfos, func(member string) (string, error) {
+		return strings.TrimPrefix(member, policysupport.CanonicalUserPrefix), nil // principals are matched by email within the rego
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policysupport.TranslateActions(members, func(actionUri string) (string, error) {
+		if actionUri == policysupport.CanonicalAccessAction {
+			return anyMethodAction, nil
+		}
+		return actionUri, nil
+	})
+}
+
+// MakeDefaultBundle bundles the data with the fixed policy.rego, which evaluates the policies found in the data.
 func (o *OpaProvider) MakeDefaultBundle(data []byte) (bytes.Buffer, error) {
+	return o.MakeBundle(data, o.defaultRego())
+}
+
+// MakeBundle bundles the data with a policy module, such as one from GenerateRego.
+func (o *OpaProvider) MakeBundle(data []byte, rego []byte) (bytes.Buffer, error) {
+	return o.makeBundle(data, rego, defaultPackage, nil)
+}
+
+// Revision identifies bundle content, so that decision logs can be correlated with the policies that made them.
+func Revision(data []byte, rego []byte) string {
+	hash := sha256.New()
+	hash.Write(data)
+	hash.Write([]byte{0})
+	hash.Write(rego)
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+func (o *OpaProvider) defaultRego() []byte {
 	_, file, _, _ := runtime.Caller(0)
-	join := filepath.Join(file, "../resources/bundles/bundle")
-	manifest, _ := ioutil.ReadFile(filepath.Join(join, "/.manifest"))
-	rego, _ := ioutil.ReadFile(filepath.Join(join, "/policy.rego"))
+	rego, _ := ioutil.ReadFile(filepath.Join(file, "../resources/bundles/bundle/policy.rego"))
+	return rego
+}
 
-	// todo - ignoring errors for the moment while spiking
+// makeBundle builds the bundle in memory with a manifest whose revision hashes the bundle's content and whose roots
+// are the rego package and the policies' data, adding a .signatures.json when given a signer.
+func (o *OpaProvider) makeBundle(data []byte, rego []byte, regoPackage string, signer *BundleSigner) (bytes.Buffer, error) {
+	root := strings.ReplaceAll(regoPackage, ".", "/")
+	manifest, _ := json.Marshal(Manifest{Revision: Revision(data, rego), Roots: []string{root, "bundle"}})
 
-	tempDir := os.TempDir()
-	_ = os.Mkdir(filepath.Join(tempDir, "/bundles"), 0744)
-	_ = os.Mkdir(filepath.Join(tempDir, "/bundles/bundle"), 0744)
-	_ = ioutil.WriteFile(filepath.Join(tempDir, "/bundles/bundle/.manifest"), manifest, 0644)
-	_ = ioutil.WriteFile(filepath.Join(tempDir, "/bundles/bundle/data.json"), data, 0644)
-	_ = ioutil.WriteFile(filepath.Join(tempDir, "/bundles/bundle/policy.rego"), rego, 0644)
+	files := map[string][]byte{ManifestFile: manifest, "bundle/data.json": data, "bundle/policy.rego": rego}
+	if signer != nil {
+		signed, signErr := signer.Sign(files)
+		if signErr != nil {
+			return bytes.Buffer{}, signErr
+		}
+		files[SignaturesFile] = signed
+	}
 
-	tar, _ := compressionsupport.TarFromPath(filepath.Join(tempDir, "/bundles"))
 	var buffer bytes.Buffer
-	_ = compressionsupport.Gzip(&buffer, tar)
-
-	return buffer, nil
+	err := compressionsupport.TarGzip(&buffer, files)
+	return buffer, err
 }
 
 // /
 
+// bundleUrl is the application's bundle when it is on the key's bundle server, otherwise the key's bundle, as for
+// applications discovered before bundles were listed.
+func (o *OpaProvider) bundleUrl(client BundleClient, appInfo orchestrator.ApplicationInfo) string {
+	decoded, decodeErr := base64.StdEncoding.DecodeString(appInfo.ObjectID)
+	if decodeErr != nil {
+		return client.BundleServerURL
+	}
+	app, appErr := url.Parse(string(decoded))
+	server, serverErr := url.Parse(client.BundleServerURL)
+	if appErr != nil || serverErr != nil || app.Scheme != server.Scheme || app.Host != server.Host || server.Host == "" {
+		return client.BundleServerURL
+	}
+	return strings.SplitN(string(decoded), "#", 2)[0]
+}
+
+// regoPackage is the package of the application's bundle, kept as its identifier's fragment, or authz.
+func (o *OpaProvider) regoPackage(appInfo orchestrator.ApplicationInfo) string {
+	decoded, _ := base64.StdEncoding.DecodeString(appInfo.ObjectID)
+	if app, err := url.Parse(string(decoded)); err == nil && app.Fragment != "" {
+		return app.Fragment
+	}
+	return defaultPackage
+}
+
+// Rego modes of an integration key, data-only bundles the fixed policy.rego while generated compiles the policies
+// into the bundle's rego, supporting conditions.
+const (
+	RegoData      = "data"
+	RegoGenerated = "generated"
+)
+
+// defaultPackage is the package of the fixed policy.rego and of bundles whose package is not known.
+const defaultPackage = "authz"
+
+// anyMethodAction matches every request method, both the fixed policy.rego and generated rego treat * as a wildcard.
+const anyMethodAction = "http:*"
+
 type credentials struct {
 	BundleUrl string `json:"bundle_url"`
 	CACert    string `json:"ca_cert,omitempty"`
+	Rego      string `json:"rego,omitempty"`
+	// SigningKey signs bundles and verifies them when read back, see BundleSigner.
+	SigningKey   string `json:"signing_key,omitempty"`
+	SigningKeyID string `json:"signing_key_id,omitempty"`
 }
 
 func (o *OpaProvider) credentials(key []byte) credentials {
@@ -214,12 +402,22 @@ func (o *OpaProvider) ensureClientIsAvailable(key []byte) BundleClient {
 		}
 	}
 
+	var signer *BundleSigner
+	if creds.SigningKey != "" {
+		signer = &BundleSigner{Key: []byte(creds.SigningKey), KeyID: creds.SigningKeyID}
+	}
+
 	if o.BundleClientOverride.HttpClient != nil {
-		return o.BundleClientOverride
+		override := o.BundleClientOverride
+		if signer != nil {
+			override.Signer = signer
+		}
+		return override
 	}
 
 	return BundleClient{
 		BundleServerURL: creds.BundleUrl,
 		HttpClient:      client,
+		Signer:          signer,
 	}
 }
@@ -2,19 +2,19 @@ package openpolicyagent_test
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"math/rand"
+	"io"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
-	"time"
 
 	"github.com/hexa-org/policy-orchestrator/pkg/compressionsupport"
 	"github.com/hexa-org/policy-orchestrator/pkg/orchestrator"
@@ -37,6 +37,111 @@ func TestDiscoverApplications(t *testing.T) {
 	assert.Equal(t, "Open policy agent bundle", applications[0].Description)
 }
 
+func TestDiscoverApplications_withUnparsableUrl(t *testing.T) {
+	key := []byte(`{"bundle_url": "http://[::1"}`)
+	p := openpolicyagent.OpaProvider{}
+	applications, _ := p.DiscoverApplications(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key})
+	assert.Equal(t, 1, len(applications))
+	assert.Equal(t, "package authz", applications[0].Name)
+}
+
+func TestDiscoverApplications_withListedBundles(t *testing.T) {
+	bundleServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bundles", r.URL.Path)
+		_, _ = rw.Write([]byte(`{"bundles":[{"name":"bundle","path":"/bundles/bundle.tar.gz","package":"authz"},{"name":"anotherService","path":"/bundles/anotherService.tar.gz","package":"another"}]}`))
+	}))
+	defer bundleServer.Close()
+
+	key := []byte(fmt.Sprintf(`{"bundle_url": "%s/bundles/bundle.tar.gz"}`, bundleServer.URL))
+	p := openpolicyagent.OpaProvider{}
+	applications, err := p.DiscoverApplications(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key})
+	assert.NoError(t, err)
+	assert.Equal(t, []orchestrator.ApplicationInfo{
+		{ObjectID: base64.StdEncoding.EncodeToString([]byte(bundleServer.URL + "/bundles/bundle.tar.gz")), Name: "bundle package authz", Description: "Open policy agent bundle /bundles/bundle.tar.gz"},
+		{ObjectID: base64.StdEncoding.EncodeToString([]byte(bundleServer.URL + "/bundles/anotherService.tar.gz#another")), Name: "anotherService package another", Description: "Open policy agent bundle /bundles/anotherService.tar.gz"},
+	}, applications)
+}
+
+func TestPolicyInfo_withBundlePath(t *testing.T) {
+	var bundle []byte
+	var posted []string
+	bundleServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posted = append(posted, r.URL.Path)
+			file, _, _ := r.FormFile("bundle")
+			bundle, _ = io.ReadAll(file)
+			rw.WriteHeader(http.StatusCreated)
+			return
+		}
+		assert.Equal(t, "/bundles/anotherService.tar.gz", r.URL.Path)
+		_, _ = rw.Write(bundle)
+	}))
+	defer bundleServer.Close()
+
+	key := []byte(fmt.Sprintf(`{"bundle_url": "%s/bundles/bundle.tar.gz"}`, bundleServer.URL))
+	app := orchestrator.ApplicationInfo{ObjectID: base64.StdEncoding.EncodeToString([]byte(bundleServer.URL + "/bundles/anotherService.tar.gz"))}
+	p := openpolicyagent.OpaProvider{}
+	status, err := p.SetPolicyInfo(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key}, app,
+		[]policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}},
+			Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}, Object: policysupport.ObjectInfo{ResourceID: "aResourceId"}}},
+	)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.NoError(t, err)
+
+	policies, err := p.GetPolicyInfo(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key}, app)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(policies))
+	assert.Equal(t, app.ObjectID, policies[0].Object.ResourceID)
+
+	elsewhere := orchestrator.ApplicationInfo{ObjectID: base64.StdEncoding.EncodeToString([]byte("http://elsewhere:8889/bundles/anotherService.tar.gz"))}
+	_, _ = p.SetPolicyInfo(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key}, elsewhere,
+		[]policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}},
+			Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}, Object: policysupport.ObjectInfo{ResourceID: "aResourceId"}}},
+	)
+	assert.Equal(t, []string{"/bundles/anotherService", "/bundles/bundle"}, posted)
+}
+
+func TestSetPolicyInfo_withBundlePackage(t *testing.T) {
+	var bundle []byte
+	var posted []string
+	bundleServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posted = append(posted, r.URL.Path)
+			file, _, _ := r.FormFile("bundle")
+			bundle, _ = io.ReadAll(file)
+			rw.WriteHeader(http.StatusCreated)
+			return
+		}
+		assert.Equal(t, "/bundles/payments.tar.gz", r.URL.Path)
+		_, _ = rw.Write(bundle)
+	}))
+	defer bundleServer.Close()
+
+	app := orchestrator.ApplicationInfo{ObjectID: base64.StdEncoding.EncodeToString([]byte(bundleServer.URL + "/bundles/payments.tar.gz#payments.authz"))}
+	policies := []policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}},
+		Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}, Object: policysupport.ObjectInfo{ResourceID: "aResourceId"}}}
+
+	for _, rego := range []string{openpolicyagent.RegoData, openpolicyagent.RegoGenerated} {
+		key := []byte(fmt.Sprintf(`{"bundle_url": "%s/bundles/bundle.tar.gz", "rego": "%s"}`, bundleServer.URL, rego))
+		p := openpolicyagent.OpaProvider{}
+		status, err := p.SetPolicyInfo(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key}, app, policies)
+		assert.Equal(t, http.StatusCreated, status)
+		assert.NoError(t, err)
+
+		files, _ := compressionsupport.UnTarGzip(bytes.NewReader(bundle))
+		assert.Contains(t, string(files["bundle/policy.rego"]), "package payments.authz\n")
+		assert.NotContains(t, string(files["bundle/policy.rego"]), "package authz")
+		var manifest openpolicyagent.Manifest
+		_ = json.Unmarshal(files[".manifest"], &manifest)
+		assert.Equal(t, []string{"payments/authz", "bundle"}, manifest.Roots)
+
+		found, err := p.GetPolicyInfo(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key}, app)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(found))
+	}
+	assert.Equal(t, []string{"/bundles/payments", "/bundles/payments"}, posted)
+}
+
 func TestGetPolicyInfo(t *testing.T) {
 	key := []byte(`
 {
@@ -59,6 +164,32 @@ func TestGetPolicyInfo(t *testing.T) {
 	assert.Equal(t, 4, len(policies))
 }
 
+func TestGetPolicyInfo_withRevision(t *testing.T) {
+	key := []byte(`{"bundle_url": "aBigUrl"}`)
+	set := func(member string) []byte {
+		mockClient := openpolicyagent_test.MockClient{Status: http.StatusCreated}
+		p := openpolicyagent.OpaProvider{BundleClientOverride: openpolicyagent.BundleClient{HttpClient: &mockClient}}
+		_, _ = p.SetPolicyInfo(
+			orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key},
+			orchestrator.ApplicationInfo{ObjectID: "aResourceId"},
+			[]policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}},
+				Subject: policysupport.SubjectInfo{Members: []string{member}}, Object: policysupport.ObjectInfo{ResourceID: "aResourceId"}}},
+		)
+		return mockClient.Request
+	}
+	get := func(bundle []byte) []policysupport.PolicyInfo {
+		p := openpolicyagent.OpaProvider{BundleClientOverride: openpolicyagent.BundleClient{HttpClient: &openpolicyagent_test.MockClient{Response: bundle}}}
+		policies, _ := p.GetPolicyInfo(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key}, orchestrator.ApplicationInfo{})
+		return policies
+	}
+
+	first := get(set("allusers"))
+	assert.Equal(t, 1, len(first))
+	assert.Equal(t, 16, len(first[0].Meta.Revision))
+	assert.Equal(t, first[0].Meta.Revision, get(set("allusers"))[0].Meta.Revision)
+	assert.NotEqual(t, first[0].Meta.Revision, get(set("allauthenticated"))[0].Meta.Revision)
+}
+
 func TestGetPolicyInfo_withBadKey(t *testing.T) {
 	client := openpolicyagent.BundleClient{}
 	_, file, _, _ := runtime.Caller(0)
@@ -120,13 +251,170 @@ func TestSetPolicyInfo(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, status)
 	assert.NoError(t, err)
 
-	gzip, _ := compressionsupport.UnGzip(bytes.NewReader(mockClient.Request))
-	rand.Seed(time.Now().UnixNano())
-	path := filepath.Join(file, fmt.Sprintf("../resources/bundles/.bundle-%d", rand.Uint64()))
-	_ = compressionsupport.UnTarToPath(bytes.NewReader(gzip), path)
-	readFile, _ := ioutil.ReadFile(path + "/bundle/data.json")
-	assert.Equal(t, `{"policies":[{"meta":{"version":"0.5"},"actions":[{"action_uri":"http:GET"}],"subject":{"members":["allusers"]},"object":{"resource_id":"anotherResourceId"}}]}`, string(readFile))
-	_ = os.RemoveAll(path)
+	files, _ := compressionsupport.UnTarGzip(bytes.NewReader(mockClient.Request))
+	readFile := files["bundle/data.json"]
+	assert.Equal(t, `{"policies":[{"id":"b865eaa9a741","meta":{"version":"0.5"},"actions":[{"action_uri":"http:GET"}],"subject":{"members":["allusers"]},"object":{"resource_id":"anotherResourceId"}}]}`, string(readFile))
+}
+
+func TestSetPolicyInfo_withDenyEffect(t *testing.T) {
+	key := []byte(`
+{
+  "bundle_url": "aBigUrl"
+}
+`)
+	mockClient := openpolicyagent_test.MockClient{Status: http.StatusCreated}
+	client := openpolicyagent.BundleClient{HttpClient: &mockClient}
+
+	_, file, _, _ := runtime.Caller(0)
+	p := openpolicyagent.OpaProvider{BundleClientOverride: client, ResourcesDirectory: filepath.Join(file, "../resources")}
+	status, err := p.SetPolicyInfo(
+		orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key},
+		orchestrator.ApplicationInfo{ObjectID: "anotherResourceId"},
+		[]policysupport.PolicyInfo{
+			{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}, Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}, Object: policysupport.ObjectInfo{
+				ResourceID: "aResourceId",
+			}, Effect: policysupport.EffectDeny},
+		},
+	)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.NoError(t, err)
+
+	files, _ := compressionsupport.UnTarGzip(bytes.NewReader(mockClient.Request))
+	readFile := files["bundle/data.json"]
+	assert.Equal(t, `{"policies":[{"id":"b865eaa9a741","meta":{"version":"0.5"},"actions":[{"action_uri":"http:GET"}],"subject":{"members":["allusers"]},"object":{"resource_id":"anotherResourceId"},"effect":"deny"}]}`, string(readFile))
+}
+
+func TestSetPolicyInfo_withCondition(t *testing.T) {
+	key := []byte(`
+{
+  "bundle_url": "aBigUrl"
+}
+`)
+	mockClient := openpolicyagent_test.MockClient{Status: http.StatusCreated}
+	client := openpolicyagent.BundleClient{HttpClient: &mockClient}
+
+	_, file, _, _ := runtime.Caller(0)
+	p := openpolicyagent.OpaProvider{BundleClientOverride: client, ResourcesDirectory: filepath.Join(file, "../resources")}
+	status, err := p.SetPolicyInfo(
+		orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key},
+		orchestrator.ApplicationInfo{ObjectID: "anotherResourceId"},
+		[]policysupport.PolicyInfo{
+			{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}, Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}, Object: policysupport.ObjectInfo{
+				ResourceID: "aResourceId",
+			}, Condition: policysupport.ConditionInfo{Rule: "req.ip sw 127"}},
+		},
+	)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.EqualError(t, err, "open_policy_agent does not support policy conditions, unable to apply condition \"req.ip sw 127\"")
+	assert.Nil(t, mockClient.Request)
+}
+
+func TestSetPolicyInfo_withGeneratedRego(t *testing.T) {
+	key := []byte(`
+{
+  "bundle_url": "aBigUrl",
+  "rego": "generated"
+}
+`)
+	mockClient := openpolicyagent_test.MockClient{Status: http.StatusCreated}
+	client := openpolicyagent.BundleClient{HttpClient: &mockClient}
+
+	_, file, _, _ := runtime.Caller(0)
+	p := openpolicyagent.OpaProvider{BundleClientOverride: client, ResourcesDirectory: filepath.Join(file, "../resources")}
+	status, err := p.SetPolicyInfo(
+		orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key},
+		orchestrator.ApplicationInfo{ObjectID: "anotherResourceId"},
+		[]policysupport.PolicyInfo{
+			{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}, Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}, Object: policysupport.ObjectInfo{
+				ResourceID: "aResourceId",
+			}, Condition: policysupport.ConditionInfo{Rule: "req.ip sw 127"}},
+		},
+	)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.NoError(t, err)
+
+	files, _ := compressionsupport.UnTarGzip(bytes.NewReader(mockClient.Request))
+	readFile := files["bundle/data.json"]
+	assert.Contains(t, string(readFile), `"condition":{"rule":"req.ip sw 127"}`)
+	rego := files["bundle/policy.rego"]
+	assert.Contains(t, string(rego), "# Generated by the policy orchestrator from 1 policies, do not edit.")
+	assert.Contains(t, string(rego), `resource_matches("anotherResourceId")`)
+	assert.Contains(t, string(rego), `startswith(input.req.ip, "127")`)
+}
+
+func TestSetPolicyInfo_withGeneratedRegoAndBadCondition(t *testing.T) {
+	key := []byte(`
+{
+  "bundle_url": "aBigUrl",
+  "rego": "generated"
+}
+`)
+	mockClient := openpolicyagent_test.MockClient{Status: http.StatusCreated}
+	client := openpolicyagent.BundleClient{HttpClient: &mockClient}
+
+	_, file, _, _ := runtime.Caller(0)
+	p := openpolicyagent.OpaProvider{BundleClientOverride: client, ResourcesDirectory: filepath.Join(file, "../resources")}
+	status, err := p.SetPolicyInfo(
+		orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key},
+		orchestrator.ApplicationInfo{ObjectID: "anotherResourceId"},
+		[]policysupport.PolicyInfo{
+			{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}, Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}, Object: policysupport.ObjectInfo{
+				ResourceID: "aResourceId",
+			}, Condition: policysupport.ConditionInfo{Rule: "req.ip xx 127"}},
+		},
+	)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.EqualError(t, err, `unable to generate rego for policy "b865eaa9a741", unsupported operator "xx" in condition`)
+	assert.Nil(t, mockClient.Request)
+}
+
+func TestSetPolicyInfo_withSigningKey(t *testing.T) {
+	key := []byte(`
+{
+  "bundle_url": "aBigUrl",
+  "signing_key": "aSecret"
+}
+`)
+	mockClient := openpolicyagent_test.MockClient{Status: http.StatusCreated}
+	client := openpolicyagent.BundleClient{HttpClient: &mockClient}
+
+	_, file, _, _ := runtime.Caller(0)
+	p := openpolicyagent.OpaProvider{BundleClientOverride: client, ResourcesDirectory: filepath.Join(file, "../resources")}
+	status, err := p.SetPolicyInfo(
+		orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key},
+		orchestrator.ApplicationInfo{ObjectID: "aResourceId"},
+		[]policysupport.PolicyInfo{
+			{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}, Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}, Object: policysupport.ObjectInfo{
+				ResourceID: "aResourceId",
+			}},
+		},
+	)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.NoError(t, err)
+
+	files, _ := compressionsupport.UnTarGzip(bytes.NewReader(mockClient.Request))
+	names := make([]string, 0)
+	for name := range files {
+		names = append(names, name)
+	}
+	assert.ElementsMatch(t, []string{".manifest", ".signatures.json", "bundle/data.json", "bundle/policy.rego"}, names)
+
+	var signatures struct {
+		Signatures []string `json:"signatures"`
+	}
+	_ = json.Unmarshal(files[".signatures.json"], &signatures)
+	claims, _ := base64.RawURLEncoding.DecodeString(strings.Split(signatures.Signatures[0], ".")[1])
+	var signed struct {
+		Files []struct {
+			Name string `json:"name"`
+		} `json:"files"`
+	}
+	_ = json.Unmarshal(claims, &signed)
+	signedNames := make([]string, 0)
+	for _, signedFile := range signed.Files {
+		signedNames = append(signedNames, signedFile.Name)
+	}
+	assert.Equal(t, []string{".manifest", "bundle/data.json", "bundle/policy.rego"}, signedNames)
 }
 
 func TestSetPolicyInfo_withInvalidArguments(t *testing.T) {
@@ -222,18 +510,15 @@ func TestMakeDefaultBundle(t *testing.T) {
 }`)
 	bundle, _ := p.MakeDefaultBundle(data)
 
-	gzip, _ := compressionsupport.UnGzip(bytes.NewReader(bundle.Bytes()))
-	rand.Seed(time.Now().UnixNano())
-	path := filepath.Join(os.TempDir(), fmt.Sprintf("/test-bundle-%d", rand.Uint64()))
-	_ = compressionsupport.UnTarToPath(bytes.NewReader(gzip), path)
+	files, _ := compressionsupport.UnTarGzip(bytes.NewReader(bundle.Bytes()))
 
-	created, _ := ioutil.ReadFile(filepath.Join(path, "/bundle/policy.rego"))
+	created := files["bundle/policy.rego"]
 	assert.Contains(t, string(created), "package authz")
 
-	mcreated, _ := ioutil.ReadFile(filepath.Join(path, "/bundle/.manifest"))
-	assert.Contains(t, string(mcreated), "{\"revision\":\"\",\"roots\":[\"\"]}")
+	mcreated := files[".manifest"]
+	assert.Equal(t, fmt.Sprintf(`{"revision":"%s","roots":["authz","bundle"]}`, openpolicyagent.Revision(data, created)), string(mcreated))
 
-	dcreated, _ := ioutil.ReadFile(filepath.Join(path, "/bundle/data.json"))
+	dcreated := files["bundle/data.json"]
 	assert.Equal(t, `{
   "policies": [
     {
@@ -251,5 +536,81 @@ func TestMakeDefaultBundle(t *testing.T) {
     }
   ]
 }`, string(dcreated))
-	_ = os.RemoveAll(path)
+}
+
+func TestTranslation(t *testing.T) {
+	p := openpolicyagent.OpaProvider{}
+	infos := []policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET:/"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser@example.com", "allauthenticated"}}, Object: policysupport.ObjectInfo{ResourceID: "anId"}}}
+
+	canonical, err := p.ToCanonical(infos)
+	assert.NoError(t, err)
+	assert.Equal(t, "http:GET:/", canonical[0].Actions[0].ActionUri)
+	assert.Equal(t, []string{"user:aUser@example.com", "allauthenticated"}, canonical[0].Subject.Members)
+
+	opa, err := p.FromCanonical(canonical)
+	assert.NoError(t, err)
+	assert.Equal(t, infos, opa)
+}
+
+func TestTranslation_withAccessAction(t *testing.T) {
+	p := openpolicyagent.OpaProvider{}
+	canonical := []policysupport.PolicyInfo{{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "hexa:access"}}, Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}, Object: policysupport.ObjectInfo{ResourceID: "anId"}}}
+
+	opa, err := p.FromCanonical(canonical)
+	assert.NoError(t, err)
+	assert.Equal(t, "http:*", opa[0].Actions[0].ActionUri)
+
+	rego, err := openpolicyagent.GenerateRego("authz", opa)
+	assert.NoError(t, err)
+	assert.Contains(t, rego, `regex.match("^http:.*$", input.method)`)
+
+	back, err := p.ToCanonical(opa)
+	assert.NoError(t, err)
+	assert.Equal(t, canonical, back)
+}
+
+func TestCheckCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bundles/bundle.tar.gz" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := openpolicyagent.OpaProvider{BundleClientOverride: openpolicyagent.BundleClient{HttpClient: &http.Client{}}}
+
+	key := []byte(fmt.Sprintf(`{"bundle_url": "%s/bundles/bundle.tar.gz"}`, server.URL))
+	assert.NoError(t, p.CheckCredentials(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: key}))
+
+	unknownKey := []byte(fmt.Sprintf(`{"bundle_url": "%s/bundles/unknown.tar.gz"}`, server.URL))
+	err := p.CheckCredentials(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: unknownKey})
+	assert.Contains(t, err.Error(), "404 Not Found")
+}
+
+func TestCheckCredentials_withBadKey(t *testing.T) {
+	p := openpolicyagent.OpaProvider{}
+	err := p.CheckCredentials(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: []byte("{}")})
+	assert.Equal(t, "unable to read open policy agent key, missing bundle_url", err.Error())
+}
+
+func TestCheckCredentials_withRequestError(t *testing.T) {
+	client := &openpolicyagent_test.MockClient{Err: errors.New("oops")}
+	p := openpolicyagent.OpaProvider{BundleClientOverride: openpolicyagent.BundleClient{HttpClient: client}}
+	err := p.CheckCredentials(orchestrator.IntegrationInfo{Name: "open_policy_agent", Key: []byte(`{"bundle_url": "aBigUrl"}`)})
+	assert.Equal(t, "unable to reach bundle server, oops", err.Error())
+}
+
+func TestMakeBundle_concurrently(t *testing.T) {
+	p := openpolicyagent.OpaProvider{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(data []byte) {
+			defer wg.Done()
+			bundle, err := p.MakeBundle(data, []byte("package authz"))
+			assert.NoError(t, err)
+			files, _ := compressionsupport.UnTarGzip(&bundle)
+			assert.Equal(t, string(data), string(files["bundle/data.json"]))
+		}([]byte(fmt.Sprintf(`{"policies":[{"id":"%d"}]}`, i)))
+	}
+	wg.Wait()
 }
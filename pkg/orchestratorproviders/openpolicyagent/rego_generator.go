@@ -0,0 +1,377 @@
+package openpolicyagent
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
+)
+
+// GenerateRego compiles policies into a self-contained module of the rego package, allowing a request when a policy
+// permits it and none denies it. Requests are matched on input.method and input.principal, and on input.resource when present.
+//
+// Action uris and members may contain * wildcards. Conditions compare input attributes, for example
+//
+//	req.ip sw "127." and (req.method eq "GET" or not (req.header.x pr))
+//
+// with the operators eq, ne, gt, ge, lt, le, sw, ew, co and pr, where req.ip reads input.req.ip.
+func GenerateRego(regoPackage string, infos []policysupport.PolicyInfo) (string, error) {
+	g := regoGenerator{}
+	g.line("# Generated by the policy orchestrator from %d policies, do not edit.", len(infos))
+	g.line("package %s", regoPackage)
+	g.line("")
+	g.line("import future.keywords.in")
+	g.line("")
+	g.line("default allow = false")
+	g.line("")
+	g.line("default permitted = false")
+	g.line("")
+	g.line("default denied = false")
+	g.line("")
+	g.line("allow {")
+	g.line("\tpermitted")
+	g.line("\tnot denied")
+	g.line("}")
+	g.line("")
+	g.line("principal_exists {")
+	g.line("\tinput.principal != \"\"")
+	g.line("}")
+	g.line("")
+	g.line("resource_matches(resource) {")
+	g.line("\tnot input.resource")
+	g.line("}")
+	g.line("")
+	g.line("resource_matches(resource) {")
+	g.line("\tinput.resource == resource")
+	g.line("}")
+
+	for i, info := range infos {
+		if err := g.policy(i, info); err != nil {
+			return "", fmt.Errorf("unable to generate rego for policy %q, %w", info.ID, err)
+		}
+	}
+	return g.builder.String(), nil
+}
+
+type regoGenerator struct {
+	builder strings.Builder
+	helpers int
+}
+
+func (g *regoGenerator) line(format string, args ...interface{}) {
+	g.builder.WriteString(fmt.Sprintf(format, args...) + "\n")
+}
+
+func (g *regoGenerator) policy(i int, info policysupport.PolicyInfo) error {
+	name := fmt.Sprintf("policy_%d", i)
+	effect := "permitted"
+	if info.IsDeny() {
+		effect = "denied"
+	}
+	g.line("")
+	if info.ID != "" {
+		g.line("# %s", info.ID)
+	}
+	g.line("%s {", effect)
+	g.line("\t%s", name)
+	g.line("}")
+
+	actions := make([]string, 0)
+	for _, action := range info.Actions {
+		actions = append(actions, action.ActionUri)
+	}
+	g.values(name+"_action", "input.method", actions, nil)
+	g.values(name+"_member", "input.principal", info.Subject.Members, map[string]string{
+		policysupport.CanonicalAllUsers:         "true",
+		policysupport.CanonicalAllAuthenticated: "principal_exists",
+	})
+
+	body := []string{name + "_action", name + "_member", "resource_matches(" + quote(info.Object.ResourceID) + ")"}
+	if info.HasCondition() {
+		node, err := parseCondition(info.Condition.Rule)
+		if err != nil {
+			return err
+		}
+		body = append(body, g.condition(name+"_condition", node))
+	}
+	g.rule(name, body)
+	return nil
+}
+
+// values defines a rule matching the input against exact values, wildcard patterns and keywords, a rule without
+// values never matches.
+func (g *regoGenerator) values(name, input string, values []string, keywords map[string]string) {
+	exact := make([]string, 0)
+	bodies := make([][]string, 0)
+	for _, value := range values {
+		if expression, found := keywords[value]; found {
+			bodies = append(bodies, []string{expression})
+			continue
+		}
+		if strings.Contains(value, "*") {
+			pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(value), `\*`, ".*") + "$"
+			bodies = append(bodies, []string{fmt.Sprintf("regex.match(%s, %s)", quote(pattern), input)})
+			continue
+		}
+		exact = append(exact, quote(value))
+	}
+	if len(exact) > 0 {
+		sort.Strings(exact)
+		bodies = append([][]string{{fmt.Sprintf("%s in {%s}", input, strings.Join(exact, ", "))}}, bodies...)
+	}
+	if len(bodies) == 0 {
+		bodies = append(bodies, []string{"false"})
+	}
+	for _, body := range bodies {
+		g.rule(name, body)
+	}
+}
+
+// condition defines rules for the condition and returns the expression referencing them. Disjunctions become a rule
+// per alternative and negations a helper rule, so that any condition compiles to plain rule bodies.
+func (g *regoGenerator) condition(name string, node conditionNode) string {
+	if node.op == "or" {
+		for _, child := range node.children {
+			g.rule(name, g.conjuncts(name, child))
+		}
+		return name
+	}
+	g.rule(name, g.conjuncts(name, node))
+	return name
+}
+
+func (g *regoGenerator) conjuncts(name string, node conditionNode) []string {
+	switch node.op {
+	case "and":
+		body := make([]string, 0)
+		for _, child := range node.children {
+			body = append(body, g.conjuncts(name, child)...)
+		}
+		return body
+	case "or":
+		return []string{g.condition(g.helper(name), node)}
+	case "not":
+		return []string{"not " + g.condition(g.helper(name), node.children[0])}
+	}
+	return []string{node.comparison()}
+}
+
+func (g *regoGenerator) helper(name string) string {
+	g.helpers++
+	return fmt.Sprintf("%s_%d", name, g.helpers)
+}
+
+func (g *regoGenerator) rule(name string, body []string) {
+	g.line("")
+	g.line("%s {", name)
+	for _, expression := range body {
+		g.line("\t%s", expression)
+	}
+	g.line("}")
+}
+
+func quote(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+type conditionNode struct {
+	op        string
+	children  []conditionNode
+	attribute string
+	value     string
+	quoted    bool
+}
+
+var comparisons = map[string]string{"eq": "==", "ne": "!=", "gt": ">", "ge": ">=", "lt": "<", "le": "<="}
+
+var functions = map[string]string{"sw": "startswith", "ew": "endswith", "co": "contains"}
+
+func (n conditionNode) comparison() string {
+	input := "input." + n.attribute
+	if n.op == "pr" {
+		return input + " != null"
+	}
+	if function, found := functions[n.op]; found {
+		return fmt.Sprintf("%s(%s, %s)", function, input, quote(n.value))
+	}
+	return fmt.Sprintf("%s %s %s", input, comparisons[n.op], literal(n.value, n.quoted))
+}
+
+// literal keeps unquoted numbers and booleans as they are, anything else is compared as a string.
+func literal(value string, quoted bool) string {
+	if quoted {
+		return quote(value)
+	}
+	if value == "true" || value == "false" || value == "null" {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return quote(value)
+}
+
+var attributePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+func parseCondition(rule string) (conditionNode, error) {
+	tokens, err := conditionTokens(rule)
+	if err != nil {
+		return conditionNode{}, err
+	}
+	p := conditionParser{tokens: tokens}
+	node, err := p.or()
+	if err != nil {
+		return conditionNode{}, err
+	}
+	if p.position < len(p.tokens) {
+		return conditionNode{}, fmt.Errorf("unexpected %q in condition %q", p.tokens[p.position].text, rule)
+	}
+	return node, nil
+}
+
+type conditionToken struct {
+	text   string
+	quoted bool
+}
+
+func conditionTokens(rule string) ([]conditionToken, error) {
+	tokens := make([]conditionToken, 0)
+	runes := []rune(rule)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, conditionToken{text: string(r)})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in condition %q", rule)
+			}
+			text, err := strconv.Unquote(string(runes[i : end+1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid string in condition %q", rule)
+			}
+			tokens = append(tokens, conditionToken{text: text, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '(' && runes[end] != ')' {
+				end++
+			}
+			tokens = append(tokens, conditionToken{text: string(runes[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type conditionParser struct {
+	tokens   []conditionToken
+	position int
+}
+
+func (p *conditionParser) peek(keyword string) bool {
+	return p.position < len(p.tokens) && !p.tokens[p.position].quoted && strings.EqualFold(p.tokens[p.position].text, keyword)
+}
+
+func (p *conditionParser) next() (conditionToken, error) {
+	if p.position >= len(p.tokens) {
+		return conditionToken{}, fmt.Errorf("unexpected end of condition")
+	}
+	p.position++
+	return p.tokens[p.position-1], nil
+}
+
+func (p *conditionParser) or() (conditionNode, error) {
+	return p.chain("or", p.and)
+}
+
+func (p *conditionParser) and() (conditionNode, error) {
+	return p.chain("and", p.unary)
+}
+
+func (p *conditionParser) chain(op string, operand func() (conditionNode, error)) (conditionNode, error) {
+	first, err := operand()
+	if err != nil {
+		return conditionNode{}, err
+	}
+	children := []conditionNode{first}
+	for p.peek(op) {
+		p.position++
+		next, nextErr := operand()
+		if nextErr != nil {
+			return conditionNode{}, nextErr
+		}
+		children = append(children, next)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return conditionNode{op: op, children: children}, nil
+}
+
+func (p *conditionParser) unary() (conditionNode, error) {
+	if p.peek("not") {
+		p.position++
+		operand, err := p.unary()
+		if err != nil {
+			return conditionNode{}, err
+		}
+		return conditionNode{op: "not", children: []conditionNode{operand}}, nil
+	}
+	if p.peek("(") {
+		p.position++
+		node, err := p.or()
+		if err != nil {
+			return conditionNode{}, err
+		}
+		if !p.peek(")") {
+			return conditionNode{}, fmt.Errorf("missing ) in condition")
+		}
+		p.position++
+		return node, nil
+	}
+	return p.comparison()
+}
+
+func (p *conditionParser) comparison() (conditionNode, error) {
+	attribute, err := p.next()
+	if err != nil {
+		return conditionNode{}, err
+	}
+	if attribute.quoted || !attributePattern.MatchString(attribute.text) {
+		return conditionNode{}, fmt.Errorf("invalid attribute %q in condition", attribute.text)
+	}
+	operator, err := p.next()
+	if err != nil {
+		return conditionNode{}, err
+	}
+	op := strings.ToLower(operator.text)
+	if _, comparison := comparisons[op]; !comparison && functions[op] == "" && op != "pr" {
+		return conditionNode{}, fmt.Errorf("unsupported operator %q in condition", operator.text)
+	}
+	node := conditionNode{op: op, attribute: attribute.text}
+	if op == "pr" {
+		return node, nil
+	}
+	value, err := p.next()
+	if err != nil {
+		return conditionNode{}, err
+	}
+	node.value, node.quoted = value.text, value.quoted
+	return node, nil
+}
@@ -0,0 +1,68 @@
+package openpolicyagent_test
+
+import (
+	"testing"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/orchestratorproviders/openpolicyagent"
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateRego(t *testing.T) {
+	rego, err := openpolicyagent.GenerateRego("authz", []policysupport.PolicyInfo{
+		{ID: "aPolicy", Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}, {ActionUri: "http:POST"}, {ActionUri: "http:*"}},
+			Subject: policysupport.SubjectInfo{Members: []string{"user:a@example.com", "allauthenticated"}},
+			Object:  policysupport.ObjectInfo{ResourceID: "aResourceId"}},
+		{ID: "aDeny", Actions: []policysupport.ActionInfo{{ActionUri: "http:DELETE"}},
+			Subject: policysupport.SubjectInfo{Members: []string{"allusers"}},
+			Object:  policysupport.ObjectInfo{ResourceID: "aResourceId"}, Effect: policysupport.EffectDeny},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, rego, "package authz")
+	assert.Contains(t, rego, "allow {\n\tpermitted\n\tnot denied\n}")
+	assert.Contains(t, rego, "# aPolicy\npermitted {\n\tpolicy_0\n}")
+	assert.Contains(t, rego, "policy_0_action {\n\tinput.method in {\"http:GET\", \"http:POST\"}\n}")
+	assert.Contains(t, rego, "policy_0_action {\n\tregex.match(\"^http:.*$\", input.method)\n}")
+	assert.Contains(t, rego, "policy_0_member {\n\tinput.principal in {\"user:a@example.com\"}\n}")
+	assert.Contains(t, rego, "policy_0_member {\n\tprincipal_exists\n}")
+	assert.Contains(t, rego, "policy_0 {\n\tpolicy_0_action\n\tpolicy_0_member\n\tresource_matches(\"aResourceId\")\n}")
+	assert.Contains(t, rego, "# aDeny\ndenied {\n\tpolicy_1\n}")
+	assert.Contains(t, rego, "policy_1_member {\n\ttrue\n}")
+}
+
+func TestGenerateRego_withCondition(t *testing.T) {
+	rego, err := openpolicyagent.GenerateRego("authz", []policysupport.PolicyInfo{
+		{ID: "aPolicy", Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}, Subject: policysupport.SubjectInfo{Members: []string{"allusers"}},
+			Condition: policysupport.ConditionInfo{Rule: `req.ip sw "127." and (req.port eq 8080 or not (req.header.x pr))`}},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, rego, "policy_0 {\n\tpolicy_0_action\n\tpolicy_0_member\n\tresource_matches(\"\")\n\tpolicy_0_condition\n}")
+	assert.Contains(t, rego, "policy_0_condition {\n\tstartswith(input.req.ip, \"127.\")\n\tpolicy_0_condition_1\n}")
+	assert.Contains(t, rego, "policy_0_condition_1 {\n\tinput.req.port == 8080\n}")
+	assert.Contains(t, rego, "policy_0_condition_1 {\n\tnot policy_0_condition_1_2\n}")
+	assert.Contains(t, rego, "policy_0_condition_1_2 {\n\tinput.req.header.x != null\n}")
+}
+
+func TestGenerateRego_withNoMembers(t *testing.T) {
+	rego, err := openpolicyagent.GenerateRego("authz", []policysupport.PolicyInfo{
+		{Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, rego, "policy_0_member {\n\tfalse\n}")
+}
+
+func TestGenerateRego_withBadCondition(t *testing.T) {
+	for rule, message := range map[string]string{
+		`req.ip xx 127`:          `unsupported operator "xx" in condition`,
+		`"req.ip" eq 127`:        `invalid attribute "req.ip" in condition`,
+		`req.ip eq`:              `unexpected end of condition`,
+		`(req.ip eq 127`:         `missing ) in condition`,
+		`req.ip eq "127`:         `unterminated string in condition "req.ip eq \"127"`,
+		`req.ip eq 127 req.port`: `unexpected "req.port" in condition "req.ip eq 127 req.port"`,
+	} {
+		_, err := openpolicyagent.GenerateRego("authz", []policysupport.PolicyInfo{
+			{ID: "aPolicy", Condition: policysupport.ConditionInfo{Rule: rule}},
+		})
+		assert.EqualError(t, err, `unable to generate rego for policy "aPolicy", `+message)
+	}
+}
@@ -0,0 +1,122 @@
+package policysupport
+
+import (
+	"fmt"
+	"sort"
+)
+
+type PolicyDiff struct {
+	ActionsAdded   []string     `json:"actions_added"`
+	ActionsRemoved []string     `json:"actions_removed"`
+	Members        []MemberDiff `json:"members"`
+	Rules          []RuleDiff   `json:"rules"`
+}
+
+type MemberDiff struct {
+	ActionUri string   `json:"action_uri"`
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+}
+
+// RuleDiff lists the rules, an effect on a resource and any condition, of the policies for an action that were added
+// or removed, such as a policy turned from allow to deny.
+type RuleDiff struct {
+	ActionUri string   `json:"action_uri"`
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+}
+
+func (d PolicyDiff) IsEmpty() bool {
+	return len(d.ActionsAdded) == 0 && len(d.ActionsRemoved) == 0 && len(d.Members) == 0 && len(d.Rules) == 0
+}
+
+// Diff compares the members and rules of each action, ignoring how members and actions are grouped into policies.
+func Diff(current, proposed []PolicyInfo) PolicyDiff {
+	currentMembers := byAction(current, members)
+	proposedMembers := byAction(proposed, members)
+	currentRules := byAction(current, rules)
+	proposedRules := byAction(proposed, rules)
+
+	diff := PolicyDiff{ActionsAdded: make([]string, 0), ActionsRemoved: make([]string, 0), Members: make([]MemberDiff, 0), Rules: make([]RuleDiff, 0)}
+	for _, action := range sortedKeys(proposedMembers, currentMembers) {
+		existing, wasGranted := currentMembers[action]
+		desired, isGranted := proposedMembers[action]
+		if isGranted && !wasGranted {
+			diff.ActionsAdded = append(diff.ActionsAdded, action)
+		}
+		if wasGranted && !isGranted {
+			diff.ActionsRemoved = append(diff.ActionsRemoved, action)
+		}
+
+		added := difference(desired, existing)
+		removed := difference(existing, desired)
+		if len(added) > 0 || len(removed) > 0 {
+			diff.Members = append(diff.Members, MemberDiff{ActionUri: action, Added: added, Removed: removed})
+		}
+
+		rulesAdded := difference(proposedRules[action], currentRules[action])
+		rulesRemoved := difference(currentRules[action], proposedRules[action])
+		if len(rulesAdded) > 0 || len(rulesRemoved) > 0 {
+			diff.Rules = append(diff.Rules, RuleDiff{ActionUri: action, Added: rulesAdded, Removed: rulesRemoved})
+		}
+	}
+	return diff
+}
+
+func byAction(infos []PolicyInfo, values func(info PolicyInfo) []string) map[string]map[string]bool {
+	found := make(map[string]map[string]bool)
+	for _, info := range infos {
+		for _, action := range info.Actions {
+			if found[action.ActionUri] == nil {
+				found[action.ActionUri] = make(map[string]bool)
+			}
+			for _, value := range values(info) {
+				found[action.ActionUri][value] = true
+			}
+		}
+	}
+	return found
+}
+
+func members(info PolicyInfo) []string {
+	return info.Subject.Members
+}
+
+// rules describes the policy's effect, resource and condition, an empty effect allows.
+func rules(info PolicyInfo) []string {
+	effect := EffectAllow
+	if info.IsDeny() {
+		effect = EffectDeny
+	}
+	rule := fmt.Sprintf("%s on %q", effect, info.Object.ResourceID)
+	if info.HasCondition() {
+		rule += fmt.Sprintf(" when %s", info.Condition.Rule)
+	}
+	return []string{rule}
+}
+
+func difference(from, without map[string]bool) []string {
+	found := make([]string, 0)
+	for member := range from {
+		if !without[member] {
+			found = append(found, member)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+func sortedKeys(maps ...map[string]map[string]bool) []string {
+	seen := make(map[string]bool)
+	keys := make([]string, 0)
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,16 +1,33 @@
 package policysupport
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // todo - longer name used here to simplify a refactoring
 
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
 type PolicyInfo struct {
-	Meta    MetaInfo     `validate:"required"`
-	Actions []ActionInfo `validate:"required"`
-	Subject SubjectInfo  `validate:"required"`
-	Object  ObjectInfo   `validate:"required"`
+	ID        string        // optional, providers map to a native identifier where one exists
+	Meta      MetaInfo      `validate:"required"`
+	Actions   []ActionInfo  `validate:"required"`
+	Subject   SubjectInfo   `validate:"required"`
+	Object    ObjectInfo    `validate:"required"`
+	Effect    string        `validate:"omitempty,oneof=allow deny"`
+	Condition ConditionInfo // optional, an empty rule applies the policy unconditionally
 }
 
 type MetaInfo struct {
-	Version string `validate:"required"`
+	Version  string `validate:"required"`
+	Revision string // optional, the revision of the provider's policies when it tracks one
 }
 
 type ActionInfo struct {
@@ -24,3 +41,63 @@ type SubjectInfo struct {
 type ObjectInfo struct {
 	ResourceID string `validate:"required"`
 }
+
+type ConditionInfo struct {
+	Rule string
+}
+
+func (p PolicyInfo) IsDeny() bool {
+	return p.Effect == EffectDeny
+}
+
+func (p PolicyInfo) HasCondition() bool {
+	return p.Condition.Rule != ""
+}
+
+func RejectConditions(provider string, infos []PolicyInfo) error {
+	for _, info := range infos {
+		if info.HasCondition() {
+			return fmt.Errorf("%s does not support policy conditions, unable to apply condition %q", provider, info.Condition.Rule)
+		}
+	}
+	return nil
+}
+
+func RejectDenyEffects(provider string, infos []PolicyInfo) error {
+	for _, info := range infos {
+		if info.IsDeny() {
+			return fmt.Errorf("%s does not support deny effects, unable to apply deny policy", provider)
+		}
+	}
+	return nil
+}
+
+func EnsureIdentifiers(infos []PolicyInfo) []PolicyInfo {
+	taken := make(map[string]bool)
+	for _, info := range infos {
+		taken[info.ID] = true
+	}
+	identified := make([]PolicyInfo, 0)
+	for _, info := range infos {
+		if info.ID == "" {
+			candidate := identifierFromActions(info.Actions)
+			info.ID = candidate
+			for count := 2; taken[info.ID]; count++ {
+				info.ID = fmt.Sprintf("%s-%d", candidate, count)
+			}
+			taken[info.ID] = true
+		}
+		identified = append(identified, info)
+	}
+	return identified
+}
+
+func identifierFromActions(actions []ActionInfo) string {
+	uris := make([]string, 0)
+	for _, action := range actions {
+		uris = append(uris, action.ActionUri)
+	}
+	sort.Strings(uris)
+	sum := sha256.Sum256([]byte(strings.Join(uris, ",")))
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,111 @@
+package policysupport_test
+
+import (
+	"testing"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRejectConditions(t *testing.T) {
+	unconditional := policysupport.PolicyInfo{Meta: policysupport.MetaInfo{Version: "0.5"}}
+	assert.NoError(t, policysupport.RejectConditions("aProvider", []policysupport.PolicyInfo{unconditional}))
+
+	conditional := policysupport.PolicyInfo{Meta: policysupport.MetaInfo{Version: "0.5"}, Condition: policysupport.ConditionInfo{Rule: "req.ip sw 127"}}
+	err := policysupport.RejectConditions("aProvider", []policysupport.PolicyInfo{unconditional, conditional})
+	assert.EqualError(t, err, "aProvider does not support policy conditions, unable to apply condition \"req.ip sw 127\"")
+}
+
+func TestRejectDenyEffects(t *testing.T) {
+	allow := policysupport.PolicyInfo{Meta: policysupport.MetaInfo{Version: "0.5"}, Effect: policysupport.EffectAllow}
+	unspecified := policysupport.PolicyInfo{Meta: policysupport.MetaInfo{Version: "0.5"}}
+	assert.NoError(t, policysupport.RejectDenyEffects("aProvider", []policysupport.PolicyInfo{allow, unspecified}))
+
+	deny := policysupport.PolicyInfo{Meta: policysupport.MetaInfo{Version: "0.5"}, Effect: policysupport.EffectDeny}
+	err := policysupport.RejectDenyEffects("aProvider", []policysupport.PolicyInfo{allow, deny})
+	assert.EqualError(t, err, "aProvider does not support deny effects, unable to apply deny policy")
+}
+
+func TestEnsureIdentifiers(t *testing.T) {
+	identified := policysupport.PolicyInfo{ID: "anId", Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}}
+	unidentified := policysupport.PolicyInfo{Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}}
+	duplicate := policysupport.PolicyInfo{Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}}
+
+	infos := policysupport.EnsureIdentifiers([]policysupport.PolicyInfo{identified, unidentified, duplicate})
+	assert.Equal(t, "anId", infos[0].ID)
+	assert.Equal(t, "b865eaa9a741", infos[1].ID)
+	assert.Equal(t, "b865eaa9a741-2", infos[2].ID)
+
+	again := policysupport.EnsureIdentifiers([]policysupport.PolicyInfo{unidentified})
+	assert.Equal(t, infos[1].ID, again[0].ID)
+}
+
+func TestTranslateMembersAndActions(t *testing.T) {
+	infos := []policysupport.PolicyInfo{{Actions: []policysupport.ActionInfo{{ActionUri: "anAction"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}}}
+
+	members, _ := policysupport.TranslateMembers(infos, func(member string) (string, error) { return "user:" + member, nil })
+	assert.Equal(t, []string{"user:aUser"}, members[0].Subject.Members)
+	assert.Equal(t, []string{"aUser"}, infos[0].Subject.Members)
+
+	actions, _ := policysupport.TranslateActions(infos, func(actionUri string) (string, error) { return "hexa:" + actionUri, nil })
+	assert.Equal(t, "hexa:anAction", actions[0].Actions[0].ActionUri)
+
+	_, err := policysupport.TranslateMembers(infos, func(member string) (string, error) {
+		return "", policysupport.UntranslatableMember("aProvider", member)
+	})
+	assert.EqualError(t, err, "aProvider is unable to represent member \"aUser\"")
+
+	_, err = policysupport.TranslateActions(infos, func(actionUri string) (string, error) {
+		return "", policysupport.UntranslatableAction("aProvider", actionUri)
+	})
+	assert.EqualError(t, err, "aProvider is unable to represent action \"anAction\"")
+}
+
+func TestDiff(t *testing.T) {
+	current := []policysupport.PolicyInfo{
+		{Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser", "anotherUser"}}},
+		{Actions: []policysupport.ActionInfo{{ActionUri: "http:DELETE"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}},
+	}
+	proposed := []policysupport.PolicyInfo{
+		{Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}, {ActionUri: "http:POST"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser", "yetAnotherUser"}}},
+	}
+
+	diff := policysupport.Diff(current, proposed)
+	assert.Equal(t, []string{"http:POST"}, diff.ActionsAdded)
+	assert.Equal(t, []string{"http:DELETE"}, diff.ActionsRemoved)
+	assert.Equal(t, []policysupport.MemberDiff{
+		{ActionUri: "http:DELETE", Added: []string{}, Removed: []string{"aUser"}},
+		{ActionUri: "http:GET", Added: []string{"yetAnotherUser"}, Removed: []string{"anotherUser"}},
+		{ActionUri: "http:POST", Added: []string{"aUser", "yetAnotherUser"}, Removed: []string{}},
+	}, diff.Members)
+	assert.False(t, diff.IsEmpty())
+
+	assert.True(t, policysupport.Diff(current, current).IsEmpty())
+}
+
+func TestDiff_withRules(t *testing.T) {
+	current := []policysupport.PolicyInfo{
+		{Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{ResourceID: "aResource"}},
+		{Actions: []policysupport.ActionInfo{{ActionUri: "http:POST"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{ResourceID: "aResource"}},
+	}
+	proposed := []policysupport.PolicyInfo{
+		{Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{ResourceID: "aResource"}, Condition: policysupport.ConditionInfo{Rule: "req.ip sw 127"}},
+		{Actions: []policysupport.ActionInfo{{ActionUri: "http:POST"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{ResourceID: "aResource"}, Effect: policysupport.EffectDeny},
+	}
+
+	diff := policysupport.Diff(current, proposed)
+	assert.Equal(t, []policysupport.MemberDiff{}, diff.Members)
+	assert.Equal(t, []policysupport.RuleDiff{
+		{ActionUri: "http:GET", Added: []string{`allow on "aResource" when req.ip sw 127`}, Removed: []string{`allow on "aResource"`}},
+		{ActionUri: "http:POST", Added: []string{`deny on "aResource"`}, Removed: []string{`allow on "aResource"`}},
+	}, diff.Rules)
+	assert.False(t, diff.IsEmpty())
+
+	moved := []policysupport.PolicyInfo{
+		{Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}, {ActionUri: "http:POST"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser"}}, Object: policysupport.ObjectInfo{ResourceID: "anotherResource"}, Effect: policysupport.EffectAllow},
+	}
+	assert.Equal(t, []policysupport.RuleDiff{
+		{ActionUri: "http:GET", Added: []string{`allow on "anotherResource"`}, Removed: []string{`allow on "aResource"`}},
+		{ActionUri: "http:POST", Added: []string{`allow on "anotherResource"`}, Removed: []string{`allow on "aResource"`}},
+	}, policysupport.Diff(current, moved).Rules)
+}
@@ -0,0 +1,60 @@
+package policysupport
+
+import "fmt"
+
+// Canonical policies are provider neutral and are used to move policies between providers.
+// Members take the forms user:<email>, group:<name>, id:<identifier>:<display name> for
+// identities only meaningful within a single provider, or one of allusers and allauthenticated.
+// The canonical access action grants access to a resource without finer grained permissions.
+
+const (
+	CanonicalAccessAction     = "hexa:access"
+	CanonicalAllUsers         = "allusers"
+	CanonicalAllAuthenticated = "allauthenticated"
+
+	CanonicalUserPrefix     = "user:"
+	CanonicalGroupPrefix    = "group:"
+	CanonicalIdentityPrefix = "id:"
+)
+
+func TranslateMembers(infos []PolicyInfo, translate func(member string) (string, error)) ([]PolicyInfo, error) {
+	translated := make([]PolicyInfo, 0)
+	for _, info := range infos {
+		members := make([]string, 0)
+		for _, member := range info.Subject.Members {
+			found, err := translate(member)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, found)
+		}
+		info.Subject = SubjectInfo{Members: members}
+		translated = append(translated, info)
+	}
+	return translated, nil
+}
+
+func TranslateActions(infos []PolicyInfo, translate func(actionUri string) (string, error)) ([]PolicyInfo, error) {
+	translated := make([]PolicyInfo, 0)
+	for _, info := range infos {
+		actions := make([]ActionInfo, 0)
+		for _, action := range info.Actions {
+			found, err := translate(action.ActionUri)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, ActionInfo{ActionUri: found})
+		}
+		info.Actions = actions
+		translated = append(translated, info)
+	}
+	return translated, nil
+}
+
+func UntranslatableMember(provider, member string) error {
+	return fmt.Errorf("%s is unable to represent member %q", provider, member)
+}
+
+func UntranslatableAction(provider, actionUri string) error {
+	return fmt.Errorf("%s is unable to represent action %q", provider, actionUri)
+}
@@ -0,0 +1,330 @@
+package policytext
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
+)
+
+const DefaultVersion = "0.5"
+
+type ParseError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// Parse reads policies written in the compact textual syntax, for example
+//
+//	policy "aPolicyId" version "0.5"
+//	permit subject in ["aUser", "anotherUser"]
+//	    action in ["http:GET"]
+//	    on resource "aResourceId"
+//	    when "req.ip sw 127";
+//
+// The policy and version clauses are optional, version defaults to DefaultVersion.
+// Lines starting with # are comments.
+func Parse(text string) ([]policysupport.PolicyInfo, error) {
+	p := parser{lexer: lexer{text: text, line: 1, column: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	infos := make([]policysupport.PolicyInfo, 0)
+	for p.current.kind != eof {
+		info, err := p.policy()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+type parser struct {
+	lexer   lexer
+	current token
+}
+
+func (p *parser) advance() error {
+	next, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.current = next
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return ParseError{Line: p.current.line, Column: p.current.column, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) keyword(word string) bool {
+	return p.current.kind == identifier && p.current.value == word
+}
+
+func (p *parser) expectKeyword(word string) error {
+	if !p.keyword(word) {
+		return p.errorf("expected %q, found %s", word, p.current)
+	}
+	return p.advance()
+}
+
+func (p *parser) expect(kind tokenKind) (string, error) {
+	if p.current.kind != kind {
+		return "", p.errorf("expected %s, found %s", kind, p.current)
+	}
+	value := p.current.value
+	return value, p.advance()
+}
+
+func (p *parser) policy() (policysupport.PolicyInfo, error) {
+	info := policysupport.PolicyInfo{Meta: policysupport.MetaInfo{Version: DefaultVersion}}
+
+	if p.keyword("policy") {
+		if err := p.advance(); err != nil {
+			return info, err
+		}
+		id, err := p.expect(str)
+		if err != nil {
+			return info, err
+		}
+		info.ID = id
+	}
+
+	if p.keyword("version") {
+		if err := p.advance(); err != nil {
+			return info, err
+		}
+		version, err := p.expect(str)
+		if err != nil {
+			return info, err
+		}
+		info.Meta.Version = version
+	}
+
+	switch {
+	case p.keyword("permit"):
+		info.Effect = policysupport.EffectAllow
+	case p.keyword("deny"):
+		info.Effect = policysupport.EffectDeny
+	default:
+		return info, p.errorf("expected \"permit\" or \"deny\", found %s", p.current)
+	}
+	if err := p.advance(); err != nil {
+		return info, err
+	}
+
+	for _, word := range []string{"subject", "in"} {
+		if err := p.expectKeyword(word); err != nil {
+			return info, err
+		}
+	}
+	members, err := p.list()
+	if err != nil {
+		return info, err
+	}
+	info.Subject = policysupport.SubjectInfo{Members: members}
+
+	for _, word := range []string{"action", "in"} {
+		if err := p.expectKeyword(word); err != nil {
+			return info, err
+		}
+	}
+	actions, err := p.list()
+	if err != nil {
+		return info, err
+	}
+	for _, action := range actions {
+		info.Actions = append(info.Actions, policysupport.ActionInfo{ActionUri: action})
+	}
+
+	for _, word := range []string{"on", "resource"} {
+		if err := p.expectKeyword(word); err != nil {
+			return info, err
+		}
+	}
+	resource, err := p.expect(str)
+	if err != nil {
+		return info, err
+	}
+	info.Object = policysupport.ObjectInfo{ResourceID: resource}
+
+	if p.keyword("when") {
+		if err := p.advance(); err != nil {
+			return info, err
+		}
+		rule, err := p.expect(str)
+		if err != nil {
+			return info, err
+		}
+		info.Condition = policysupport.ConditionInfo{Rule: rule}
+	}
+
+	_, err = p.expect(semicolon)
+	return info, err
+}
+
+func (p *parser) list() ([]string, error) {
+	if _, err := p.expect(openBracket); err != nil {
+		return nil, err
+	}
+	values := make([]string, 0)
+	for p.current.kind != closeBracket {
+		value, err := p.expect(str)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if p.current.kind != comma {
+			break
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	_, err := p.expect(closeBracket)
+	return values, err
+}
+
+///
+
+type tokenKind string
+
+const (
+	eof          tokenKind = "end of input"
+	identifier   tokenKind = "keyword"
+	str          tokenKind = "string"
+	openBracket  tokenKind = "\"[\""
+	closeBracket tokenKind = "\"]\""
+	comma        tokenKind = "\",\""
+	semicolon    tokenKind = "\";\""
+)
+
+type token struct {
+	kind   tokenKind
+	value  string
+	line   int
+	column int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case identifier:
+		return fmt.Sprintf("%q", t.value)
+	case str:
+		return "string " + strconv.Quote(t.value)
+	}
+	return string(t.kind)
+}
+
+type lexer struct {
+	text   string
+	offset int
+	line   int
+	column int
+}
+
+func (l *lexer) peek() rune {
+	r, _ := utf8.DecodeRuneInString(l.text[l.offset:])
+	return r
+}
+
+func (l *lexer) read() rune {
+	r, size := utf8.DecodeRuneInString(l.text[l.offset:])
+	l.offset += size
+	if r == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return r
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpaceAndComments()
+	start := token{line: l.line, column: l.column}
+	if l.offset >= len(l.text) {
+		start.kind = eof
+		return start, nil
+	}
+
+	r := l.peek()
+	switch {
+	case r == '[':
+		l.read()
+		start.kind = openBracket
+	case r == ']':
+		l.read()
+		start.kind = closeBracket
+	case r == ',':
+		l.read()
+		start.kind = comma
+	case r == ';':
+		l.read()
+		start.kind = semicolon
+	case r == '"':
+		value, err := l.quoted(start)
+		if err != nil {
+			return start, err
+		}
+		start.kind = str
+		start.value = value
+	case unicode.IsLetter(r):
+		begin := l.offset
+		for l.offset < len(l.text) && (unicode.IsLetter(l.peek()) || unicode.IsDigit(l.peek()) || l.peek() == '_') {
+			l.read()
+		}
+		start.kind = identifier
+		start.value = l.text[begin:l.offset]
+	default:
+		return start, ParseError{Line: start.line, Column: start.column, Message: fmt.Sprintf("unexpected character %q", r)}
+	}
+	return start, nil
+}
+
+func (l *lexer) quoted(start token) (string, error) {
+	begin := l.offset
+	l.read()
+	for l.offset < len(l.text) {
+		switch l.read() {
+		case '\\':
+			if l.offset < len(l.text) {
+				l.read()
+			}
+		case '\n':
+			return "", ParseError{Line: start.line, Column: start.column, Message: "unterminated string"}
+		case '"':
+			value, err := strconv.Unquote(l.text[begin:l.offset])
+			if err != nil {
+				return "", ParseError{Line: start.line, Column: start.column, Message: "invalid string " + l.text[begin:l.offset]}
+			}
+			return value, nil
+		}
+	}
+	return "", ParseError{Line: start.line, Column: start.column, Message: "unterminated string"}
+}
+
+func (l *lexer) skipSpaceAndComments() {
+	for l.offset < len(l.text) {
+		r := l.peek()
+		switch {
+		case unicode.IsSpace(r):
+			l.read()
+		case r == '#':
+			for l.offset < len(l.text) && l.peek() != '\n' {
+				l.read()
+			}
+		default:
+			return
+		}
+	}
+}
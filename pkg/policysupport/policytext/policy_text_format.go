@@ -0,0 +1,48 @@
+package policytext
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
+)
+
+// Format writes policies in the textual syntax read by Parse.
+func Format(infos []policysupport.PolicyInfo) string {
+	var builder strings.Builder
+	for index, info := range infos {
+		if index > 0 {
+			builder.WriteString("\n")
+		}
+		if info.ID != "" {
+			builder.WriteString("policy " + strconv.Quote(info.ID) + " ")
+		}
+		builder.WriteString("version " + strconv.Quote(info.Meta.Version) + "\n")
+
+		effect := "permit"
+		if info.IsDeny() {
+			effect = "deny"
+		}
+		builder.WriteString(effect + " subject in " + list(info.Subject.Members) + "\n")
+
+		actions := make([]string, 0)
+		for _, action := range info.Actions {
+			actions = append(actions, action.ActionUri)
+		}
+		builder.WriteString("    action in " + list(actions) + "\n")
+		builder.WriteString("    on resource " + strconv.Quote(info.Object.ResourceID))
+		if info.HasCondition() {
+			builder.WriteString("\n    when " + strconv.Quote(info.Condition.Rule))
+		}
+		builder.WriteString(";\n")
+	}
+	return builder.String()
+}
+
+func list(values []string) string {
+	quoted := make([]string, 0)
+	for _, value := range values {
+		quoted = append(quoted, strconv.Quote(value))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
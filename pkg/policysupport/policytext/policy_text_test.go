@@ -0,0 +1,88 @@
+package policytext_test
+
+import (
+	"testing"
+
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport"
+	"github.com/hexa-org/policy-orchestrator/pkg/policysupport/policytext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	text := `
+# a comment
+policy "aPolicyId" version "0.6"
+permit subject in ["aUser", "anotherUser"]
+    action in ["http:GET", "http:POST"]
+    on resource "aResourceId";
+
+deny subject in ["allusers"] action in ["http:DELETE"] on resource "aResourceId" when "req.ip sw 127";
+`
+	infos, err := policytext.Parse(text)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(infos))
+
+	assert.Equal(t, "aPolicyId", infos[0].ID)
+	assert.Equal(t, "0.6", infos[0].Meta.Version)
+	assert.Equal(t, policysupport.EffectAllow, infos[0].Effect)
+	assert.Equal(t, []string{"aUser", "anotherUser"}, infos[0].Subject.Members)
+	assert.Equal(t, []policysupport.ActionInfo{{ActionUri: "http:GET"}, {ActionUri: "http:POST"}}, infos[0].Actions)
+	assert.Equal(t, "aResourceId", infos[0].Object.ResourceID)
+	assert.False(t, infos[0].HasCondition())
+
+	assert.Equal(t, "", infos[1].ID)
+	assert.Equal(t, policytext.DefaultVersion, infos[1].Meta.Version)
+	assert.Equal(t, policysupport.EffectDeny, infos[1].Effect)
+	assert.Equal(t, "req.ip sw 127", infos[1].Condition.Rule)
+}
+
+func TestParse_withEmpty(t *testing.T) {
+	infos, err := policytext.Parse("  # nothing here\n")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(infos))
+}
+
+func TestParse_withErrors(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{`allow subject in ["aUser"];`, `line 1, column 1: expected "permit" or "deny", found "allow"`},
+		{"permit subject in [\"aUser\"]\n    action [\"http:GET\"]", `line 2, column 12: expected "in", found "["`},
+		{`permit subject in ["aUser" "anotherUser"]`, `line 1, column 28: expected "]", found string "anotherUser"`},
+		{`permit subject in ["aUser"] action in ["http:GET"] on resource "anId"`, `line 1, column 70: expected ";", found end of input`},
+		{`permit subject in ["aUser`, `line 1, column 20: unterminated string`},
+		{"permit subject in [\"aUser\"] @", `line 1, column 29: unexpected character '@'`},
+	}
+	for _, test := range tests {
+		_, err := policytext.Parse(test.text)
+		assert.EqualError(t, err, test.expected)
+
+		var parseErr policytext.ParseError
+		assert.ErrorAs(t, err, &parseErr)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	infos := []policysupport.PolicyInfo{
+		{ID: "aPolicyId", Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:GET"}}, Subject: policysupport.SubjectInfo{Members: []string{"aUser", "anotherUser"}}, Object: policysupport.ObjectInfo{ResourceID: "aResourceId"}},
+		{Meta: policysupport.MetaInfo{Version: "0.5"}, Actions: []policysupport.ActionInfo{{ActionUri: "http:DELETE"}}, Subject: policysupport.SubjectInfo{Members: []string{"allusers"}}, Object: policysupport.ObjectInfo{ResourceID: "aResourceId"}, Effect: policysupport.EffectDeny, Condition: policysupport.ConditionInfo{Rule: "req.ip sw \"127\""}},
+	}
+	expected := `policy "aPolicyId" version "0.5"
+permit subject in ["aUser", "anotherUser"]
+    action in ["http:GET"]
+    on resource "aResourceId";
+
+version "0.5"
+deny subject in ["allusers"]
+    action in ["http:DELETE"]
+    on resource "aResourceId"
+    when "req.ip sw \"127\"";
+`
+	assert.Equal(t, expected, policytext.Format(infos))
+
+	parsed, err := policytext.Parse(policytext.Format(infos))
+	assert.NoError(t, err)
+	assert.Equal(t, "req.ip sw \"127\"", parsed[1].Condition.Rule)
+	assert.Equal(t, policytext.Format(infos), policytext.Format(parsed))
+}
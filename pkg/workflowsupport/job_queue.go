@@ -0,0 +1,144 @@
+package workflowsupport
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// JobWorker runs claimed jobs, an error fails the attempt.
+type JobWorker interface {
+	RunJob(job Job) error
+}
+
+// ErrPermanent identifies errors that no retry can fix, a job failing with one is dead-lettered at once.
+var ErrPermanent = errors.New("permanent job failure")
+
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+func (e permanentError) Is(target error) bool {
+	return target == ErrPermanent
+}
+
+// Permanent marks a job's error as one that no retry can fix, keeping its message.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+type JobRequest struct {
+	Kind    string
+	Key     string
+	Payload []byte
+}
+
+// JobFinder finds recurring work, its requests are enqueued each time the scheduler ticks.
+type JobFinder interface {
+	FindJobs() []JobRequest
+}
+
+// Backoff doubles the delay before each retry, starting at Initial and capped at Max.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Initial
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		return b.Max
+	}
+	return delay
+}
+
+const (
+	DefaultMaxAttempts = 5
+	DefaultRunners     = 4
+	DefaultStaleAfter  = 30 * time.Minute
+)
+
+var DefaultBackoff = Backoff{Initial: time.Second, Max: 10 * time.Minute}
+
+// JobQueue runs stored jobs with the worker registered for their kind, retrying failed attempts with exponential
+// backoff. Jobs failing MaxAttempts times, or with a Permanent error, are dead-lettered. Replica names the claims of this process, jobs still
+// running after StaleAfter are presumed abandoned by their replica.
+type JobQueue struct {
+	Store       JobStore
+	Workers     map[string]JobWorker
+	MaxAttempts int
+	Backoff     Backoff
+	Runners     int
+	Replica     string
+	StaleAfter  time.Duration
+}
+
+func NewJobQueue(db *sql.DB, workers map[string]JobWorker) *JobQueue {
+	return &JobQueue{
+		Store:       JobStore{DB: db},
+		Workers:     workers,
+		MaxAttempts: DefaultMaxAttempts,
+		Backoff:     DefaultBackoff,
+		Runners:     DefaultRunners,
+		StaleAfter:  DefaultStaleAfter,
+	}
+}
+
+func (q *JobQueue) Enqueue(request JobRequest) (string, error) {
+	if _, found := q.Workers[request.Kind]; !found {
+		return "", fmt.Errorf("no worker for %s jobs", request.Kind)
+	}
+	return q.Store.Enqueue(request.Kind, request.Key, request.Payload, q.MaxAttempts)
+}
+
+// RunNext runs the next due job, it returns false when no job is due.
+func (q *JobQueue) RunNext() (bool, error) {
+	kinds := make([]string, 0, len(q.Workers))
+	for kind := range q.Workers {
+		kinds = append(kinds, kind)
+	}
+	job, err := q.Store.Claim(kinds, q.Replica)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if runErr := q.run(job); runErr != nil {
+		if errors.Is(runErr, ErrPermanent) {
+			log.Printf("Giving up on %s job %s, it cannot succeed: %s", job.Kind, job.ID, runErr.Error())
+			return true, q.Store.DeadLetter(job.ID, runErr.Error())
+		}
+		state, failErr := q.Store.Fail(job.ID, runErr.Error(), q.Backoff.Delay(job.Attempts))
+		if failErr != nil {
+			return true, failErr
+		}
+		if state == JobDead {
+			log.Printf("Giving up on %s job %s after %d attempts: %s", job.Kind, job.ID, job.Attempts, runErr.Error())
+		}
+		return true, nil
+	}
+	return true, q.Store.Succeed(job.ID)
+}
+
+func (q *JobQueue) run(job Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%s job panicked: %v", job.Kind, recovered)
+		}
+	}()
+	return q.Workers[job.Kind].RunJob(job)
+}
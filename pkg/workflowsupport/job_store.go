@@ -0,0 +1,146 @@
+package workflowsupport
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed" // the last attempt failed, the job is retried at RunAt
+	JobDead      = "dead"   // every attempt failed, or one failed permanently, the job is no longer retried
+)
+
+type Job struct {
+	ID          string
+	Kind        string
+	Key         string
+	Payload     []byte
+	State       string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LastError   string
+	ClaimedBy   string
+	Progress    int    // percent complete, as reported by the job's worker
+	Result      []byte // json reported by the job's worker
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// JobStore keeps jobs in Postgres so queued and failed jobs survive a restart.
+type JobStore struct {
+	DB *sql.DB
+}
+
+const jobColumns = "id, kind, coalesce(key, ''), payload, state, attempts, max_attempts, run_at, coalesce(last_error, ''), coalesce(claimed_by, ''), progress, result, created_at, updated_at"
+
+// Enqueue queues a job to run now. Jobs with a key are queued at most once per kind, enqueueing a key that is already
+// queued, running or awaiting a retry returns the existing job's id.
+func (store JobStore) Enqueue(kind string, key string, payload []byte, maxAttempts int) (string, error) {
+	var id string
+	err := store.DB.QueryRow(`insert into jobs (kind, key, payload, max_attempts) values ($1, nullif($2, ''), $3, $4)
+on conflict (kind, key) where state in ('queued', 'running', 'failed') do nothing returning id`,
+		kind, key, payload, maxAttempts).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = store.DB.QueryRow("select id from jobs where kind=$1 and key=$2 and state in ('queued', 'running', 'failed')", kind, key).Scan(&id)
+	}
+	return id, err
+}
+
+// Claim marks the next due job of the given kinds as running by the replica and counts the attempt, sql.ErrNoRows when
+// none are due. Locked jobs are skipped so several runners and replicas may claim concurrently.
+func (store JobStore) Claim(kinds []string, replica string) (Job, error) {
+	return scanJob(store.DB.QueryRow(`update jobs set state='running', attempts=attempts+1, claimed_by=$2, updated_at=now()
+where id = (select id from jobs where state in ('queued', 'failed') and run_at <= now() and kind = any($1) order by run_at for update skip locked limit 1)
+returning `+jobColumns, pq.Array(kinds), replica))
+}
+
+func (store JobStore) Succeed(id string) error {
+	_, err := store.DB.Exec("update jobs set state='succeeded', progress=100, last_error=null, updated_at=now() where id=$1", id)
+	return err
+}
+
+// Report records a running job's progress and its json result so far.
+func (store JobStore) Report(id string, progress int, result []byte) error {
+	_, err := store.DB.Exec("update jobs set progress=$2, result=$3, updated_at=now() where id=$1", id, progress, result)
+	return err
+}
+
+// Fail records a failed attempt, the job is retried after retryIn unless its attempts are exhausted.
+// Returns the job's new state, JobFailed or JobDead.
+func (store JobStore) Fail(id string, message string, retryIn time.Duration) (string, error) {
+	var state string
+	err := store.DB.QueryRow(`update jobs set state = case when attempts >= max_attempts then 'dead' else 'failed' end,
+run_at=now() + make_interval(secs => $3), last_error=$2, updated_at=now() where id=$1 returning state`, id, message, retryIn.Seconds()).Scan(&state)
+	return state, err
+}
+
+// DeadLetter records a failed attempt of a job that is not to be retried.
+func (store JobStore) DeadLetter(id string, message string) error {
+	_, err := store.DB.Exec("update jobs set state='dead', last_error=$2, updated_at=now() where id=$1", id, message)
+	return err
+}
+
+// Requeue returns jobs the replica was running to the queue, they were interrupted when the replica stopped.
+func (store JobStore) Requeue(replica string) (int, error) {
+	return store.requeue("update jobs set state='queued', run_at=now(), updated_at=now() where state='running' and claimed_by=$1", replica)
+}
+
+// RequeueStale returns jobs running for longer than olderThan to the queue, their replica is presumed dead.
+func (store JobStore) RequeueStale(olderThan time.Duration) (int, error) {
+	return store.requeue("update jobs set state='queued', run_at=now(), updated_at=now() where state='running' and updated_at < now() - make_interval(secs => $1)", olderThan.Seconds())
+}
+
+func (store JobStore) requeue(statement string, arg interface{}) (int, error) {
+	result, err := store.DB.Exec(statement, arg)
+	if err != nil {
+		return 0, err
+	}
+	requeued, _ := result.RowsAffected()
+	return int(requeued), nil
+}
+
+func (store JobStore) FindById(id string) (Job, error) {
+	return scanJob(store.DB.QueryRow("select "+jobColumns+" from jobs where id=$1", id))
+}
+
+func (store JobStore) FindByState(state string) ([]Job, error) {
+	return store.find("select "+jobColumns+" from jobs where state=$1 order by created_at", state)
+}
+
+// FindByKind returns the most recently created jobs of a kind, newest first.
+func (store JobStore) FindByKind(kind string, limit int) ([]Job, error) {
+	return store.find("select "+jobColumns+" from jobs where kind=$1 order by created_at desc limit $2", kind, limit)
+}
+
+func (store JobStore) find(query string, args ...interface{}) ([]Job, error) {
+	rows, err := store.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	jobs := make([]Job, 0)
+	for rows.Next() {
+		job, erroneousScan := scanJob(rows)
+		if erroneousScan != nil {
+			return nil, erroneousScan
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func scanJob(row interface{ Scan(...interface{}) error }) (Job, error) {
+	var job Job
+	err := row.Scan(&job.ID, &job.Kind, &job.Key, &job.Payload, &job.State, &job.Attempts, &job.MaxAttempts, &job.RunAt, &job.LastError, &job.ClaimedBy, &job.Progress, &job.Result, &job.CreatedAt, &job.UpdatedAt)
+	return job, err
+}
@@ -0,0 +1,108 @@
+package workflowsupport
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+type Lease struct {
+	Name       string
+	Holder     string
+	AcquiredAt time.Time
+	ExpiresAt  time.Time
+}
+
+// LeaseStore keeps named leases in Postgres, times are taken from the database so replicas' clocks may differ.
+type LeaseStore struct {
+	DB *sql.DB
+}
+
+// Acquire takes the lease when it is free or expired, or renews it when already held. Returns whether holder holds it.
+func (store LeaseStore) Acquire(name string, holder string, ttl time.Duration) (bool, error) {
+	var found string
+	err := store.DB.QueryRow(`insert into leases (name, holder, expires_at) values ($1, $2, now() + make_interval(secs => $3))
+on conflict (name) do update set holder=excluded.holder, expires_at=excluded.expires_at,
+acquired_at = case when leases.holder = excluded.holder then leases.acquired_at else now() end
+where leases.holder = excluded.holder or leases.expires_at < now() returning holder`, name, holder, ttl.Seconds()).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (store LeaseStore) Release(name string, holder string) error {
+	_, err := store.DB.Exec("delete from leases where name=$1 and holder=$2", name, holder)
+	return err
+}
+
+// Find returns the unexpired lease, sql.ErrNoRows when nobody holds it.
+func (store LeaseStore) Find(name string) (Lease, error) {
+	var lease Lease
+	err := store.DB.QueryRow("select name, holder, acquired_at, expires_at from leases where name=$1 and expires_at > now()", name).
+		Scan(&lease.Name, &lease.Holder, &lease.AcquiredAt, &lease.ExpiresAt)
+	return lease, err
+}
+
+const DefaultLeaseTTL = 30 * time.Second
+
+// Leader elects one of several replicas by holding a lease. The lease is renewed while the replica runs and expires
+// when it dies, at which point another replica takes over.
+type Leader struct {
+	Store  LeaseStore
+	Name   string
+	Holder string
+	TTL    time.Duration
+
+	leading int32
+}
+
+func NewLeader(db *sql.DB, name string, holder string) *Leader {
+	return &Leader{Store: LeaseStore{DB: db}, Name: name, Holder: holder, TTL: DefaultLeaseTTL}
+}
+
+// Lead acquires or renews the lease, returning whether this replica leads.
+func (l *Leader) Lead() bool {
+	acquired, err := l.Store.Acquire(l.Name, l.Holder, l.TTL)
+	if err != nil {
+		log.Printf("Unable to acquire %s lease: %s", l.Name, err.Error())
+	}
+	if was := atomic.SwapInt32(&l.leading, boolToInt32(acquired)); was != boolToInt32(acquired) {
+		if acquired {
+			log.Printf("Replica %s now leads %s.", l.Holder, l.Name)
+		} else {
+			log.Printf("Replica %s no longer leads %s.", l.Holder, l.Name)
+		}
+	}
+	return acquired
+}
+
+func (l *Leader) IsLeader() bool {
+	return atomic.LoadInt32(&l.leading) == 1
+}
+
+// Resign releases the lease so another replica may take over without waiting for it to expire.
+func (l *Leader) Resign() {
+	atomic.StoreInt32(&l.leading, 0)
+	if err := l.Store.Release(l.Name, l.Holder); err != nil {
+		log.Printf("Unable to release %s lease: %s", l.Name, err.Error())
+	}
+}
+
+// Current returns the replica leading now, empty when the lease has expired.
+func (l *Leader) Current() (string, error) {
+	lease, err := l.Store.Find(l.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return lease.Holder, err
+}
+
+func boolToInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -18,11 +18,17 @@ type WorkFinder interface {
 	Stop()
 }
 
+// WorkScheduler runs found work in memory, or with a job queue enqueues found jobs and runs them from the queue.
 type WorkScheduler struct {
 	Finder  interface{}
 	Workers []Worker
 	Delay   int64
 
+	Queue     *JobQueue
+	Finders   []JobFinder
+	PollDelay int64
+	Leader    *Leader // when set, only the leading replica enqueues found jobs
+
 	done chan bool
 }
 
@@ -35,8 +41,23 @@ func NewScheduler(finder interface{}, workers []Worker, delay int64) WorkSchedul
 	}
 }
 
+// NewJobScheduler enqueues found jobs every delay and polls the queue for due jobs every pollDelay milliseconds.
+func NewJobScheduler(queue *JobQueue, finders []JobFinder, delay int64, pollDelay int64) WorkScheduler {
+	return WorkScheduler{
+		Queue:     queue,
+		Finders:   finders,
+		Delay:     delay,
+		PollDelay: pollDelay,
+		done:      make(chan bool),
+	}
+}
+
 func (ws *WorkScheduler) Start() {
 	log.Printf("Starting the scheduler.\n")
+	if ws.Queue != nil {
+		ws.startQueue()
+		return
+	}
 	ticker := time.NewTicker(time.Duration(ws.Delay) * time.Millisecond)
 	for _, w := range ws.Workers {
 		go func(worker Worker) {
@@ -71,8 +92,103 @@ func (ws *WorkScheduler) checkForWork(worker Worker) {
 	}
 }
 
+func (ws *WorkScheduler) startQueue() {
+	if requeued, err := ws.Queue.Store.Requeue(ws.Queue.Replica); err != nil {
+		log.Printf("Unable to requeue interrupted jobs: %s", err.Error())
+	} else if requeued > 0 {
+		log.Printf("Requeued %d interrupted jobs.", requeued)
+	}
+
+	if ws.Leader != nil {
+		ws.Leader.Lead()
+		leaseTicker := time.NewTicker(ws.Leader.TTL / 3)
+		go func() {
+			defer leaseTicker.Stop()
+			for {
+				select {
+				case <-ws.done:
+					ws.Leader.Resign()
+					return
+				case <-leaseTicker.C:
+					ws.Leader.Lead()
+				}
+			}
+		}()
+	}
+
+	finderTicker := time.NewTicker(time.Duration(ws.Delay) * time.Millisecond)
+	go func() {
+		defer finderTicker.Stop()
+		for {
+			select {
+			case <-ws.done:
+				return
+			case <-finderTicker.C:
+				ws.enqueueFound()
+			}
+		}
+	}()
+
+	runners := ws.Queue.Runners
+	if runners < 1 {
+		runners = 1
+	}
+	for i := 0; i < runners; i++ {
+		go func() {
+			pollTicker := time.NewTicker(time.Duration(ws.PollDelay) * time.Millisecond)
+			defer pollTicker.Stop()
+			for {
+				select {
+				case <-ws.done:
+					return
+				case <-pollTicker.C:
+					ws.runDue()
+				}
+			}
+		}()
+	}
+}
+
+func (ws *WorkScheduler) enqueueFound() {
+	if ws.Leader != nil && !ws.Leader.IsLeader() {
+		return
+	}
+	if requeued, err := ws.Queue.Store.RequeueStale(ws.Queue.StaleAfter); err != nil {
+		log.Printf("Unable to requeue stale jobs: %s", err.Error())
+	} else if requeued > 0 {
+		log.Printf("Requeued %d stale jobs.", requeued)
+	}
+	for _, finder := range ws.Finders {
+		for _, request := range finder.FindJobs() {
+			if _, err := ws.Queue.Enqueue(request); err != nil {
+				log.Printf("Unable to enqueue %s job: %s", request.Kind, err.Error())
+			}
+		}
+	}
+}
+
+func (ws *WorkScheduler) runDue() {
+	for {
+		select {
+		case <-ws.done:
+			return
+		default:
+		}
+		ran, err := ws.Queue.RunNext()
+		if err != nil {
+			log.Printf("Unable to run job: %s", err.Error())
+			return
+		}
+		if !ran {
+			return
+		}
+	}
+}
+
 func (ws *WorkScheduler) Stop() {
-	ws.done <- true
-	ws.Finder.(WorkFinder).Stop()
+	close(ws.done)
+	if finder, ok := ws.Finder.(WorkFinder); ok {
+		finder.Stop()
+	}
 	log.Printf("Scheduler stopped.\n")
 }